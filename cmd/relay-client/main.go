@@ -4,7 +4,9 @@ import (
 	"context"
 	"crypto/rand"
 	"encoding/base64"
+	"fmt"
 	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
@@ -12,83 +14,92 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/QuantumSolver/crm-relay/internal/adminapi"
 	"github.com/QuantumSolver/crm-relay/internal/auth"
 	"github.com/QuantumSolver/crm-relay/internal/config"
+	"github.com/QuantumSolver/crm-relay/internal/logging"
+	"github.com/QuantumSolver/crm-relay/internal/metrics"
+	"github.com/QuantumSolver/crm-relay/internal/models"
 	relayclientpkg "github.com/QuantumSolver/crm-relay/internal/relay-client"
 	"github.com/QuantumSolver/crm-relay/internal/storage"
+	"github.com/QuantumSolver/crm-relay/internal/tracing"
 )
 
 func main() {
-	log.Println("Starting CRM Relay Client...")
-
 	// Load configuration
 	cfg, err := config.Load()
 	if err != nil {
 		log.Fatalf("Failed to load configuration: %v", err)
 	}
 
-	log.Printf("Configuration loaded: RedisURL=%s, StreamName=%s, LocalWebhookURL=%s",
-		cfg.RedisURL, cfg.StreamName, cfg.LocalWebhookURL)
+	logging.Setup(cfg, "crm-relay-client")
+	slog.Info("Starting CRM Relay Client",
+		"redis_url", cfg.RedisURL, "stream", cfg.StreamName, "local_webhook_url", cfg.LocalWebhookURL)
+
+	// Set up tracing
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg, "crm-relay-client")
+	if err != nil {
+		fatal("Failed to set up tracing", err)
+	}
 
 	// Initialize Redis client
 	redisClient, err := storage.NewRedisClient(cfg)
 	if err != nil {
-		log.Fatalf("Failed to initialize Redis client: %v", err)
+		fatal("Failed to initialize Redis client", err)
 	}
 	defer redisClient.Close()
 
-	log.Println("Redis client initialized successfully")
-
-	// Generate JWT secret if not set
-	if cfg.JWTSecret == "" {
-		bytes := make([]byte, 32)
-		if _, err := rand.Read(bytes); err != nil {
-			log.Fatalf("Failed to generate JWT secret: %v", err)
-		}
-		cfg.JWTSecret = base64.URLEncoding.EncodeToString(bytes)
-		log.Printf("Generated JWT secret: %s", cfg.JWTSecret)
-	}
-
-	// Initialize JWT service (must be after secret is set)
-	jwtService := auth.NewJWTService(cfg.JWTSecret, cfg.JWTExpiration)
+	slog.Info("Redis client initialized successfully")
 
-	// Initialize default admin user
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	adminPassword := cfg.AdminPassword
-	if adminPassword == "" {
-		// Generate random password
-		bytes := make([]byte, 16)
-		if _, err := rand.Read(bytes); err != nil {
-			log.Fatalf("Failed to generate admin password: %v", err)
+	// Initialize JWT service; without JWT_SECRET every replica signs with the key
+	// set stored in Redis, created by whichever starts first
+	jwtService := auth.NewJWTService(cfg.JWTSecret, cfg.JWTExpiration, cfg.JWTRefreshExpiration)
+	if cfg.JWTSecret == "" {
+		if err := jwtService.UseKeyStore(ctx, redisClient); err != nil {
+			fatal("Failed to load JWT signing keys", err)
 		}
-		adminPassword = base64.URLEncoding.EncodeToString(bytes)
-		log.Printf("Generated admin password: %s", adminPassword)
 	}
 
-	adminPasswordHash, err := auth.HashPassword(adminPassword)
-	if err != nil {
-		log.Fatalf("Failed to hash admin password: %v", err)
+	// Initialize default admin user
+	if err := initializeAdmin(ctx, redisClient, cfg); err != nil {
+		fatal("Failed to initialize default admin user", err)
 	}
 
-	if err := redisClient.InitializeDefaultUser(ctx, cfg.AdminUsername, adminPasswordHash); err != nil {
-		log.Fatalf("Failed to initialize default admin user: %v", err)
+	// Create router
+	router := relayclientpkg.NewRouter(redisClient, cfg)
+	if err := router.Reload(ctx); err != nil {
+		slog.Error("Failed to load routes", "error", err)
 	}
 
-	log.Printf("Default admin user initialized: username=%s, password=%s", cfg.AdminUsername, adminPassword)
-
 	// Create forwarder
-	forwarder := relayclientpkg.NewForwarder(cfg)
+	forwarder := relayclientpkg.NewForwarder(cfg, router)
 	defer forwarder.Close()
 
-	log.Printf("Forwarder initialized: LocalWebhookURL=%s", cfg.LocalWebhookURL)
+	slog.Info("Forwarder initialized", "local_webhook_url", cfg.LocalWebhookURL)
+
+	// Register Prometheus metrics
+	registry := metrics.NewRegistry()
+	registry.MustRegister(metrics.NewQueueCollector(redisClient))
+
+	clientMetrics := metrics.NewClientMetrics(registry)
 
 	// Create consumer
-	consumer := relayclientpkg.NewConsumer(redisClient, cfg, forwarder)
+	consumer := relayclientpkg.NewConsumer(redisClient, cfg, forwarder, router, clientMetrics)
+
+	// Create retry scheduler
+	retryScheduler := relayclientpkg.NewRetryScheduler(redisClient, cfg)
+
+	// Create bulk DLQ job runner
+	dlqJobs := relayclientpkg.NewDLQJobRunner(redisClient, cfg)
+	if _, err := dlqJobs.FailStaleJobs(ctx); err != nil {
+		slog.Error("Failed to check for abandoned DLQ jobs", "error", err)
+	}
 
 	// Create handler
-	handler := relayclientpkg.NewHandler(redisClient, cfg, jwtService, consumer.GetMetrics())
+	handler := relayclientpkg.NewHandler(redisClient, cfg, jwtService, consumer.GetMetrics(), clientMetrics, router, dlqJobs)
 
 	// Set up HTTP server with enhanced ServeMux (Go 1.22+)
 	mux := http.NewServeMux()
@@ -100,14 +111,49 @@ func main() {
 		w.Write([]byte(`{"status":"healthy"}`))
 	})
 	mux.HandleFunc("POST /api/auth/login", handler.HandleLogin)
-	mux.HandleFunc("GET /api/auth/me", handler.HandleGetCurrentUser)
-	mux.HandleFunc("GET /api/config", handler.HandleGetConfig)
-	mux.HandleFunc("PUT /api/config/local-endpoint", handler.HandleUpdateLocalEndpoint)
-	mux.HandleFunc("PUT /api/config/retry", handler.HandleUpdateRetryConfig)
-	mux.HandleFunc("GET /api/dlq", handler.HandleGetDLQMessages)
-	mux.HandleFunc("POST /api/dlq/", handler.HandleReplayDLQMessage)
-	mux.HandleFunc("DELETE /api/dlq/", handler.HandleDeleteDLQMessage)
-	mux.HandleFunc("GET /api/metrics", handler.HandleGetMetrics)
+	mux.HandleFunc("POST /api/auth/refresh", handler.HandleRefreshToken)
+
+	// Admin API routes and the permission each one requires
+	apiRoutes := []struct {
+		pattern    string
+		permission auth.Permission
+		handler    http.HandlerFunc
+	}{
+		{"GET /api/auth/me", auth.PermAuthenticated, handler.HandleGetCurrentUser},
+		{"PUT /api/auth/password", auth.PermAuthenticated, handler.HandleChangePassword},
+		{"POST /api/auth/logout", auth.PermAuthenticated, handler.HandleLogout},
+		{"POST /api/auth/logout-all", auth.PermAuthenticated, handler.HandleLogoutAll},
+		{"GET /api/auth/signing-keys", auth.PermSigningKeys, handler.HandleListSigningKeys},
+		{"POST /api/auth/signing-keys", auth.PermSigningKeys, handler.HandleRotateSigningKey},
+		{"DELETE /api/auth/signing-keys/{kid}", auth.PermSigningKeys, handler.HandleRetireSigningKey},
+		{"GET /api/users", auth.PermUsersManage, handler.HandleListUsers},
+		{"POST /api/users", auth.PermUsersManage, handler.HandleCreateUser},
+		{"GET /api/users/{username}", auth.PermUsersManage, handler.HandleGetUser},
+		{"PUT /api/users/{username}", auth.PermUsersManage, handler.HandleUpdateUser},
+		{"DELETE /api/users/{username}", auth.PermUsersManage, handler.HandleDeleteUser},
+		{"DELETE /api/users/{username}/sessions", auth.PermUsersManage, handler.HandleRevokeUserSessions},
+		{"DELETE /api/users/{username}/lockout", auth.PermUsersManage, handler.HandleUnlockUser},
+		{"GET /api/auth/audit", auth.PermUsersManage, handler.HandleListAuthEvents},
+		{"GET /api/config", auth.PermConfigRead, handler.HandleGetConfig},
+		{"PUT /api/config/local-endpoint", auth.PermConfigWrite, handler.HandleUpdateLocalEndpoint},
+		{"PUT /api/config/retry", auth.PermConfigWrite, handler.HandleUpdateRetryConfig},
+		{"GET /api/routes", auth.PermRoutesRead, handler.HandleListRoutes},
+		{"POST /api/routes", auth.PermRoutesWrite, handler.HandleCreateRoute},
+		{"PUT /api/routes/", auth.PermRoutesWrite, handler.HandleUpdateRoute},
+		{"DELETE /api/routes/", auth.PermRoutesWrite, handler.HandleDeleteRoute},
+		{"GET /api/dlq", auth.PermDLQRead, handler.HandleGetDLQMessages},
+		{"POST /api/dlq/", auth.PermDLQWrite, handler.HandleReplayDLQMessage},
+		{"DELETE /api/dlq/", auth.PermDLQWrite, handler.HandleDeleteDLQMessage},
+		{"GET /api/dlq/replays", auth.PermDLQRead, handler.HandleListDLQReplays},
+		{"GET /api/dlq/jobs", auth.PermDLQRead, handler.HandleListDLQJobs},
+		{"POST /api/dlq/jobs", auth.PermDLQWrite, handler.HandleCreateDLQJob},
+		{"GET /api/dlq/jobs/", auth.PermDLQRead, handler.HandleGetDLQJob},
+		{"POST /api/dlq/jobs/{id}/cancel", auth.PermDLQWrite, handler.HandleCancelDLQJob},
+		{"GET /api/metrics", auth.PermMetricsRead, handler.HandleGetMetrics},
+	}
+	for _, route := range apiRoutes {
+		mux.Handle(route.pattern, relayclientpkg.RequirePermission(route.permission, route.handler))
+	}
 
 	// Serve static files for UI (public)
 	uiDir := http.Dir("web/client-ui/dist")
@@ -122,10 +168,14 @@ func main() {
 			r.URL.Path == "/api/" ||
 			r.URL.Path == "/api/auth/login" ||
 			r.URL.Path == "/api/auth/me" ||
+			r.URL.Path == "/api/users" ||
 			r.URL.Path == "/api/config" ||
 			r.URL.Path == "/api/config/local-endpoint" ||
 			r.URL.Path == "/api/config/retry" ||
+			r.URL.Path == "/api/routes" ||
 			r.URL.Path == "/api/dlq" ||
+			r.URL.Path == "/api/dlq/jobs" ||
+			r.URL.Path == "/api/dlq/replays" ||
 			r.URL.Path == "/api/metrics" {
 			http.NotFound(w, r)
 			return
@@ -139,6 +189,7 @@ func main() {
 			// Skip authentication for public routes
 			if r.URL.Path == "/health" ||
 				r.URL.Path == "/api/auth/login" ||
+				r.URL.Path == "/api/auth/refresh" ||
 				r.URL.Path == "/assets/" ||
 				r.URL.Path == "/index.html" ||
 				(r.Method == "GET" && !strings.HasPrefix(r.URL.Path, "/api/")) {
@@ -147,16 +198,18 @@ func main() {
 			}
 
 			// Apply authentication to protected routes
-			authHandler := relayclientpkg.JWTMiddleware(jwtService)(next)
+			authHandler := adminapi.JWTMiddleware(jwtService, redisClient)(next)
 			authHandler.ServeHTTP(w, r)
 		})
 	}
 
 	// Apply middleware
-	handlerChain := relayclientpkg.CORSMiddleware(
-		relayclientpkg.RecoveryMiddleware(
-			relayclientpkg.LoggingMiddleware(
-				authMiddleware(mux),
+	handlerChain := relayclientpkg.RequestIDMiddleware(
+		relayclientpkg.CORSMiddleware(
+			relayclientpkg.RecoveryMiddleware(
+				relayclientpkg.LoggingMiddleware(
+					authMiddleware(mux),
+				),
 			),
 		),
 	)
@@ -174,11 +227,26 @@ func main() {
 	ctx, cancel = context.WithCancel(context.Background())
 	defer cancel()
 
+	// Start router refresh in a goroutine
+	go func() {
+		router.Start(ctx)
+	}()
+
 	// Start consumer in a goroutine
 	go func() {
 		consumer.Start(ctx)
 	}()
 
+	// Start retry scheduler in a goroutine
+	go func() {
+		retryScheduler.Start(ctx)
+	}()
+
+	// Pick up signing keys rotated or retired by other replicas
+	if cfg.JWTSecret == "" {
+		go jwtService.WatchKeys(ctx, auth.KeyReloadInterval)
+	}
+
 	// Start metrics reporter
 	go func() {
 		ticker := time.NewTicker(time.Duration(cfg.HealthCheckInterval) * time.Second)
@@ -190,35 +258,50 @@ func main() {
 				return
 			case <-ticker.C:
 				metrics := consumer.GetMetrics()
-				log.Printf("Metrics: Received=%d, Processed=%d, Failed=%d, Retried=%d",
-					metrics.WebhooksReceived,
-					metrics.WebhooksProcessed,
-					metrics.WebhooksFailed,
-					metrics.WebhooksRetried,
+				slog.Info("Metrics",
+					"received", metrics.WebhooksReceived,
+					"processed", metrics.WebhooksProcessed,
+					"failed", metrics.WebhooksFailed,
+					"retried", metrics.WebhooksRetried,
+					"reclaimed", metrics.MessagesReclaimed,
 				)
 			}
 		}
 	}()
 
+	// Serve Prometheus metrics on their own listener, only when configured
+	var metricsServer *http.Server
+	if cfg.MetricsAddr != "" {
+		metricsServer = metrics.NewServer(cfg.MetricsAddr, registry)
+		go func() {
+			slog.Info("Metrics listening", "addr", cfg.MetricsAddr)
+			if err := metricsServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				fatal("Metrics server error", err)
+			}
+		}()
+	}
+
 	// Start HTTP server in a goroutine
 	go func() {
-		log.Printf("HTTP server listening on port %s", cfg.ServerPort)
+		slog.Info("HTTP server listening", "port", cfg.ServerPort)
 		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("HTTP server error: %v", err)
+			fatal("HTTP server error", err)
 		}
 	}()
 
-	log.Println("Relay client is running...")
+	slog.Info("Relay client is running")
 
 	// Wait for interrupt signal
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 
-	log.Println("Shutting down relay client...")
+	slog.Info("Shutting down relay client")
 
-	// Stop consumer
+	// Stop consumer, retry scheduler and bulk DLQ jobs
 	consumer.Stop()
+	retryScheduler.Stop()
+	dlqJobs.Stop()
 
 	// Cancel context
 	cancel()
@@ -227,11 +310,78 @@ func main() {
 	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer shutdownCancel()
 	if err := server.Shutdown(shutdownCtx); err != nil {
-		log.Printf("HTTP server shutdown error: %v", err)
+		slog.Error("HTTP server shutdown error", "error", err)
+	}
+	if metricsServer != nil {
+		if err := metricsServer.Shutdown(shutdownCtx); err != nil {
+			slog.Error("Metrics server shutdown error", "error", err)
+		}
+	}
+
+	// Flush buffered spans
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		slog.Error("Tracing shutdown error", "error", err)
 	}
 
 	// Wait a bit for cleanup
 	time.Sleep(2 * time.Second)
 
-	log.Println("Relay client stopped")
+	slog.Info("Relay client stopped")
+}
+
+// initializeAdmin creates the default admin user if it does not exist yet. A generated
+// password is written to ADMIN_PASSWORD_FILE rather than logged.
+func initializeAdmin(ctx context.Context, redisClient *storage.RedisClient, cfg *models.Config) error {
+	if _, err := redisClient.GetUser(ctx, cfg.AdminUsername); err == nil {
+		slog.Info("Default admin user already exists", "username", cfg.AdminUsername)
+		// Admins created before the user index existed are added to it here
+		return redisClient.IndexUser(ctx, cfg.AdminUsername)
+	}
+
+	adminPassword := cfg.AdminPassword
+	generated := adminPassword == ""
+	if generated {
+		// Generate random password
+		bytes := make([]byte, 16)
+		if _, err := rand.Read(bytes); err != nil {
+			return fmt.Errorf("failed to generate admin password: %w", err)
+		}
+		adminPassword = base64.URLEncoding.EncodeToString(bytes)
+	}
+
+	adminPasswordHash, err := auth.HashPassword(adminPassword)
+	if err != nil {
+		return fmt.Errorf("failed to hash admin password: %w", err)
+	}
+
+	created, err := redisClient.InitializeDefaultUser(ctx, cfg.AdminUsername, adminPasswordHash)
+	if err != nil {
+		return err
+	}
+	if !created {
+		slog.Info("Default admin user was created by another instance", "username", cfg.AdminUsername)
+		return nil
+	}
+
+	// Only the instance that created the admin writes the password it generated
+	if generated {
+		if err := os.WriteFile(cfg.AdminPasswordFile, []byte(adminPassword+"\n"), 0600); err != nil {
+			// Without the password the account is unusable, so let the next start retry
+			if delErr := redisClient.DeleteUser(ctx, cfg.AdminUsername); delErr != nil {
+				slog.Error("Failed to remove admin user after password write failed", "error", delErr)
+			}
+			return fmt.Errorf("failed to write generated admin password: %w", err)
+		}
+		slog.Warn("ADMIN_PASSWORD is not set; generated admin password written to file",
+			"username", cfg.AdminUsername, "path", cfg.AdminPasswordFile)
+	}
+
+	slog.Info("Default admin user initialized", "username", cfg.AdminUsername)
+	return nil
+}
+
+// fatal logs an error and exits
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
 }
@@ -4,7 +4,9 @@ import (
 	"context"
 	"crypto/rand"
 	"encoding/base64"
+	"fmt"
 	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
@@ -12,74 +14,74 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/QuantumSolver/crm-relay/internal/adminapi"
 	"github.com/QuantumSolver/crm-relay/internal/auth"
 	"github.com/QuantumSolver/crm-relay/internal/config"
+	"github.com/QuantumSolver/crm-relay/internal/logging"
+	"github.com/QuantumSolver/crm-relay/internal/metrics"
+	"github.com/QuantumSolver/crm-relay/internal/models"
 	relayserverpkg "github.com/QuantumSolver/crm-relay/internal/relay-server"
 	"github.com/QuantumSolver/crm-relay/internal/storage"
+	"github.com/QuantumSolver/crm-relay/internal/tracing"
 )
 
 func main() {
-	log.Println("Starting CRM Relay Server...")
-
 	// Load configuration
 	cfg, err := config.Load()
 	if err != nil {
 		log.Fatalf("Failed to load configuration: %v", err)
 	}
 
-	log.Printf("Configuration loaded: ServerPort=%s, RedisURL=%s, StreamName=%s",
-		cfg.ServerPort, cfg.RedisURL, cfg.StreamName)
+	logging.Setup(cfg, "crm-relay-server")
+	slog.Info("Starting CRM Relay Server",
+		"server_port", cfg.ServerPort, "redis_url", cfg.RedisURL, "stream", cfg.StreamName)
+
+	// Set up tracing
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg, "crm-relay-server")
+	if err != nil {
+		fatal("Failed to set up tracing", err)
+	}
 
 	// Initialize Redis client
 	redisClient, err := storage.NewRedisClient(cfg)
 	if err != nil {
-		log.Fatalf("Failed to initialize Redis client: %v", err)
+		fatal("Failed to initialize Redis client", err)
 	}
 	defer redisClient.Close()
 
-	log.Println("Redis client initialized successfully")
-
-	// Generate JWT secret if not set
-	if cfg.JWTSecret == "" {
-		bytes := make([]byte, 32)
-		if _, err := rand.Read(bytes); err != nil {
-			log.Fatalf("Failed to generate JWT secret: %v", err)
-		}
-		cfg.JWTSecret = base64.URLEncoding.EncodeToString(bytes)
-		log.Printf("Generated JWT secret: %s", cfg.JWTSecret)
-	}
-
-	// Initialize JWT service (must be after secret is set)
-	jwtService := auth.NewJWTService(cfg.JWTSecret, cfg.JWTExpiration)
+	slog.Info("Redis client initialized successfully")
 
-	// Initialize default admin user
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	adminPassword := cfg.AdminPassword
-	if adminPassword == "" {
-		// Generate random password
-		bytes := make([]byte, 16)
-		if _, err := rand.Read(bytes); err != nil {
-			log.Fatalf("Failed to generate admin password: %v", err)
+	// Initialize JWT service; without JWT_SECRET every replica signs with the key
+	// set stored in Redis, created by whichever starts first
+	jwtService := auth.NewJWTService(cfg.JWTSecret, cfg.JWTExpiration, cfg.JWTRefreshExpiration)
+	if cfg.JWTSecret == "" {
+		if err := jwtService.UseKeyStore(ctx, redisClient); err != nil {
+			fatal("Failed to load JWT signing keys", err)
 		}
-		adminPassword = base64.URLEncoding.EncodeToString(bytes)
-		log.Printf("Generated admin password: %s", adminPassword)
 	}
 
-	adminPasswordHash, err := auth.HashPassword(adminPassword)
-	if err != nil {
-		log.Fatalf("Failed to hash admin password: %v", err)
+	// Initialize default admin user
+	if err := initializeAdmin(ctx, redisClient, cfg); err != nil {
+		fatal("Failed to initialize default admin user", err)
 	}
 
-	if err := redisClient.InitializeDefaultUser(ctx, cfg.AdminUsername, adminPasswordHash); err != nil {
-		log.Fatalf("Failed to initialize default admin user: %v", err)
+	// Endpoints created before retry overrides were optional carry a copy of the
+	// global policy; clear it so the client's policy applies to them
+	if cleared, err := relayserverpkg.ClearCopiedRetryConfigs(ctx, redisClient, cfg); err != nil {
+		slog.Error("Failed to clear copied endpoint retry configs", "error", err)
+	} else if cleared > 0 {
+		slog.Info("Cleared copied endpoint retry configs", "count", cleared)
 	}
 
-	log.Printf("Default admin user initialized: username=%s, password=%s", cfg.AdminUsername, adminPassword)
+	// Register Prometheus metrics
+	registry := metrics.NewRegistry()
+	registry.MustRegister(metrics.NewQueueCollector(redisClient))
 
 	// Create handler
-	handler := relayserverpkg.NewHandler(redisClient, cfg, jwtService)
+	handler := relayserverpkg.NewHandler(redisClient, cfg, jwtService, metrics.NewServerMetrics(registry))
 
 	// Set up HTTP server with enhanced ServeMux (Go 1.22+)
 	mux := http.NewServeMux()
@@ -87,20 +89,47 @@ func main() {
 	// Register all routes
 	mux.HandleFunc("POST /webhook", handler.HandleWebhook)
 	mux.HandleFunc("POST /webhook/", handler.HandleWebhook)
+	mux.HandleFunc("GET /webhook/", handler.HandleWebhookVerification)
 	mux.HandleFunc("GET /health", handler.HandleHealth)
 	mux.HandleFunc("POST /api/auth/login", handler.HandleLogin)
-	mux.HandleFunc("GET /api/auth/me", handler.HandleGetCurrentUser)
-	mux.HandleFunc("GET /api/keys", handler.HandleListAPIKeys)
-	mux.HandleFunc("POST /api/keys", handler.HandleCreateAPIKey)
-	mux.HandleFunc("PUT /api/keys/", handler.HandleUpdateAPIKey)
-	mux.HandleFunc("DELETE /api/keys/", handler.HandleDeleteAPIKey)
-	mux.HandleFunc("GET /api/endpoints", handler.HandleListEndpoints)
-	mux.HandleFunc("POST /api/endpoints", handler.HandleCreateEndpoint)
-	mux.HandleFunc("PUT /api/endpoints/", handler.HandleUpdateEndpoint)
-	mux.HandleFunc("DELETE /api/endpoints/", handler.HandleDeleteEndpoint)
-	mux.HandleFunc("GET /api/metrics", handler.HandleGetMetrics)
-	mux.HandleFunc("GET /api/queue-depth", handler.HandleGetQueueDepth)
-	mux.HandleFunc("GET /api/pending-messages", handler.HandleGetPendingMessages)
+	mux.HandleFunc("POST /api/auth/refresh", handler.HandleRefreshToken)
+
+	// Admin API routes and the permission each one requires
+	apiRoutes := []struct {
+		pattern    string
+		permission auth.Permission
+		handler    http.HandlerFunc
+	}{
+		{"GET /api/auth/me", auth.PermAuthenticated, handler.HandleGetCurrentUser},
+		{"PUT /api/auth/password", auth.PermAuthenticated, handler.HandleChangePassword},
+		{"POST /api/auth/logout", auth.PermAuthenticated, handler.HandleLogout},
+		{"POST /api/auth/logout-all", auth.PermAuthenticated, handler.HandleLogoutAll},
+		{"GET /api/auth/signing-keys", auth.PermSigningKeys, handler.HandleListSigningKeys},
+		{"POST /api/auth/signing-keys", auth.PermSigningKeys, handler.HandleRotateSigningKey},
+		{"DELETE /api/auth/signing-keys/{kid}", auth.PermSigningKeys, handler.HandleRetireSigningKey},
+		{"GET /api/users", auth.PermUsersManage, handler.HandleListUsers},
+		{"POST /api/users", auth.PermUsersManage, handler.HandleCreateUser},
+		{"GET /api/users/{username}", auth.PermUsersManage, handler.HandleGetUser},
+		{"PUT /api/users/{username}", auth.PermUsersManage, handler.HandleUpdateUser},
+		{"DELETE /api/users/{username}", auth.PermUsersManage, handler.HandleDeleteUser},
+		{"DELETE /api/users/{username}/sessions", auth.PermUsersManage, handler.HandleRevokeUserSessions},
+		{"DELETE /api/users/{username}/lockout", auth.PermUsersManage, handler.HandleUnlockUser},
+		{"GET /api/auth/audit", auth.PermUsersManage, handler.HandleListAuthEvents},
+		{"GET /api/keys", auth.PermKeysRead, handler.HandleListAPIKeys},
+		{"POST /api/keys", auth.PermKeysWrite, handler.HandleCreateAPIKey},
+		{"PUT /api/keys/", auth.PermKeysWrite, handler.HandleUpdateAPIKey},
+		{"DELETE /api/keys/", auth.PermKeysWrite, handler.HandleDeleteAPIKey},
+		{"GET /api/endpoints", auth.PermEndpointsRead, handler.HandleListEndpoints},
+		{"POST /api/endpoints", auth.PermEndpointsWrite, handler.HandleCreateEndpoint},
+		{"PUT /api/endpoints/", auth.PermEndpointsWrite, handler.HandleUpdateEndpoint},
+		{"DELETE /api/endpoints/", auth.PermEndpointsWrite, handler.HandleDeleteEndpoint},
+		{"GET /api/metrics", auth.PermMetricsRead, handler.HandleGetMetrics},
+		{"GET /api/queue-depth", auth.PermMetricsRead, handler.HandleGetQueueDepth},
+		{"GET /api/pending-messages", auth.PermMetricsRead, handler.HandleGetPendingMessages},
+	}
+	for _, route := range apiRoutes {
+		mux.Handle(route.pattern, relayserverpkg.RequirePermission(route.permission, route.handler))
+	}
 
 	// Serve static files for UI (public)
 	uiDir := http.Dir("web/server-ui/dist")
@@ -116,6 +145,7 @@ func main() {
 			r.URL.Path == "/api/" ||
 			r.URL.Path == "/api/auth/login" ||
 			r.URL.Path == "/api/auth/me" ||
+			r.URL.Path == "/api/users" ||
 			r.URL.Path == "/api/keys" ||
 			r.URL.Path == "/api/endpoints" ||
 			r.URL.Path == "/api/metrics" {
@@ -132,6 +162,7 @@ func main() {
 			if r.URL.Path == "/health" ||
 				strings.HasPrefix(r.URL.Path, "/webhook") ||
 				r.URL.Path == "/api/auth/login" ||
+				r.URL.Path == "/api/auth/refresh" ||
 				r.URL.Path == "/assets/" ||
 				r.URL.Path == "/index.html" ||
 				(r.Method == "GET" && !strings.HasPrefix(r.URL.Path, "/api/")) {
@@ -140,16 +171,18 @@ func main() {
 			}
 
 			// Apply authentication to protected routes (JWT only for UI)
-			authHandler := relayserverpkg.JWTMiddleware(jwtService)(next)
+			authHandler := adminapi.JWTMiddleware(jwtService, redisClient)(next)
 			authHandler.ServeHTTP(w, r)
 		})
 	}
 
 	// Apply middleware
-	handlerChain := relayserverpkg.CORSMiddleware(
-		relayserverpkg.RecoveryMiddleware(
-			relayserverpkg.LoggingMiddleware(
-				authMiddleware(mux),
+	handlerChain := relayserverpkg.RequestIDMiddleware(
+		relayserverpkg.CORSMiddleware(
+			relayserverpkg.RecoveryMiddleware(
+				relayserverpkg.LoggingMiddleware(
+					authMiddleware(mux),
+				),
 			),
 		),
 	)
@@ -163,11 +196,35 @@ func main() {
 		IdleTimeout:  60 * time.Second,
 	}
 
+	// Start stream retention and signing key reloads
+	trimCtx, trimCancel := context.WithCancel(context.Background())
+	defer trimCancel()
+
+	trimmer := relayserverpkg.NewStreamTrimmer(redisClient, cfg)
+	go trimmer.Start(trimCtx)
+
+	// Pick up signing keys rotated or retired by other replicas
+	if cfg.JWTSecret == "" {
+		go jwtService.WatchKeys(trimCtx, auth.KeyReloadInterval)
+	}
+
+	// Serve Prometheus metrics on their own listener, only when configured
+	var metricsServer *http.Server
+	if cfg.MetricsAddr != "" {
+		metricsServer = metrics.NewServer(cfg.MetricsAddr, registry)
+		go func() {
+			slog.Info("Metrics listening", "addr", cfg.MetricsAddr)
+			if err := metricsServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				fatal("Metrics server error", err)
+			}
+		}()
+	}
+
 	// Start server in a goroutine
 	go func() {
-		log.Printf("Server listening on port %s", cfg.ServerPort)
+		slog.Info("Server listening", "port", cfg.ServerPort)
 		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("Server error: %v", err)
+			fatal("Server error", err)
 		}
 	}()
 
@@ -176,15 +233,85 @@ func main() {
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 
-	log.Println("Shutting down server...")
+	slog.Info("Shutting down server")
+
+	trimmer.Stop()
+	trimCancel()
 
 	// Graceful shutdown
 	ctx, cancel = context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
 	if err := server.Shutdown(ctx); err != nil {
-		log.Printf("Server shutdown error: %v", err)
+		slog.Error("Server shutdown error", "error", err)
+	}
+	if metricsServer != nil {
+		if err := metricsServer.Shutdown(ctx); err != nil {
+			slog.Error("Metrics server shutdown error", "error", err)
+		}
+	}
+
+	// Flush buffered spans
+	if err := shutdownTracing(ctx); err != nil {
+		slog.Error("Tracing shutdown error", "error", err)
+	}
+
+	slog.Info("Server stopped")
+}
+
+// initializeAdmin creates the default admin user if it does not exist yet. A generated
+// password is written to ADMIN_PASSWORD_FILE rather than logged.
+func initializeAdmin(ctx context.Context, redisClient *storage.RedisClient, cfg *models.Config) error {
+	if _, err := redisClient.GetUser(ctx, cfg.AdminUsername); err == nil {
+		slog.Info("Default admin user already exists", "username", cfg.AdminUsername)
+		// Admins created before the user index existed are added to it here
+		return redisClient.IndexUser(ctx, cfg.AdminUsername)
 	}
 
-	log.Println("Server stopped")
+	adminPassword := cfg.AdminPassword
+	generated := adminPassword == ""
+	if generated {
+		// Generate random password
+		bytes := make([]byte, 16)
+		if _, err := rand.Read(bytes); err != nil {
+			return fmt.Errorf("failed to generate admin password: %w", err)
+		}
+		adminPassword = base64.URLEncoding.EncodeToString(bytes)
+	}
+
+	adminPasswordHash, err := auth.HashPassword(adminPassword)
+	if err != nil {
+		return fmt.Errorf("failed to hash admin password: %w", err)
+	}
+
+	created, err := redisClient.InitializeDefaultUser(ctx, cfg.AdminUsername, adminPasswordHash)
+	if err != nil {
+		return err
+	}
+	if !created {
+		slog.Info("Default admin user was created by another instance", "username", cfg.AdminUsername)
+		return nil
+	}
+
+	// Only the instance that created the admin writes the password it generated
+	if generated {
+		if err := os.WriteFile(cfg.AdminPasswordFile, []byte(adminPassword+"\n"), 0600); err != nil {
+			// Without the password the account is unusable, so let the next start retry
+			if delErr := redisClient.DeleteUser(ctx, cfg.AdminUsername); delErr != nil {
+				slog.Error("Failed to remove admin user after password write failed", "error", delErr)
+			}
+			return fmt.Errorf("failed to write generated admin password: %w", err)
+		}
+		slog.Warn("ADMIN_PASSWORD is not set; generated admin password written to file",
+			"username", cfg.AdminUsername, "path", cfg.AdminPasswordFile)
+	}
+
+	slog.Info("Default admin user initialized", "username", cfg.AdminUsername)
+	return nil
+}
+
+// fatal logs an error and exits
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
 }
@@ -5,11 +5,23 @@ import (
 	"io"
 	"log"
 	"net/http"
+	"os"
+	"time"
+
+	"github.com/QuantumSolver/crm-relay/pkg/relaysig"
 )
 
 func main() {
 	log.Println("Starting test webhook server on port 3000...")
 
+	secret, err := relaysig.DecodeSecret(os.Getenv("RELAY_SIGNING_SECRET"))
+	if err != nil {
+		log.Fatalf("Invalid RELAY_SIGNING_SECRET: %v", err)
+	}
+	if len(secret) == 0 {
+		secret = nil
+	}
+
 	http.HandleFunc("/webhook", func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPost {
 			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
@@ -36,6 +48,15 @@ func main() {
 		// Log body
 		log.Printf("Body: %s", string(body))
 
+		// Verify relay signature when a secret is configured
+		if secret != nil {
+			if err := relaysig.Verify(secret, r.Header, body, time.Now(), 0); err != nil {
+				log.Printf("Rejected request: %v", err)
+				http.Error(w, "Invalid signature", http.StatusUnauthorized)
+				return
+			}
+		}
+
 		// Parse JSON
 		var data map[string]interface{}
 		if err := json.Unmarshal(body, &data); err == nil {
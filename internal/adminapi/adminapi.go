@@ -0,0 +1,87 @@
+// Package adminapi implements the account endpoints that the relay server and the
+// relay client both serve against their own Redis: users, sessions, JWT signing
+// keys and logins.
+package adminapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/QuantumSolver/crm-relay/internal/auth"
+	"github.com/QuantumSolver/crm-relay/internal/models"
+)
+
+// Store persists users, their sessions and the login history
+type Store interface {
+	GetUser(ctx context.Context, username string) (*models.User, error)
+	ListUsers(ctx context.Context) ([]*models.User, error)
+	CreateUser(ctx context.Context, user *models.User) error
+	ChangeUserPassword(ctx context.Context, expected *models.User, passwordHash string) (bool, error)
+	UpdateUserKeepingAdmin(ctx context.Context, user *models.User) error
+	DeleteUserKeepingAdmin(ctx context.Context, username string) error
+	CreateSession(ctx context.Context, session *models.Session) error
+	GetSession(ctx context.Context, id string) (*models.Session, error)
+	RotateSession(ctx context.Context, id, oldHash, newHash string, expiresAt time.Time) (bool, error)
+	DeleteSession(ctx context.Context, session *models.Session) error
+	DeleteUserSessions(ctx context.Context, username, keep string) (int64, error)
+	RevokeToken(ctx context.Context, tokenID string, expiresAt time.Time) error
+	IsTokenRevoked(ctx context.Context, claims *models.JWTClaims) (bool, error)
+	RecordLoginFailure(ctx context.Context, subject string, maxAttempts int, window, lockoutBase, lockoutMax time.Duration) (time.Time, error)
+	LoginLockedUntil(ctx context.Context, subjects ...string) (time.Time, error)
+	ClearLoginFailures(ctx context.Context, subject string) error
+	RecordAuthEvent(ctx context.Context, event *models.AuthEvent) error
+	ListAuthEvents(ctx context.Context, count int64) ([]*models.AuthEvent, error)
+}
+
+// Handler handles the account endpoints
+type Handler struct {
+	store      Store
+	config     *models.Config
+	jwtService *auth.JWTService
+}
+
+// NewHandler creates a new handler
+func NewHandler(store Store, config *models.Config, jwtService *auth.JWTService) *Handler {
+	return &Handler{
+		store:      store,
+		config:     config,
+		jwtService: jwtService,
+	}
+}
+
+// currentUsername returns the username of the authenticated user, if any
+func currentUsername(r *http.Request) string {
+	if claims, ok := r.Context().Value("user").(*models.JWTClaims); ok {
+		return claims.Username
+	}
+	return ""
+}
+
+// currentSessionID returns the session ID of the authenticated user, if any
+func currentSessionID(r *http.Request) string {
+	if claims, ok := r.Context().Value("user").(*models.JWTClaims); ok {
+		return claims.SessionID
+	}
+	return ""
+}
+
+// sendErrorResponse sends an error response as JSON
+func sendErrorResponse(w http.ResponseWriter, statusCode int, err *models.RelayError) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+
+	response := map[string]interface{}{
+		"error": map[string]interface{}{
+			"code":    err.Code,
+			"message": err.Message,
+		},
+	}
+
+	if err.Err != nil {
+		response["error"].(map[string]interface{})["details"] = err.Err.Error()
+	}
+
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,255 @@
+package adminapi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/QuantumSolver/crm-relay/internal/models"
+)
+
+// memoryStore is a Store standing in for Redis
+type memoryStore struct {
+	mu       sync.Mutex
+	users    map[string]models.User
+	sessions map[string]models.Session
+	revoked  map[string]bool
+	failures map[string]int
+	locked   map[string]time.Time
+	events   []*models.AuthEvent
+}
+
+func newMemoryStore(users ...*models.User) *memoryStore {
+	s := &memoryStore{
+		users:    make(map[string]models.User),
+		sessions: make(map[string]models.Session),
+		revoked:  make(map[string]bool),
+		failures: make(map[string]int),
+		locked:   make(map[string]time.Time),
+	}
+	for _, user := range users {
+		s.users[user.Username] = *user
+	}
+	return s
+}
+
+func (s *memoryStore) GetUser(ctx context.Context, username string) (*models.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	user, ok := s.users[username]
+	if !ok {
+		return nil, models.NewRelayError(models.ErrCodeAuthentication, "user not found", nil)
+	}
+	return &user, nil
+}
+
+func (s *memoryStore) ListUsers(ctx context.Context) ([]*models.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	users := make([]*models.User, 0, len(s.users))
+	for _, user := range s.users {
+		user := user
+		users = append(users, &user)
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].Username < users[j].Username })
+	return users, nil
+}
+
+func (s *memoryStore) CreateUser(ctx context.Context, user *models.User) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.users[user.Username]; ok {
+		return models.NewRelayError(models.ErrCodeInvalidRequest, "user already exists", nil)
+	}
+	s.users[user.Username] = *user
+	return nil
+}
+
+func (s *memoryStore) ChangeUserPassword(ctx context.Context, expected *models.User, passwordHash string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	user, ok := s.users[expected.Username]
+	if !ok || user.PasswordHash != expected.PasswordHash || user.Disabled != expected.Disabled ||
+		user.Role != expected.Role || !user.UpdatedAt.Equal(expected.UpdatedAt) {
+		return false, nil
+	}
+	user.PasswordHash = passwordHash
+	user.UpdatedAt = time.Now()
+	s.users[user.Username] = user
+	return true, nil
+}
+
+func (s *memoryStore) UpdateUserKeepingAdmin(ctx context.Context, user *models.User) error {
+	return s.writeUserKeepingAdmin(user.Username, user)
+}
+
+func (s *memoryStore) DeleteUserKeepingAdmin(ctx context.Context, username string) error {
+	return s.writeUserKeepingAdmin(username, nil)
+}
+
+// writeUserKeepingAdmin replaces or, with a nil user, deletes a user unless that
+// would leave no enabled admin
+func (s *memoryStore) writeUserKeepingAdmin(username string, user *models.User) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	current, ok := s.users[username]
+	if !ok {
+		return models.NewRelayError(models.ErrCodeInvalidRequest, "user not found", nil)
+	}
+
+	enabledAdmin := func(u *models.User) bool { return u != nil && u.Role == models.RoleAdmin && !u.Disabled }
+	if enabledAdmin(&current) && !enabledAdmin(user) {
+		others := 0
+		for name, other := range s.users {
+			if name != username && enabledAdmin(&other) {
+				others++
+			}
+		}
+		if others == 0 {
+			return models.NewRelayError(models.ErrCodeLastAdmin, "cannot remove the last enabled admin", nil)
+		}
+	}
+
+	if user == nil {
+		delete(s.users, username)
+	} else {
+		s.users[username] = *user
+	}
+	return nil
+}
+
+func (s *memoryStore) CreateSession(ctx context.Context, session *models.Session) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sessions[session.ID] = *session
+	return nil
+}
+
+func (s *memoryStore) GetSession(ctx context.Context, id string) (*models.Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	session, ok := s.sessions[id]
+	if !ok {
+		return nil, models.NewRelayError(models.ErrCodeAuthentication, "session not found", nil)
+	}
+	return &session, nil
+}
+
+func (s *memoryStore) RotateSession(ctx context.Context, id, oldHash, newHash string, expiresAt time.Time) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	session, ok := s.sessions[id]
+	if !ok {
+		return false, models.NewRelayError(models.ErrCodeAuthentication, "session not found", nil)
+	}
+	if session.RefreshHash != oldHash {
+		return false, nil
+	}
+	session.RefreshHash = newHash
+	session.ExpiresAt = expiresAt
+	s.sessions[id] = session
+	return true, nil
+}
+
+func (s *memoryStore) DeleteSession(ctx context.Context, session *models.Session) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.sessions, session.ID)
+	return nil
+}
+
+func (s *memoryStore) DeleteUserSessions(ctx context.Context, username, keep string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var revoked int64
+	for id, session := range s.sessions {
+		if session.Username == username && id != keep {
+			delete(s.sessions, id)
+			revoked++
+		}
+	}
+	return revoked, nil
+}
+
+func (s *memoryStore) RevokeToken(ctx context.Context, tokenID string, expiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.revoked[tokenID] = true
+	return nil
+}
+
+func (s *memoryStore) IsTokenRevoked(ctx context.Context, claims *models.JWTClaims) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.sessions[claims.SessionID]
+	return s.revoked[claims.TokenID] || !ok, nil
+}
+
+func (s *memoryStore) RecordLoginFailure(ctx context.Context, subject string, maxAttempts int, window, lockoutBase, lockoutMax time.Duration) (time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures[subject]++
+	if s.failures[subject] < maxAttempts {
+		return time.Time{}, nil
+	}
+	s.failures[subject] = 0
+	s.locked[subject] = time.Now().Add(lockoutBase)
+	return s.locked[subject], nil
+}
+
+func (s *memoryStore) LoginLockedUntil(ctx context.Context, subjects ...string) (time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var lockedUntil time.Time
+	for _, subject := range subjects {
+		if s.locked[subject].After(lockedUntil) {
+			lockedUntil = s.locked[subject]
+		}
+	}
+	return lockedUntil, nil
+}
+
+func (s *memoryStore) ClearLoginFailures(ctx context.Context, subject string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.failures, subject)
+	delete(s.locked, subject)
+	return nil
+}
+
+func (s *memoryStore) RecordAuthEvent(ctx context.Context, event *models.AuthEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events = append([]*models.AuthEvent{event}, s.events...)
+	return nil
+}
+
+func (s *memoryStore) ListAuthEvents(ctx context.Context, count int64) ([]*models.AuthEvent, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.events[:min(int(count), len(s.events))], nil
+}
+
+// asUser adds the claims JWTMiddleware would put on a request from username
+func asUser(r *http.Request, username, role, sessionID string) *http.Request {
+	claims := &models.JWTClaims{Username: username, Role: role, SessionID: sessionID}
+	return r.WithContext(context.WithValue(r.Context(), "user", claims))
+}
+
+// serve calls a handler method with a request routed through a ServeMux, so path
+// values are set
+func serve(pattern string, handler http.HandlerFunc, r *http.Request) *httptest.ResponseRecorder {
+	mux := http.NewServeMux()
+	mux.HandleFunc(pattern, handler)
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, r)
+	return rec
+}
+
+func newRequest(method, target, body string) *http.Request {
+	return httptest.NewRequest(method, target, strings.NewReader(body))
+}
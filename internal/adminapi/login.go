@@ -0,0 +1,287 @@
+package adminapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/QuantumSolver/crm-relay/internal/auth"
+	"github.com/QuantumSolver/crm-relay/internal/logging"
+	"github.com/QuantumSolver/crm-relay/internal/models"
+)
+
+// Login endpoints
+
+const (
+	authAuditDefaultLimit = 100
+	authAuditMaxLimit     = 1000
+
+	// maxLoginUsernameLength matches the longest username ValidateUsername accepts;
+	// longer names cannot exist and are neither counted nor stored in full
+	maxLoginUsernameLength = 64
+)
+
+// loginLimit is a failure counter a login counts against and its lockout threshold
+type loginLimit struct {
+	scope       string // user or ip
+	subject     string
+	maxAttempts int
+}
+
+// countsUsername reports whether failures are counted against a username
+func countsUsername(username string) bool {
+	return username != "" && len(username) <= maxLoginUsernameLength
+}
+
+// userLoginSubject names the failure counter of a username
+func userLoginSubject(username string) string {
+	return "user:" + username
+}
+
+// ipLoginSubject names the failure counter of a client IP
+func ipLoginSubject(ip string) string {
+	return "ip:" + ip
+}
+
+// HandleLogin handles login requests
+func (h *Handler) HandleLogin(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		sendErrorResponse(w, http.StatusMethodNotAllowed, models.NewRelayError(
+			models.ErrCodeInvalidRequest,
+			"method not allowed",
+			nil,
+		))
+		return
+	}
+
+	var loginReq models.LoginRequest
+	if err := json.NewDecoder(r.Body).Decode(&loginReq); err != nil {
+		sendErrorResponse(w, http.StatusBadRequest, models.NewRelayError(
+			models.ErrCodeInvalidRequest,
+			"invalid request body",
+			err,
+		))
+		return
+	}
+
+	// Validate credentials
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	ip := auth.ClientIP(r, h.config.TrustedProxyHops)
+	if !h.checkLoginLockout(ctx, w, r, loginReq.Username, ip) {
+		return
+	}
+
+	user, err := h.store.GetUser(ctx, loginReq.Username)
+	if err != nil {
+		if err.(*models.RelayError).Code == models.ErrCodeRedisConnection {
+			slog.ErrorContext(r.Context(), "Failed to retrieve user", "error", err)
+			sendErrorResponse(w, http.StatusInternalServerError, err.(*models.RelayError))
+			return
+		}
+		user = nil
+	}
+
+	// Unknown users, wrong passwords and disabled accounts all get the same answer
+	reason := ""
+	if !auth.VerifyLogin(user, loginReq.Password) {
+		reason = models.LoginFailureBadPassword
+		if user == nil {
+			reason = models.LoginFailureUnknownUser
+		}
+	} else if user.Disabled {
+		reason = models.LoginFailureDisabled
+	}
+	if reason != "" {
+		h.recordLoginFailure(ctx, r, loginReq.Username, ip, reason)
+		sendErrorResponse(w, http.StatusUnauthorized, models.NewRelayError(
+			models.ErrCodeAuthentication,
+			"invalid credentials",
+			nil,
+		))
+		return
+	}
+
+	// Success clears the username's failures but not the IP's, so one valid account
+	// cannot reset the count of guesses against others
+	if err := h.store.ClearLoginFailures(ctx, userLoginSubject(user.Username)); err != nil {
+		slog.ErrorContext(r.Context(), "Failed to clear login failures", "username", user.Username, "error", err)
+	}
+
+	// Start a session and issue its access and refresh tokens
+	response, err := h.startSession(ctx, user)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to start session", "error", err)
+		sendErrorResponse(w, http.StatusInternalServerError, models.NewRelayError(
+			models.ErrCodeAuthentication,
+			"failed to generate token",
+			err,
+		))
+		return
+	}
+
+	// Send response
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+
+	slog.InfoContext(r.Context(), "User logged in", "username", user.Username)
+}
+
+// checkLoginLockout reports whether a login may proceed, and otherwise sends 429.
+// Unknown usernames are locked out like real ones, so the answer reveals nothing.
+func (h *Handler) checkLoginLockout(ctx context.Context, w http.ResponseWriter, r *http.Request, username, ip string) bool {
+	subjects := []string{ipLoginSubject(ip)}
+	if countsUsername(username) {
+		subjects = append(subjects, userLoginSubject(username))
+	}
+
+	lockedUntil, err := h.store.LoginLockedUntil(ctx, subjects...)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to check login lockout", "error", err)
+		sendErrorResponse(w, http.StatusInternalServerError, err.(*models.RelayError))
+		return false
+	}
+
+	if !lockedUntil.After(time.Now()) {
+		return true
+	}
+
+	h.recordAuthEvent(ctx, r, &models.AuthEvent{
+		Type:        models.AuthEventLoginBlocked,
+		Username:    username,
+		IP:          ip,
+		LockedUntil: &lockedUntil,
+	})
+
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(time.Until(lockedUntil).Seconds()))))
+	sendErrorResponse(w, http.StatusTooManyRequests, models.NewRelayError(
+		models.ErrCodeAuthentication,
+		"too many failed login attempts; try again later",
+		nil,
+	))
+	return false
+}
+
+// recordLoginFailure audits a failed login and counts it against the username and
+// the client IP, locking either out once it reaches its limit
+func (h *Handler) recordLoginFailure(ctx context.Context, r *http.Request, username, ip, reason string) {
+	h.recordAuthEvent(ctx, r, &models.AuthEvent{
+		Type:     models.AuthEventLoginFailed,
+		Username: username,
+		IP:       ip,
+		Reason:   reason,
+	})
+
+	limits := []loginLimit{{"ip", ipLoginSubject(ip), h.config.LoginMaxIPAttempts}}
+	if countsUsername(username) {
+		limits = append(limits, loginLimit{"user", userLoginSubject(username), h.config.LoginMaxAttempts})
+	}
+
+	window := time.Duration(h.config.LoginAttemptWindow) * time.Second
+	lockoutBase := time.Duration(h.config.LoginLockoutBase) * time.Second
+	lockoutMax := time.Duration(h.config.LoginLockoutMax) * time.Second
+
+	for _, limit := range limits {
+		lockedUntil, err := h.store.RecordLoginFailure(ctx, limit.subject, limit.maxAttempts, window, lockoutBase, lockoutMax)
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Failed to record login failure", "scope", limit.scope, "error", err)
+			continue
+		}
+		if !lockedUntil.IsZero() {
+			h.recordAuthEvent(ctx, r, &models.AuthEvent{
+				Type:        models.AuthEventLockout,
+				Username:    username,
+				IP:          ip,
+				Reason:      limit.scope,
+				LockedUntil: &lockedUntil,
+			})
+		}
+	}
+}
+
+// recordAuthEvent logs a login audit event and appends it to the audit log
+func (h *Handler) recordAuthEvent(ctx context.Context, r *http.Request, event *models.AuthEvent) {
+	if len(event.Username) > maxLoginUsernameLength {
+		event.Username = event.Username[:maxLoginUsernameLength]
+	}
+	event.RequestID = logging.RequestID(r.Context())
+	event.At = time.Now()
+
+	attrs := []any{"event", event.Type, "username", event.Username, "ip", event.IP}
+	if event.Reason != "" {
+		attrs = append(attrs, "reason", event.Reason)
+	}
+	if event.LockedUntil != nil {
+		attrs = append(attrs, "locked_until", *event.LockedUntil)
+	}
+	slog.WarnContext(r.Context(), "Login audit event", attrs...)
+
+	if err := h.store.RecordAuthEvent(ctx, event); err != nil {
+		slog.ErrorContext(r.Context(), "Failed to record login audit event", "error", err)
+	}
+}
+
+// HandleListAuthEvents handles requests to list the login audit log, newest first
+func (h *Handler) HandleListAuthEvents(w http.ResponseWriter, r *http.Request) {
+	limit := authAuditDefaultLimit
+	if value := r.URL.Query().Get("limit"); value != "" {
+		var err error
+		limit, err = strconv.Atoi(value)
+		if err != nil || limit <= 0 || limit > authAuditMaxLimit {
+			sendErrorResponse(w, http.StatusBadRequest, models.NewRelayError(
+				models.ErrCodeInvalidRequest,
+				fmt.Sprintf("limit must be between 1 and %d", authAuditMaxLimit),
+				err,
+			))
+			return
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	events, err := h.store.ListAuthEvents(ctx, int64(limit))
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to list auth events", "error", err)
+		sendErrorResponse(w, http.StatusInternalServerError, err.(*models.RelayError))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"events": events,
+		"count":  len(events),
+	})
+}
+
+// HandleUnlockUser handles requests from an admin to clear a username's failed
+// logins and lockout
+func (h *Handler) HandleUnlockUser(w http.ResponseWriter, r *http.Request) {
+	username := r.PathValue("username")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := h.store.ClearLoginFailures(ctx, userLoginSubject(username)); err != nil {
+		slog.ErrorContext(r.Context(), "Failed to clear login failures", "username", username, "error", err)
+		sendErrorResponse(w, http.StatusInternalServerError, err.(*models.RelayError))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "User unlocked successfully",
+	})
+
+	slog.InfoContext(r.Context(), "User unlocked", "username", username, "actor", currentUsername(r))
+}
@@ -0,0 +1,106 @@
+package adminapi
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/QuantumSolver/crm-relay/internal/auth"
+	"github.com/QuantumSolver/crm-relay/internal/models"
+)
+
+func TestLoginLockout(t *testing.T) {
+	hash, err := auth.HashPassword("correct-horse-battery")
+	if err != nil {
+		t.Fatalf("Failed to hash password: %v", err)
+	}
+	store := newMemoryStore(&models.User{Username: "alice", PasswordHash: hash, Role: models.RoleAdmin})
+	h := NewHandler(store, &models.Config{
+		LoginMaxAttempts:   3,
+		LoginMaxIPAttempts: 20,
+		LoginAttemptWindow: 900,
+		LoginLockoutBase:   60,
+		LoginLockoutMax:    3600,
+	}, auth.NewJWTService("test-secret", 900, 3600))
+
+	login := func(password string) (int, string) {
+		req := newRequest(http.MethodPost, "/api/auth/login", `{"username":"alice","password":"`+password+`"}`)
+		rec := serve("POST /api/auth/login", h.HandleLogin, req)
+		return rec.Code, rec.Header().Get("Retry-After")
+	}
+
+	for i := 0; i < 3; i++ {
+		if code, _ := login("wrong"); code != http.StatusUnauthorized {
+			t.Fatalf("Attempt %d: expected status 401, got %d", i+1, code)
+		}
+	}
+
+	// Locked out, even with the right password
+	code, retryAfter := login("correct-horse-battery")
+	if code != http.StatusTooManyRequests {
+		t.Fatalf("Expected status 429 once locked out, got %d", code)
+	}
+	if retryAfter == "" {
+		t.Error("Expected a Retry-After header")
+	}
+
+	req := asUser(newRequest(http.MethodDelete, "/api/users/alice/lockout", ""), "bob", models.RoleAdmin, "s1")
+	if rec := serve("DELETE /api/users/{username}/lockout", h.HandleUnlockUser, req); rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200 when unlocking, got %d", rec.Code)
+	}
+	if code, _ := login("correct-horse-battery"); code != http.StatusOK {
+		t.Errorf("Expected status 200 after unlocking, got %d", code)
+	}
+
+	events, _ := store.ListAuthEvents(context.Background(), 100)
+	var lockouts int
+	for _, event := range events {
+		if event.Type == models.AuthEventLockout {
+			lockouts++
+		}
+	}
+	if lockouts != 1 {
+		t.Errorf("Expected one lockout audit event, got %d", lockouts)
+	}
+}
+
+func TestChangePasswordSharesLoginLockout(t *testing.T) {
+	hash, err := auth.HashPassword("correct-horse-battery")
+	if err != nil {
+		t.Fatalf("Failed to hash password: %v", err)
+	}
+	store := newMemoryStore(&models.User{Username: "alice", PasswordHash: hash, Role: models.RoleAdmin})
+	h := NewHandler(store, &models.Config{
+		LoginMaxAttempts:   3,
+		LoginMaxIPAttempts: 20,
+		LoginAttemptWindow: 900,
+		LoginLockoutBase:   60,
+		LoginLockoutMax:    3600,
+	}, auth.NewJWTService("test-secret", 900, 3600))
+
+	changePassword := func(current string) int {
+		req := asUser(newRequest(http.MethodPut, "/api/auth/password",
+			`{"current_password":"`+current+`","new_password":"staple-battery-horse"}`), "alice", models.RoleAdmin, "s1")
+		return serve("PUT /api/auth/password", h.HandleChangePassword, req).Code
+	}
+
+	for i := 0; i < 3; i++ {
+		if code := changePassword("wrong"); code != http.StatusUnauthorized {
+			t.Fatalf("Attempt %d: expected status 401, got %d", i+1, code)
+		}
+	}
+
+	// Locked out, even with the right password, and so is logging in
+	if code := changePassword("correct-horse-battery"); code != http.StatusTooManyRequests {
+		t.Fatalf("Expected status 429 once locked out, got %d", code)
+	}
+	req := newRequest(http.MethodPost, "/api/auth/login", `{"username":"alice","password":"correct-horse-battery"}`)
+	if rec := serve("POST /api/auth/login", h.HandleLogin, req); rec.Code != http.StatusTooManyRequests {
+		t.Errorf("Expected login to be locked out too, got %d", rec.Code)
+	}
+
+	user, _ := store.GetUser(context.Background(), "alice")
+	if user.PasswordHash != hash {
+		t.Error("Expected the password to be unchanged")
+	}
+}
@@ -0,0 +1,86 @@
+package adminapi
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/QuantumSolver/crm-relay/internal/auth"
+	"github.com/QuantumSolver/crm-relay/internal/models"
+)
+
+// JWTMiddleware validates JWT tokens and rejects revoked ones
+func JWTMiddleware(jwtService *auth.JWTService, store Store) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// Skip JWT for health check, login and refresh
+			if r.URL.Path == "/health" || r.URL.Path == "/api/auth/login" || r.URL.Path == "/api/auth/refresh" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			// Skip JWT for webhook endpoints (they use API key auth)
+			if r.URL.Path == "/webhook" || (len(r.URL.Path) > 8 && r.URL.Path[:8] == "/webhook/") {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			// Get token from Authorization header
+			authHeader := r.Header.Get("Authorization")
+			if authHeader == "" {
+				sendErrorResponse(w, http.StatusUnauthorized, models.NewRelayError(
+					models.ErrCodeAuthentication,
+					"missing authorization header",
+					nil,
+				))
+				return
+			}
+
+			// Extract token from "Bearer <token>"
+			if len(authHeader) < 7 || authHeader[:7] != "Bearer " {
+				sendErrorResponse(w, http.StatusUnauthorized, models.NewRelayError(
+					models.ErrCodeAuthentication,
+					"invalid authorization header format",
+					nil,
+				))
+				return
+			}
+
+			tokenString := authHeader[7:]
+
+			// Validate token
+			claims, err := jwtService.ValidateToken(tokenString)
+			if err != nil {
+				sendErrorResponse(w, http.StatusUnauthorized, models.NewRelayError(
+					models.ErrCodeAuthentication,
+					"invalid token",
+					err,
+				))
+				return
+			}
+
+			// Reject tokens that were revoked or whose session has ended
+			ctx, cancel := context.WithTimeout(r.Context(), 2*time.Second)
+			revoked, err := store.IsTokenRevoked(ctx, claims)
+			cancel()
+			if err != nil {
+				slog.ErrorContext(r.Context(), "Failed to check token revocation", "error", err)
+				sendErrorResponse(w, http.StatusServiceUnavailable, err.(*models.RelayError))
+				return
+			}
+			if revoked {
+				sendErrorResponse(w, http.StatusUnauthorized, models.NewRelayError(
+					models.ErrCodeAuthentication,
+					"token has been revoked",
+					nil,
+				))
+				return
+			}
+
+			// Add user context to request
+			ctx = context.WithValue(r.Context(), "user", claims)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
@@ -0,0 +1,254 @@
+package adminapi
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/QuantumSolver/crm-relay/internal/auth"
+	"github.com/QuantumSolver/crm-relay/internal/models"
+)
+
+// Session endpoints
+
+// HandleRefreshToken handles requests to exchange a refresh token for a new access
+// token and a new refresh token. Each refresh token works once; presenting a used one
+// ends the session, since it means the token was stolen or replayed.
+func (h *Handler) HandleRefreshToken(w http.ResponseWriter, r *http.Request) {
+	var req models.RefreshRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendErrorResponse(w, http.StatusBadRequest, models.NewRelayError(
+			models.ErrCodeInvalidRequest,
+			"invalid request body",
+			err,
+		))
+		return
+	}
+
+	invalid := models.NewRelayError(models.ErrCodeAuthentication, "invalid refresh token", nil)
+
+	sessionID, err := auth.ParseRefreshToken(req.RefreshToken)
+	if err != nil {
+		sendErrorResponse(w, http.StatusUnauthorized, invalid)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	session, err := h.store.GetSession(ctx, sessionID)
+	if err != nil {
+		sendSessionError(w, r, err, invalid)
+		return
+	}
+
+	// Sessions of deleted or disabled users cannot be extended
+	user, err := h.store.GetUser(ctx, session.Username)
+	if err != nil || user.Disabled {
+		h.endSession(ctx, r, session)
+		sendErrorResponse(w, http.StatusUnauthorized, invalid)
+		return
+	}
+
+	refreshToken, refreshHash, err := auth.NewRefreshToken(session.ID)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to generate refresh token", "error", err)
+		sendErrorResponse(w, http.StatusInternalServerError, models.NewRelayError(
+			models.ErrCodeAuthentication,
+			"failed to generate token",
+			err,
+		))
+		return
+	}
+
+	refreshExpiresAt := time.Now().Add(h.jwtService.RefreshExpiration())
+	rotated, err := h.store.RotateSession(ctx, session.ID, auth.HashRefreshToken(req.RefreshToken), refreshHash, refreshExpiresAt)
+	if err != nil {
+		sendSessionError(w, r, err, invalid)
+		return
+	}
+	if !rotated {
+		slog.WarnContext(r.Context(), "Refresh token reused; ending session",
+			"username", session.Username, "session_id", session.ID)
+		h.endSession(ctx, r, session)
+		sendErrorResponse(w, http.StatusUnauthorized, invalid)
+		return
+	}
+
+	token, expiresAt, err := h.jwtService.GenerateToken(user, session.ID)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to generate token", "error", err)
+		sendErrorResponse(w, http.StatusInternalServerError, models.NewRelayError(
+			models.ErrCodeAuthentication,
+			"failed to generate token",
+			err,
+		))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(models.LoginResponse{
+		Token:            token,
+		User:             user.Info(),
+		ExpiresAt:        expiresAt,
+		RefreshToken:     refreshToken,
+		RefreshExpiresAt: refreshExpiresAt.Unix(),
+	})
+}
+
+// HandleLogout handles requests to end the current session
+func (h *Handler) HandleLogout(w http.ResponseWriter, r *http.Request) {
+	claims, ok := r.Context().Value("user").(*models.JWTClaims)
+	if !ok {
+		sendErrorResponse(w, http.StatusUnauthorized, models.NewRelayError(
+			models.ErrCodeAuthentication,
+			"user not authenticated",
+			nil,
+		))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := h.store.RevokeToken(ctx, claims.TokenID, time.Unix(claims.ExpiresAt, 0)); err != nil {
+		slog.ErrorContext(r.Context(), "Failed to revoke token", "error", err)
+		sendErrorResponse(w, http.StatusInternalServerError, err.(*models.RelayError))
+		return
+	}
+
+	session := &models.Session{ID: claims.SessionID, Username: claims.Username}
+	if err := h.store.DeleteSession(ctx, session); err != nil {
+		slog.ErrorContext(r.Context(), "Failed to delete session", "error", err)
+		sendErrorResponse(w, http.StatusInternalServerError, err.(*models.RelayError))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "Logged out successfully",
+	})
+
+	slog.InfoContext(r.Context(), "User logged out", "username", claims.Username, "session_id", claims.SessionID)
+}
+
+// HandleLogoutAll handles requests to end every session of the current user
+func (h *Handler) HandleLogoutAll(w http.ResponseWriter, r *http.Request) {
+	username := currentUsername(r)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	h.sendSessionsRevoked(ctx, w, r, username)
+}
+
+// HandleRevokeUserSessions handles requests from an admin to end every session of a user
+func (h *Handler) HandleRevokeUserSessions(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	user, err := h.store.GetUser(ctx, r.PathValue("username"))
+	if err != nil {
+		sendUserLookupError(w, r, err)
+		return
+	}
+
+	h.sendSessionsRevoked(ctx, w, r, user.Username)
+}
+
+// sendSessionsRevoked ends every session of username and reports how many were ended
+func (h *Handler) sendSessionsRevoked(ctx context.Context, w http.ResponseWriter, r *http.Request, username string) {
+	revoked, err := h.store.DeleteUserSessions(ctx, username, "")
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to revoke sessions", "username", username, "error", err)
+		sendErrorResponse(w, http.StatusInternalServerError, err.(*models.RelayError))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":  true,
+		"revoked":  revoked,
+		"message":  "Sessions revoked successfully",
+		"username": username,
+	})
+
+	slog.InfoContext(r.Context(), "Sessions revoked",
+		"username", username, "count", revoked, "actor", currentUsername(r))
+}
+
+// startSession creates a session for user and returns the tokens for it
+func (h *Handler) startSession(ctx context.Context, user *models.User) (*models.LoginResponse, error) {
+	sessionID, err := auth.GenerateID()
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, refreshHash, err := auth.NewRefreshToken(sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	token, expiresAt, err := h.jwtService.GenerateToken(user, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	session := &models.Session{
+		ID:          sessionID,
+		Username:    user.Username,
+		RefreshHash: refreshHash,
+		CreatedAt:   now,
+		RefreshedAt: now,
+		ExpiresAt:   now.Add(h.jwtService.RefreshExpiration()),
+	}
+	if err := h.store.CreateSession(ctx, session); err != nil {
+		return nil, err
+	}
+
+	return &models.LoginResponse{
+		Token:            token,
+		User:             user.Info(),
+		ExpiresAt:        expiresAt,
+		RefreshToken:     refreshToken,
+		RefreshExpiresAt: session.ExpiresAt.Unix(),
+	}, nil
+}
+
+// endSession deletes a session, logging rather than reporting a failure
+func (h *Handler) endSession(ctx context.Context, r *http.Request, session *models.Session) {
+	if err := h.store.DeleteSession(ctx, session); err != nil {
+		slog.ErrorContext(r.Context(), "Failed to delete session", "session_id", session.ID, "error", err)
+	}
+}
+
+// revokeUserSessions ends the sessions of username other than keep after its account
+// changed, logging rather than reporting a failure
+func (h *Handler) revokeUserSessions(ctx context.Context, r *http.Request, username, keep string) {
+	revoked, err := h.store.DeleteUserSessions(ctx, username, keep)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to revoke sessions", "username", username, "error", err)
+		return
+	}
+	if revoked > 0 {
+		slog.InfoContext(r.Context(), "Sessions revoked", "username", username, "count", revoked)
+	}
+}
+
+// sendSessionError maps a failed session lookup to the invalid token error or 500
+func sendSessionError(w http.ResponseWriter, r *http.Request, err error, invalid *models.RelayError) {
+	relayErr := err.(*models.RelayError)
+	if relayErr.Code == models.ErrCodeRedisConnection {
+		slog.ErrorContext(r.Context(), "Failed to read session", "error", err)
+		sendErrorResponse(w, http.StatusInternalServerError, relayErr)
+		return
+	}
+	sendErrorResponse(w, http.StatusUnauthorized, invalid)
+}
@@ -0,0 +1,77 @@
+package adminapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/QuantumSolver/crm-relay/internal/auth"
+	"github.com/QuantumSolver/crm-relay/internal/models"
+)
+
+func newSessionHandler(t *testing.T) (*Handler, *memoryStore, *models.LoginResponse) {
+	t.Helper()
+	user := &models.User{ID: "user-1", Username: "alice", Role: models.RoleAdmin}
+	store := newMemoryStore(user)
+	h := NewHandler(store, &models.Config{}, auth.NewJWTService("test-secret", 900, 3600))
+
+	response, err := h.startSession(context.Background(), user)
+	if err != nil {
+		t.Fatalf("Failed to start session: %v", err)
+	}
+	return h, store, response
+}
+
+func refresh(h *Handler, refreshToken string) *httptest.ResponseRecorder {
+	body, _ := json.Marshal(models.RefreshRequest{RefreshToken: refreshToken})
+	return serve("POST /api/auth/refresh", h.HandleRefreshToken, newRequest(http.MethodPost, "/api/auth/refresh", string(body)))
+}
+
+func TestRefreshTokenWorksOnce(t *testing.T) {
+	h, store, login := newSessionHandler(t)
+
+	rec := refresh(h, login.RefreshToken)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body)
+	}
+	var refreshed models.LoginResponse
+	if err := json.NewDecoder(rec.Body).Decode(&refreshed); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if refreshed.RefreshToken == login.RefreshToken {
+		t.Error("Expected a new refresh token")
+	}
+
+	// Replaying the used token ends the session, so the new token stops working too
+	if rec := refresh(h, login.RefreshToken); rec.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status 401 for a reused refresh token, got %d", rec.Code)
+	}
+	if len(store.sessions) != 0 {
+		t.Errorf("Expected the session to end, got %v", store.sessions)
+	}
+	if rec := refresh(h, refreshed.RefreshToken); rec.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status 401 after the session ended, got %d", rec.Code)
+	}
+}
+
+func TestJWTMiddlewareRejectsTokensAfterLogout(t *testing.T) {
+	h, store, login := newSessionHandler(t)
+
+	protected := JWTMiddleware(h.jwtService, store)(http.HandlerFunc(h.HandleLogout))
+	logout := func() int {
+		req := newRequest(http.MethodPost, "/api/auth/logout", "")
+		req.Header.Set("Authorization", "Bearer "+login.Token)
+		rec := httptest.NewRecorder()
+		protected.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	if code := logout(); code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", code)
+	}
+	if code := logout(); code != http.StatusUnauthorized {
+		t.Errorf("Expected status 401 for a revoked token, got %d", code)
+	}
+}
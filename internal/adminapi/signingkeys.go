@@ -0,0 +1,97 @@
+package adminapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/QuantumSolver/crm-relay/internal/auth"
+	"github.com/QuantumSolver/crm-relay/internal/models"
+)
+
+// JWT signing key endpoints
+
+// HandleListSigningKeys handles requests to list the JWT signing keys
+func (h *Handler) HandleListSigningKeys(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	keys, err := h.jwtService.SigningKeys(ctx)
+	if err != nil {
+		sendSigningKeyError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(keys)
+}
+
+// HandleRotateSigningKey handles requests to add a signing key that signs all new
+// tokens; tokens signed by earlier keys stay valid until those keys are retired
+func (h *Handler) HandleRotateSigningKey(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	key, err := h.jwtService.RotateKey(ctx)
+	if err != nil {
+		sendSigningKeyError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(key)
+
+	slog.InfoContext(r.Context(), "JWT signing key rotated", "kid", key.ID, "actor", currentUsername(r))
+}
+
+// HandleRetireSigningKey handles requests to stop accepting tokens signed by a key
+func (h *Handler) HandleRetireSigningKey(w http.ResponseWriter, r *http.Request) {
+	kid := r.PathValue("kid")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := h.jwtService.RetireKey(ctx, kid); err != nil {
+		sendSigningKeyError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "Signing key retired successfully",
+	})
+
+	slog.InfoContext(r.Context(), "JWT signing key retired", "kid", kid, "actor", currentUsername(r))
+}
+
+// sendSigningKeyError maps a failed key set operation to an error response
+func sendSigningKeyError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, auth.ErrUnknownSigningKey):
+		sendErrorResponse(w, http.StatusNotFound, models.NewRelayError(
+			models.ErrCodeInvalidRequest,
+			err.Error(),
+			nil,
+		))
+	case errors.Is(err, auth.ErrStaticSigningKey), errors.Is(err, auth.ErrActiveSigningKey):
+		sendErrorResponse(w, http.StatusConflict, models.NewRelayError(
+			models.ErrCodeInvalidRequest,
+			err.Error(),
+			nil,
+		))
+	default:
+		slog.ErrorContext(r.Context(), "Failed to update signing keys", "error", err)
+		var relayErr *models.RelayError
+		if !errors.As(err, &relayErr) {
+			relayErr = models.NewRelayError(models.ErrCodeAuthentication, "failed to update signing keys", err)
+		}
+		sendErrorResponse(w, http.StatusInternalServerError, relayErr)
+	}
+}
@@ -0,0 +1,33 @@
+package adminapi
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/QuantumSolver/crm-relay/internal/auth"
+	"github.com/QuantumSolver/crm-relay/internal/models"
+)
+
+func TestSigningKeysWithStaticSecret(t *testing.T) {
+	h := NewHandler(newMemoryStore(), &models.Config{}, auth.NewJWTService("test-secret", 900, 3600))
+
+	req := asUser(newRequest(http.MethodPost, "/api/auth/signing-keys", ""), "alice", models.RoleAdmin, "s1")
+	if rec := serve("POST /api/auth/signing-keys", h.HandleRotateSigningKey, req); rec.Code != http.StatusConflict {
+		t.Errorf("Expected status 409 when rotating with JWT_SECRET set, got %d", rec.Code)
+	}
+
+	req = asUser(newRequest(http.MethodDelete, "/api/auth/signing-keys/unknown", ""), "alice", models.RoleAdmin, "s1")
+	if rec := serve("DELETE /api/auth/signing-keys/{kid}", h.HandleRetireSigningKey, req); rec.Code != http.StatusConflict {
+		t.Errorf("Expected status 409 when retiring with JWT_SECRET set, got %d", rec.Code)
+	}
+
+	req = asUser(newRequest(http.MethodGet, "/api/auth/signing-keys", ""), "alice", models.RoleAdmin, "s1")
+	rec := serve("GET /api/auth/signing-keys", h.HandleListSigningKeys, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", rec.Code)
+	}
+	if body := rec.Body.String(); !strings.Contains(body, auth.StaticKeyID) || strings.Contains(body, "test-secret") {
+		t.Errorf("Expected the static key without its secret, got %s", body)
+	}
+}
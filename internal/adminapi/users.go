@@ -0,0 +1,445 @@
+package adminapi
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/QuantumSolver/crm-relay/internal/auth"
+	"github.com/QuantumSolver/crm-relay/internal/models"
+)
+
+// User management endpoints
+
+// HandleGetCurrentUser handles requests to get the current user
+func (h *Handler) HandleGetCurrentUser(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		sendErrorResponse(w, http.StatusMethodNotAllowed, models.NewRelayError(
+			models.ErrCodeInvalidRequest,
+			"method not allowed",
+			nil,
+		))
+		return
+	}
+
+	// Get user from context
+	claims, ok := r.Context().Value("user").(*models.JWTClaims)
+	if !ok {
+		sendErrorResponse(w, http.StatusUnauthorized, models.NewRelayError(
+			models.ErrCodeAuthentication,
+			"user not authenticated",
+			nil,
+		))
+		return
+	}
+
+	// Get user from storage
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	user, err := h.store.GetUser(ctx, claims.Username)
+	if err != nil {
+		sendErrorResponse(w, http.StatusNotFound, models.NewRelayError(
+			models.ErrCodeInvalidRequest,
+			"user not found",
+			err,
+		))
+		return
+	}
+
+	info := user.Info()
+	for _, permission := range auth.Permissions(user.Role) {
+		info.Permissions = append(info.Permissions, string(permission))
+	}
+
+	// Send response
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(info)
+}
+
+// HandleListUsers handles requests to list all users
+func (h *Handler) HandleListUsers(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	users, err := h.store.ListUsers(ctx)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to list users", "error", err)
+		sendErrorResponse(w, http.StatusInternalServerError, err.(*models.RelayError))
+		return
+	}
+
+	infos := make([]models.UserInfo, 0, len(users))
+	for _, user := range users {
+		infos = append(infos, user.Info())
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(infos)
+}
+
+// HandleCreateUser handles requests to create a user
+func (h *Handler) HandleCreateUser(w http.ResponseWriter, r *http.Request) {
+	var req models.CreateUserRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendErrorResponse(w, http.StatusBadRequest, models.NewRelayError(
+			models.ErrCodeInvalidRequest,
+			"invalid request body",
+			err,
+		))
+		return
+	}
+
+	if err := auth.ValidateUsername(req.Username); err != nil {
+		sendErrorResponse(w, http.StatusBadRequest, models.NewRelayError(
+			models.ErrCodeInvalidRequest,
+			err.Error(),
+			nil,
+		))
+		return
+	}
+
+	if !auth.ValidRole(req.Role) {
+		sendErrorResponse(w, http.StatusBadRequest, models.NewRelayError(
+			models.ErrCodeInvalidRequest,
+			"role must be 'admin', 'operator' or 'viewer'",
+			nil,
+		))
+		return
+	}
+
+	if err := auth.ValidatePassword(req.Password); err != nil {
+		sendErrorResponse(w, http.StatusBadRequest, models.NewRelayError(
+			models.ErrCodeInvalidRequest,
+			err.Error(),
+			nil,
+		))
+		return
+	}
+
+	passwordHash, err := auth.HashPassword(req.Password)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to hash password", "error", err)
+		sendErrorResponse(w, http.StatusInternalServerError, models.NewRelayError(
+			models.ErrCodeInvalidRequest,
+			"failed to hash password",
+			nil,
+		))
+		return
+	}
+
+	id, err := auth.GenerateID()
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to generate ID", "error", err)
+		sendErrorResponse(w, http.StatusInternalServerError, models.NewRelayError(
+			models.ErrCodeInvalidRequest,
+			"failed to generate ID",
+			err,
+		))
+		return
+	}
+
+	now := time.Now()
+	user := &models.User{
+		ID:           id,
+		Username:     req.Username,
+		PasswordHash: passwordHash,
+		Role:         req.Role,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := h.store.CreateUser(ctx, user); err != nil {
+		relayErr := err.(*models.RelayError)
+		if relayErr.Code == models.ErrCodeInvalidRequest {
+			sendErrorResponse(w, http.StatusConflict, relayErr)
+			return
+		}
+		slog.ErrorContext(r.Context(), "Failed to create user", "error", err)
+		sendErrorResponse(w, http.StatusInternalServerError, relayErr)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(user.Info())
+
+	slog.InfoContext(r.Context(), "User created",
+		"username", user.Username, "role", user.Role, "actor", currentUsername(r))
+}
+
+// HandleGetUser handles requests to get a user
+func (h *Handler) HandleGetUser(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	user, err := h.store.GetUser(ctx, r.PathValue("username"))
+	if err != nil {
+		sendUserLookupError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(user.Info())
+}
+
+// HandleUpdateUser handles requests to change a user's role, disable or enable the
+// account, or reset its password
+func (h *Handler) HandleUpdateUser(w http.ResponseWriter, r *http.Request) {
+	var req models.UpdateUserRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendErrorResponse(w, http.StatusBadRequest, models.NewRelayError(
+			models.ErrCodeInvalidRequest,
+			"invalid request body",
+			err,
+		))
+		return
+	}
+
+	if req.Role != nil && !auth.ValidRole(*req.Role) {
+		sendErrorResponse(w, http.StatusBadRequest, models.NewRelayError(
+			models.ErrCodeInvalidRequest,
+			"role must be 'admin', 'operator' or 'viewer'",
+			nil,
+		))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	user, err := h.store.GetUser(ctx, r.PathValue("username"))
+	if err != nil {
+		sendUserLookupError(w, r, err)
+		return
+	}
+
+	// Admins cannot lock themselves out
+	demoted := req.Role != nil && *req.Role != models.RoleAdmin
+	disabled := req.Disabled != nil && *req.Disabled
+	if user.Username == currentUsername(r) && (demoted || disabled) {
+		sendErrorResponse(w, http.StatusBadRequest, models.NewRelayError(
+			models.ErrCodeInvalidRequest,
+			"cannot disable or demote your own account",
+			nil,
+		))
+		return
+	}
+
+	if req.Password != nil {
+		if err := auth.ValidatePassword(*req.Password); err != nil {
+			sendErrorResponse(w, http.StatusBadRequest, models.NewRelayError(
+				models.ErrCodeInvalidRequest,
+				err.Error(),
+				nil,
+			))
+			return
+		}
+
+		passwordHash, err := auth.HashPassword(*req.Password)
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Failed to hash password", "error", err)
+			sendErrorResponse(w, http.StatusInternalServerError, models.NewRelayError(
+				models.ErrCodeInvalidRequest,
+				"failed to hash password",
+				nil,
+			))
+			return
+		}
+		user.PasswordHash = passwordHash
+	}
+	if req.Role != nil {
+		user.Role = *req.Role
+	}
+	if req.Disabled != nil {
+		user.Disabled = *req.Disabled
+	}
+	user.UpdatedAt = time.Now()
+
+	// Demoting or disabling the last enabled admin is refused by the store, atomically
+	// with the write, so concurrent requests cannot leave no admin
+	if err := h.store.UpdateUserKeepingAdmin(ctx, user); err != nil {
+		sendUserWriteError(w, r, err)
+		return
+	}
+
+	// Tokens carry the role, so any change to the account ends its other sessions
+	keep := ""
+	if user.Username == currentUsername(r) {
+		keep = currentSessionID(r)
+	}
+	h.revokeUserSessions(ctx, r, user.Username, keep)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(user.Info())
+
+	slog.InfoContext(r.Context(), "User updated",
+		"username", user.Username,
+		"role", user.Role,
+		"disabled", user.Disabled,
+		"password_reset", req.Password != nil,
+		"actor", currentUsername(r),
+	)
+}
+
+// HandleDeleteUser handles requests to delete a user
+func (h *Handler) HandleDeleteUser(w http.ResponseWriter, r *http.Request) {
+	username := r.PathValue("username")
+	if username == currentUsername(r) {
+		sendErrorResponse(w, http.StatusBadRequest, models.NewRelayError(
+			models.ErrCodeInvalidRequest,
+			"cannot delete your own account",
+			nil,
+		))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := h.store.DeleteUserKeepingAdmin(ctx, username); err != nil {
+		sendUserWriteError(w, r, err)
+		return
+	}
+	h.revokeUserSessions(ctx, r, username, "")
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "User deleted successfully",
+	})
+
+	slog.InfoContext(r.Context(), "User deleted", "username", username, "actor", currentUsername(r))
+}
+
+// HandleChangePassword handles requests from the current user to change their password
+func (h *Handler) HandleChangePassword(w http.ResponseWriter, r *http.Request) {
+	var req models.ChangePasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendErrorResponse(w, http.StatusBadRequest, models.NewRelayError(
+			models.ErrCodeInvalidRequest,
+			"invalid request body",
+			err,
+		))
+		return
+	}
+
+	if err := auth.ValidatePassword(req.NewPassword); err != nil {
+		sendErrorResponse(w, http.StatusBadRequest, models.NewRelayError(
+			models.ErrCodeInvalidRequest,
+			err.Error(),
+			nil,
+		))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// Guesses at the current password count towards the same lockout as logins, so a
+	// stolen session cannot be used to brute-force it
+	username := currentUsername(r)
+	ip := auth.ClientIP(r, h.config.TrustedProxyHops)
+	if !h.checkLoginLockout(ctx, w, r, username, ip) {
+		return
+	}
+
+	user, err := h.store.GetUser(ctx, username)
+	if err != nil {
+		sendUserLookupError(w, r, err)
+		return
+	}
+
+	if !auth.VerifyPassword(req.CurrentPassword, user.PasswordHash) {
+		h.recordLoginFailure(ctx, r, username, ip, models.LoginFailureBadPassword)
+		sendErrorResponse(w, http.StatusUnauthorized, models.NewRelayError(
+			models.ErrCodeAuthentication,
+			"current password is incorrect",
+			nil,
+		))
+		return
+	}
+
+	passwordHash, err := auth.HashPassword(req.NewPassword)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to hash password", "error", err)
+		sendErrorResponse(w, http.StatusInternalServerError, models.NewRelayError(
+			models.ErrCodeInvalidRequest,
+			"failed to hash password",
+			nil,
+		))
+		return
+	}
+
+	// The new hash is only written while the account is unchanged, so a concurrent
+	// disable, role change or delete is never undone
+	changed, err := h.store.ChangeUserPassword(ctx, user, passwordHash)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to change password", "error", err)
+		sendErrorResponse(w, http.StatusInternalServerError, err.(*models.RelayError))
+		return
+	}
+	if !changed {
+		sendErrorResponse(w, http.StatusConflict, models.NewRelayError(
+			models.ErrCodeInvalidRequest,
+			"account changed while changing password, please try again",
+			nil,
+		))
+		return
+	}
+
+	if err := h.store.ClearLoginFailures(ctx, userLoginSubject(user.Username)); err != nil {
+		slog.ErrorContext(r.Context(), "Failed to clear login failures", "username", user.Username, "error", err)
+	}
+
+	// Sign out everywhere else; the current session stays
+	h.revokeUserSessions(ctx, r, user.Username, currentSessionID(r))
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "Password changed successfully",
+	})
+
+	slog.InfoContext(r.Context(), "Password changed", "username", user.Username)
+}
+
+// sendUserLookupError maps a failed user lookup to 404 or 500
+func sendUserLookupError(w http.ResponseWriter, r *http.Request, err error) {
+	relayErr := err.(*models.RelayError)
+	if relayErr.Code == models.ErrCodeRedisConnection || relayErr.Code == models.ErrCodeStreamRead {
+		slog.ErrorContext(r.Context(), "Failed to retrieve user", "error", err)
+		sendErrorResponse(w, http.StatusInternalServerError, relayErr)
+		return
+	}
+	sendErrorResponse(w, http.StatusNotFound, models.NewRelayError(
+		models.ErrCodeInvalidRequest,
+		"user not found",
+		nil,
+	))
+}
+
+// sendUserWriteError maps a failed user update or delete to 404, 409 or 500
+func sendUserWriteError(w http.ResponseWriter, r *http.Request, err error) {
+	relayErr := err.(*models.RelayError)
+	switch relayErr.Code {
+	case models.ErrCodeLastAdmin:
+		sendErrorResponse(w, http.StatusConflict, relayErr)
+	case models.ErrCodeInvalidRequest:
+		sendErrorResponse(w, http.StatusNotFound, relayErr)
+	default:
+		slog.ErrorContext(r.Context(), "Failed to write user", "error", err)
+		sendErrorResponse(w, http.StatusInternalServerError, relayErr)
+	}
+}
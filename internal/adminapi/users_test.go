@@ -0,0 +1,194 @@
+package adminapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/QuantumSolver/crm-relay/internal/auth"
+	"github.com/QuantumSolver/crm-relay/internal/models"
+)
+
+func TestHandleCreateUser(t *testing.T) {
+	store := newMemoryStore()
+	h := NewHandler(store, &models.Config{}, nil)
+
+	req := asUser(newRequest(http.MethodPost, "/api/users",
+		`{"username":"bob","password":"correct-horse-battery","role":"operator"}`), "alice", models.RoleAdmin, "s1")
+	rec := serve("POST /api/users", h.HandleCreateUser, req)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", rec.Code, rec.Body)
+	}
+	if strings.Contains(rec.Body.String(), "password") {
+		t.Errorf("Expected the response to omit the password hash, got %s", rec.Body)
+	}
+
+	user, err := store.GetUser(context.Background(), "bob")
+	if err != nil {
+		t.Fatalf("Expected the user to be stored: %v", err)
+	}
+	if user.Role != models.RoleOperator || user.PasswordHash == "" {
+		t.Errorf("Expected an operator with a password hash, got %+v", user)
+	}
+
+	req = asUser(newRequest(http.MethodPost, "/api/users",
+		`{"username":"bob","password":"correct-horse-battery","role":"operator"}`), "alice", models.RoleAdmin, "s1")
+	rec = serve("POST /api/users", h.HandleCreateUser, req)
+	if rec.Code != http.StatusConflict {
+		t.Errorf("Expected status 409 for a taken username, got %d", rec.Code)
+	}
+}
+
+func TestHandleUpdateUserRevokesSessions(t *testing.T) {
+	store := newMemoryStore(
+		&models.User{Username: "alice", Role: models.RoleAdmin},
+		&models.User{Username: "bob", Role: models.RoleOperator},
+	)
+	store.sessions["s1"] = models.Session{ID: "s1", Username: "bob"}
+	store.sessions["s2"] = models.Session{ID: "s2", Username: "bob"}
+	h := NewHandler(store, &models.Config{}, nil)
+
+	req := asUser(newRequest(http.MethodPut, "/api/users/bob", `{"role":"viewer"}`), "alice", models.RoleAdmin, "a1")
+	rec := serve("PUT /api/users/{username}", h.HandleUpdateUser, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body)
+	}
+
+	var info models.UserInfo
+	if err := json.NewDecoder(rec.Body).Decode(&info); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if info.Role != models.RoleViewer {
+		t.Errorf("Expected role viewer, got %q", info.Role)
+	}
+	if len(store.sessions) != 0 {
+		t.Errorf("Expected bob's sessions to be revoked, got %v", store.sessions)
+	}
+}
+
+func TestAdminsCannotRemoveThemselvesOrTheLastAdmin(t *testing.T) {
+	store := newMemoryStore(
+		&models.User{Username: "alice", Role: models.RoleAdmin},
+		&models.User{Username: "bob", Role: models.RoleAdmin, Disabled: true},
+		&models.User{Username: "carol", Role: models.RoleOperator},
+	)
+	h := NewHandler(store, &models.Config{}, nil)
+
+	tests := []struct {
+		name    string
+		method  string
+		target  string
+		pattern string
+		handler http.HandlerFunc
+		body    string
+		actor   string
+		want    int
+	}{
+		{"demote self", http.MethodPut, "/api/users/alice", "PUT /api/users/{username}", h.HandleUpdateUser, `{"role":"viewer"}`, "alice", http.StatusBadRequest},
+		{"delete self", http.MethodDelete, "/api/users/alice", "DELETE /api/users/{username}", h.HandleDeleteUser, "", "alice", http.StatusBadRequest},
+		{"disable last admin", http.MethodPut, "/api/users/alice", "PUT /api/users/{username}", h.HandleUpdateUser, `{"disabled":true}`, "carol", http.StatusConflict},
+		{"delete last admin", http.MethodDelete, "/api/users/alice", "DELETE /api/users/{username}", h.HandleDeleteUser, "", "carol", http.StatusConflict},
+		{"delete disabled admin", http.MethodDelete, "/api/users/bob", "DELETE /api/users/{username}", h.HandleDeleteUser, "", "alice", http.StatusOK},
+		{"unknown user", http.MethodDelete, "/api/users/dave", "DELETE /api/users/{username}", h.HandleDeleteUser, "", "alice", http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		req := asUser(newRequest(tt.method, tt.target, tt.body), tt.actor, models.RoleAdmin, "s1")
+		rec := serve(tt.pattern, tt.handler, req)
+		if rec.Code != tt.want {
+			t.Errorf("%s: expected status %d, got %d: %s", tt.name, tt.want, rec.Code, rec.Body)
+		}
+	}
+
+	if _, err := store.GetUser(context.Background(), "alice"); err != nil {
+		t.Errorf("Expected the last admin to remain: %v", err)
+	}
+}
+
+func TestConcurrentDemotionsKeepAnAdmin(t *testing.T) {
+	store := newMemoryStore(
+		&models.User{Username: "alice", Role: models.RoleAdmin},
+		&models.User{Username: "bob", Role: models.RoleAdmin},
+	)
+	h := NewHandler(store, &models.Config{}, nil)
+
+	// Each admin disables the other at the same time; only one may succeed
+	var wg sync.WaitGroup
+	codes := make([]int, 2)
+	for i, pair := range [][2]string{{"alice", "bob"}, {"bob", "alice"}} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			req := asUser(newRequest(http.MethodPut, "/api/users/"+pair[1], `{"disabled":true}`), pair[0], models.RoleAdmin, "s1")
+			codes[i] = serve("PUT /api/users/{username}", h.HandleUpdateUser, req).Code
+		}()
+	}
+	wg.Wait()
+
+	users, _ := store.ListUsers(context.Background())
+	var admins int
+	for _, user := range users {
+		if user.Role == models.RoleAdmin && !user.Disabled {
+			admins++
+		}
+	}
+	if admins != 1 {
+		t.Errorf("Expected one enabled admin to remain, got %d (statuses %v)", admins, codes)
+	}
+}
+
+// disablingStore disables a user right after it is read, as a concurrent admin
+// update would
+type disablingStore struct {
+	*memoryStore
+}
+
+func (s disablingStore) GetUser(ctx context.Context, username string) (*models.User, error) {
+	user, err := s.memoryStore.GetUser(ctx, username)
+	if err == nil {
+		s.mu.Lock()
+		disabled := *user
+		disabled.Disabled = true
+		disabled.UpdatedAt = time.Now()
+		s.users[username] = disabled
+		s.mu.Unlock()
+	}
+	return user, err
+}
+
+func TestHandleChangePasswordKeepsConcurrentChanges(t *testing.T) {
+	hash, err := auth.HashPassword("correct-horse-battery")
+	if err != nil {
+		t.Fatalf("Failed to hash password: %v", err)
+	}
+	store := newMemoryStore(&models.User{Username: "bob", PasswordHash: hash, Role: models.RoleOperator})
+	h := NewHandler(disablingStore{store}, &models.Config{}, nil)
+
+	req := asUser(newRequest(http.MethodPut, "/api/auth/password",
+		`{"current_password":"correct-horse-battery","new_password":"staple-battery-horse"}`), "bob", models.RoleOperator, "s1")
+	rec := serve("PUT /api/auth/password", h.HandleChangePassword, req)
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("Expected status 409, got %d: %s", rec.Code, rec.Body)
+	}
+
+	user, _ := store.GetUser(context.Background(), "bob")
+	if !user.Disabled || user.PasswordHash != hash {
+		t.Errorf("Expected bob to stay disabled with the old password, got %+v", user)
+	}
+
+	// Without a concurrent change the new password is stored
+	store.users["alice"] = models.User{Username: "alice", PasswordHash: hash, Role: models.RoleAdmin}
+	req = asUser(newRequest(http.MethodPut, "/api/auth/password",
+		`{"current_password":"correct-horse-battery","new_password":"staple-battery-horse"}`), "alice", models.RoleAdmin, "a1")
+	if rec := serve("PUT /api/auth/password", NewHandler(store, &models.Config{}, nil).HandleChangePassword, req); rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body)
+	}
+	user, _ = store.GetUser(context.Background(), "alice")
+	if !auth.VerifyPassword("staple-battery-horse", user.PasswordHash) {
+		t.Error("Expected alice's new password to be stored")
+	}
+}
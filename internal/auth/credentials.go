@@ -0,0 +1,33 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+)
+
+// MinPasswordLength is the shortest password accepted for new and changed passwords
+const MinPasswordLength = 12
+
+// validUsername keeps usernames safe to embed in Redis keys and log lines
+var validUsername = regexp.MustCompile(`^[A-Za-z0-9._@-]{3,64}$`)
+
+// ValidateUsername checks that a username can be used for a new account
+func ValidateUsername(username string) error {
+	if !validUsername.MatchString(username) {
+		return errors.New("username must be 3-64 characters of letters, digits, '.', '_', '@' or '-'")
+	}
+	return nil
+}
+
+// ValidatePassword checks that a new password is acceptable
+func ValidatePassword(password string) error {
+	if len(password) < MinPasswordLength {
+		return fmt.Errorf("password must be at least %d characters", MinPasswordLength)
+	}
+	// bcrypt ignores everything past 72 bytes
+	if len(password) > 72 {
+		return errors.New("password must be at most 72 bytes")
+	}
+	return nil
+}
@@ -0,0 +1,32 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateUsername(t *testing.T) {
+	for _, username := range []string{"alice", "support.team", "ops-bot_1", "a@example.com"} {
+		if err := ValidateUsername(username); err != nil {
+			t.Errorf("Expected %q to be valid, got %v", username, err)
+		}
+	}
+
+	for _, username := range []string{"", "ab", "has space", "colon:name", strings.Repeat("a", 65)} {
+		if err := ValidateUsername(username); err == nil {
+			t.Errorf("Expected %q to be rejected", username)
+		}
+	}
+}
+
+func TestValidatePassword(t *testing.T) {
+	if err := ValidatePassword("correct horse battery"); err != nil {
+		t.Errorf("Expected password to be valid, got %v", err)
+	}
+	if err := ValidatePassword("short"); err == nil {
+		t.Error("Expected short password to be rejected")
+	}
+	if err := ValidatePassword(strings.Repeat("a", 73)); err == nil {
+		t.Error("Expected password longer than bcrypt's limit to be rejected")
+	}
+}
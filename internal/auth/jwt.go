@@ -2,9 +2,13 @@ package auth
 
 import (
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -12,55 +16,87 @@ import (
 	"golang.org/x/crypto/bcrypt"
 )
 
-// JWTService handles JWT token generation and validation
+// JWTService handles JWT token generation and validation. Tokens are signed with
+// the active key of a key set and name it in their kid header.
 type JWTService struct {
-	secret     string
-	expiration time.Duration
+	expiration        time.Duration
+	refreshExpiration time.Duration
+
+	store    KeyStore // nil when the key comes from JWT_SECRET
+	mu       sync.RWMutex
+	keys     map[string]models.SigningKey // keys that are not retired, by ID
+	active   models.SigningKey
+	loadedAt time.Time
 }
 
-// NewJWTService creates a new JWT service
-func NewJWTService(secret string, expiration int) *JWTService {
-	return &JWTService{
-		secret:     secret,
-		expiration: time.Duration(expiration) * time.Second,
+// NewJWTService creates a new JWT service issuing access tokens valid for expiration
+// seconds within sessions that last refreshExpiration seconds without a refresh.
+// A non-empty secret is used as the only signing key; otherwise call UseKeyStore.
+func NewJWTService(secret string, expiration, refreshExpiration int) *JWTService {
+	j := &JWTService{
+		expiration:        time.Duration(expiration) * time.Second,
+		refreshExpiration: time.Duration(refreshExpiration) * time.Second,
 	}
+	if secret != "" {
+		j.setKeys([]models.SigningKey{{ID: StaticKeyID, Secret: secret}})
+	}
+	return j
+}
+
+// RefreshExpiration returns how long a session lasts without being refreshed
+func (j *JWTService) RefreshExpiration() time.Duration {
+	return j.refreshExpiration
 }
 
-// GenerateToken generates a JWT token for a user
-func (j *JWTService) GenerateToken(user *models.User) (string, int64, error) {
-	if j.secret == "" {
+// GenerateToken generates an access token for a user within a session
+func (j *JWTService) GenerateToken(user *models.User, sessionID string) (string, int64, error) {
+	j.mu.RLock()
+	key := j.active
+	j.mu.RUnlock()
+	if key.Secret == "" {
 		return "", 0, errors.New("JWT secret is not configured")
 	}
 
+	tokenID, err := GenerateID()
+	if err != nil {
+		return "", 0, err
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(j.expiration).Unix()
 	claims := jwt.MapClaims{
 		"user_id":  user.ID,
 		"username": user.Username,
 		"role":     user.Role,
-		"exp":      time.Now().Add(j.expiration).Unix(),
-		"iat":      time.Now().Unix(),
+		"jti":      tokenID,
+		"sid":      sessionID,
+		"exp":      expiresAt,
+		"iat":      now.Unix(),
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenString, err := token.SignedString([]byte(j.secret))
+	token.Header["kid"] = key.ID
+	tokenString, err := token.SignedString([]byte(key.Secret))
 	if err != nil {
 		return "", 0, fmt.Errorf("failed to generate token: %w", err)
 	}
 
-	return tokenString, time.Now().Add(j.expiration).Unix(), nil
+	return tokenString, expiresAt, nil
 }
 
 // ValidateToken validates a JWT token and returns the claims
 func (j *JWTService) ValidateToken(tokenString string) (*models.JWTClaims, error) {
-	if j.secret == "" {
-		return nil, errors.New("JWT secret is not configured")
-	}
-
 	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
 		}
-		return []byte(j.secret), nil
-	})
+		kid, _ := token.Header["kid"].(string)
+		key, ok := j.verificationKey(kid)
+		if !ok {
+			return nil, fmt.Errorf("unknown or retired signing key %q", kid)
+		}
+		return []byte(key.Secret), nil
+	}, jwt.WithExpirationRequired())
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse token: %w", err)
@@ -75,15 +111,58 @@ func (j *JWTService) ValidateToken(tokenString string) (*models.JWTClaims, error
 		return nil, errors.New("invalid token claims")
 	}
 
-	jwtClaims := &models.JWTClaims{
-		UserID:   claims["user_id"].(string),
-		Username: claims["username"].(string),
-		Role:     claims["role"].(string),
+	// Tokens issued before sessions existed carry no session ID and are rejected
+	jwtClaims := &models.JWTClaims{}
+	for name, field := range map[string]*string{
+		"user_id":  &jwtClaims.UserID,
+		"username": &jwtClaims.Username,
+		"role":     &jwtClaims.Role,
+		"jti":      &jwtClaims.TokenID,
+		"sid":      &jwtClaims.SessionID,
+	} {
+		value, ok := claims[name].(string)
+		if !ok || (value == "" && name != "user_id") {
+			return nil, fmt.Errorf("token is missing the %s claim", name)
+		}
+		*field = value
+	}
+
+	exp, err := claims.GetExpirationTime()
+	if err != nil {
+		return nil, errors.New("invalid token expiration")
 	}
+	jwtClaims.ExpiresAt = exp.Unix()
 
 	return jwtClaims, nil
 }
 
+// NewRefreshToken returns a new refresh token for a session and the hash to store for it.
+// The token is the session ID and a random secret joined by a dot.
+func NewRefreshToken(sessionID string) (string, string, error) {
+	bytes := make([]byte, 32)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", "", fmt.Errorf("failed to generate refresh token: %w", err)
+	}
+
+	token := sessionID + "." + base64.RawURLEncoding.EncodeToString(bytes)
+	return token, HashRefreshToken(token), nil
+}
+
+// ParseRefreshToken returns the session ID named by a refresh token
+func ParseRefreshToken(token string) (string, error) {
+	sessionID, secret, ok := strings.Cut(token, ".")
+	if !ok || sessionID == "" || secret == "" {
+		return "", errors.New("malformed refresh token")
+	}
+	return sessionID, nil
+}
+
+// HashRefreshToken returns the hex SHA-256 of a refresh token, as stored on its session
+func HashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
 // HashPassword hashes a password using bcrypt
 func HashPassword(password string) (string, error) {
 	bytes, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
@@ -0,0 +1,95 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/QuantumSolver/crm-relay/internal/models"
+)
+
+func TestGenerateAndValidateToken(t *testing.T) {
+	service := NewJWTService("test-secret", 900, 3600)
+	user := &models.User{ID: "user-1", Username: "alice", Role: models.RoleOperator}
+
+	token, expiresAt, err := service.GenerateToken(user, "session-1")
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+	if want := time.Now().Add(900 * time.Second).Unix(); expiresAt < want-5 || expiresAt > want+5 {
+		t.Errorf("Expected token to expire in 15 minutes, got %d", expiresAt)
+	}
+
+	claims, err := service.ValidateToken(token)
+	if err != nil {
+		t.Fatalf("Failed to validate token: %v", err)
+	}
+	if claims.Username != "alice" || claims.Role != models.RoleOperator || claims.SessionID != "session-1" {
+		t.Errorf("Unexpected claims: %+v", claims)
+	}
+	if claims.TokenID == "" || claims.ExpiresAt != expiresAt {
+		t.Errorf("Expected token ID and expiry in claims, got %+v", claims)
+	}
+
+	second, _, err := service.GenerateToken(user, "session-1")
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+	if secondClaims, _ := service.ValidateToken(second); secondClaims == nil || secondClaims.TokenID == claims.TokenID {
+		t.Error("Expected every token to get its own ID")
+	}
+}
+
+func TestValidateTokenRejectsOtherSecret(t *testing.T) {
+	user := &models.User{ID: "user-1", Username: "alice", Role: models.RoleAdmin}
+	token, _, err := NewJWTService("secret-a", 900, 3600).GenerateToken(user, "session-1")
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+
+	if _, err := NewJWTService("secret-b", 900, 3600).ValidateToken(token); err == nil {
+		t.Error("Expected token signed with another secret to be rejected")
+	}
+}
+
+func TestValidateTokenRejectsExpired(t *testing.T) {
+	service := NewJWTService("test-secret", -1, 3600)
+	user := &models.User{ID: "user-1", Username: "alice", Role: models.RoleAdmin}
+	token, _, err := service.GenerateToken(user, "session-1")
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+
+	if _, err := service.ValidateToken(token); err == nil {
+		t.Error("Expected expired token to be rejected")
+	}
+}
+
+func TestRefreshToken(t *testing.T) {
+	token, hash, err := NewRefreshToken("session-1")
+	if err != nil {
+		t.Fatalf("Failed to generate refresh token: %v", err)
+	}
+	if hash != HashRefreshToken(token) {
+		t.Error("Expected stored hash to match the token")
+	}
+	if strings.Contains(hash, token) {
+		t.Error("Expected hash not to contain the token")
+	}
+
+	sessionID, err := ParseRefreshToken(token)
+	if err != nil || sessionID != "session-1" {
+		t.Errorf("Expected session-1, got %q (%v)", sessionID, err)
+	}
+
+	other, _, _ := NewRefreshToken("session-1")
+	if other == token {
+		t.Error("Expected refresh tokens to be random")
+	}
+
+	for _, malformed := range []string{"", "no-separator", ".secret", "session."} {
+		if _, err := ParseRefreshToken(malformed); err == nil {
+			t.Errorf("Expected %q to be rejected", malformed)
+		}
+	}
+}
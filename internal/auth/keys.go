@@ -0,0 +1,260 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/QuantumSolver/crm-relay/internal/models"
+)
+
+// StaticKeyID is the kid of tokens signed with JWT_SECRET
+const StaticKeyID = "static"
+
+// KeyReloadInterval is how often WatchKeys picks up keys rotated or retired by
+// other replicas
+const KeyReloadInterval = 30 * time.Second
+
+// minKeyReload limits reloads triggered by tokens naming an unknown key
+const minKeyReload = time.Second
+
+// Errors returned by the key set operations
+var (
+	ErrStaticSigningKey  = errors.New("signing keys cannot be rotated while JWT_SECRET is set")
+	ErrActiveSigningKey  = errors.New("the active signing key cannot be retired; rotate first")
+	ErrUnknownSigningKey = errors.New("signing key not found")
+)
+
+// KeyStore persists the signing key set shared by every replica
+type KeyStore interface {
+	GetSigningKeys(ctx context.Context) ([]models.SigningKey, error)
+	CreateSigningKeys(ctx context.Context, key models.SigningKey) (bool, error)
+	StoreSigningKey(ctx context.Context, key models.SigningKey) error
+}
+
+// NewSigningKey generates a random signing key
+func NewSigningKey() (models.SigningKey, error) {
+	id := make([]byte, 8)
+	secret := make([]byte, 32)
+	if _, err := rand.Read(id); err != nil {
+		return models.SigningKey{}, fmt.Errorf("failed to generate signing key: %w", err)
+	}
+	if _, err := rand.Read(secret); err != nil {
+		return models.SigningKey{}, fmt.Errorf("failed to generate signing key: %w", err)
+	}
+
+	return models.SigningKey{
+		ID:        hex.EncodeToString(id),
+		Secret:    base64.RawURLEncoding.EncodeToString(secret),
+		CreatedAt: time.Now(),
+	}, nil
+}
+
+// UseKeyStore signs and validates tokens with the key set in store, creating it
+// with a new key if this is the first instance to start
+func (j *JWTService) UseKeyStore(ctx context.Context, store KeyStore) error {
+	key, err := NewSigningKey()
+	if err != nil {
+		return err
+	}
+
+	created, err := store.CreateSigningKeys(ctx, key)
+	if err != nil {
+		return err
+	}
+	if created {
+		slog.Info("Created JWT signing key set", "kid", key.ID)
+	}
+
+	j.mu.Lock()
+	j.store = store
+	j.mu.Unlock()
+
+	return j.ReloadKeys(ctx)
+}
+
+// ReloadKeys reads the key set from the store
+func (j *JWTService) ReloadKeys(ctx context.Context) error {
+	j.mu.RLock()
+	store := j.store
+	j.mu.RUnlock()
+	if store == nil {
+		return nil
+	}
+
+	keys, err := store.GetSigningKeys(ctx)
+	if err != nil {
+		return err
+	}
+	if !j.setKeys(keys) {
+		return errors.New("no signing key is active")
+	}
+	return nil
+}
+
+// WatchKeys reloads the key set every interval until the context is cancelled
+func (j *JWTService) WatchKeys(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			reloadCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+			if err := j.ReloadKeys(reloadCtx); err != nil {
+				slog.ErrorContext(ctx, "Failed to reload JWT signing keys", "error", err)
+			}
+			cancel()
+		}
+	}
+}
+
+// RotateKey adds a new key that signs all tokens from now on. Tokens signed by
+// earlier keys stay valid until those keys are retired.
+func (j *JWTService) RotateKey(ctx context.Context) (models.SigningKeyInfo, error) {
+	j.mu.RLock()
+	store := j.store
+	j.mu.RUnlock()
+	if store == nil {
+		return models.SigningKeyInfo{}, ErrStaticSigningKey
+	}
+
+	key, err := NewSigningKey()
+	if err != nil {
+		return models.SigningKeyInfo{}, err
+	}
+	if err := store.StoreSigningKey(ctx, key); err != nil {
+		return models.SigningKeyInfo{}, err
+	}
+	if err := j.ReloadKeys(ctx); err != nil {
+		return models.SigningKeyInfo{}, err
+	}
+
+	return models.SigningKeyInfo{ID: key.ID, CreatedAt: key.CreatedAt, Active: true}, nil
+}
+
+// RetireKey stops accepting tokens signed by a key
+func (j *JWTService) RetireKey(ctx context.Context, id string) error {
+	j.mu.RLock()
+	store := j.store
+	j.mu.RUnlock()
+	if store == nil {
+		return ErrStaticSigningKey
+	}
+
+	keys, err := store.GetSigningKeys(ctx)
+	if err != nil {
+		return err
+	}
+
+	active, _ := activeKey(keys)
+	for _, key := range keys {
+		if key.ID != id {
+			continue
+		}
+		if key.ID == active.ID {
+			return ErrActiveSigningKey
+		}
+		if key.RetiredAt == nil {
+			now := time.Now()
+			key.RetiredAt = &now
+			if err := store.StoreSigningKey(ctx, key); err != nil {
+				return err
+			}
+		}
+		return j.ReloadKeys(ctx)
+	}
+
+	return ErrUnknownSigningKey
+}
+
+// SigningKeys describes every key in the key set, oldest first
+func (j *JWTService) SigningKeys(ctx context.Context) ([]models.SigningKeyInfo, error) {
+	j.mu.RLock()
+	store := j.store
+	keys := []models.SigningKey{j.active}
+	j.mu.RUnlock()
+
+	if store != nil {
+		var err error
+		if keys, err = store.GetSigningKeys(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	active, _ := activeKey(keys)
+	infos := make([]models.SigningKeyInfo, 0, len(keys))
+	for _, key := range keys {
+		infos = append(infos, models.SigningKeyInfo{
+			ID:        key.ID,
+			CreatedAt: key.CreatedAt,
+			RetiredAt: key.RetiredAt,
+			Active:    key.ID == active.ID,
+		})
+	}
+	return infos, nil
+}
+
+// setKeys replaces the keys used for signing and validation and reports whether
+// one of them is active
+func (j *JWTService) setKeys(keys []models.SigningKey) bool {
+	active, ok := activeKey(keys)
+	if !ok {
+		return false
+	}
+
+	valid := make(map[string]models.SigningKey, len(keys))
+	for _, key := range keys {
+		if key.RetiredAt == nil {
+			valid[key.ID] = key
+		}
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.keys = valid
+	j.active = active
+	j.loadedAt = time.Now()
+	return true
+}
+
+// verificationKey returns the key named by a token's kid, reloading the key set
+// when the key may have been added by another replica since the last load
+func (j *JWTService) verificationKey(id string) (models.SigningKey, bool) {
+	j.mu.RLock()
+	key, ok := j.keys[id]
+	stale := j.store != nil && time.Since(j.loadedAt) >= minKeyReload
+	j.mu.RUnlock()
+	if ok || !stale {
+		return key, ok
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := j.ReloadKeys(ctx); err != nil {
+		slog.Error("Failed to reload JWT signing keys", "error", err)
+		return models.SigningKey{}, false
+	}
+
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+	key, ok = j.keys[id]
+	return key, ok
+}
+
+// activeKey returns the newest key that is not retired; keys are sorted oldest first
+func activeKey(keys []models.SigningKey) (models.SigningKey, bool) {
+	for i := len(keys) - 1; i >= 0; i-- {
+		if keys[i].RetiredAt == nil {
+			return keys[i], true
+		}
+	}
+	return models.SigningKey{}, false
+}
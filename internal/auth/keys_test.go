@@ -0,0 +1,148 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/QuantumSolver/crm-relay/internal/models"
+)
+
+// memoryKeyStore is a KeyStore shared by the services in a test, standing in for Redis
+type memoryKeyStore struct {
+	mu   sync.Mutex
+	keys map[string]models.SigningKey
+}
+
+func (s *memoryKeyStore) GetSigningKeys(ctx context.Context) ([]models.SigningKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	keys := make([]models.SigningKey, 0, len(s.keys))
+	for _, key := range s.keys {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].CreatedAt.Before(keys[j].CreatedAt) })
+	return keys, nil
+}
+
+func (s *memoryKeyStore) CreateSigningKeys(ctx context.Context, key models.SigningKey) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.keys) > 0 {
+		return false, nil
+	}
+	s.keys = map[string]models.SigningKey{key.ID: key}
+	return true, nil
+}
+
+func (s *memoryKeyStore) StoreSigningKey(ctx context.Context, key models.SigningKey) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys[key.ID] = key
+	return nil
+}
+
+var testUser = &models.User{ID: "user-1", Username: "alice", Role: models.RoleAdmin}
+
+func newReplica(t *testing.T, store KeyStore) *JWTService {
+	t.Helper()
+	service := NewJWTService("", 900, 3600)
+	if err := service.UseKeyStore(context.Background(), store); err != nil {
+		t.Fatalf("Failed to use key store: %v", err)
+	}
+	return service
+}
+
+func TestReplicasShareSigningKeys(t *testing.T) {
+	store := &memoryKeyStore{}
+	first := newReplica(t, store)
+	second := newReplica(t, store)
+
+	if len(store.keys) != 1 {
+		t.Fatalf("Expected one signing key after two starts, got %d", len(store.keys))
+	}
+
+	token, _, err := first.GenerateToken(testUser, "session-1")
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+	if _, err := second.ValidateToken(token); err != nil {
+		t.Errorf("Expected the other replica to accept the token, got %v", err)
+	}
+
+	// A restarted replica keeps accepting tokens issued before the restart
+	if _, err := newReplica(t, store).ValidateToken(token); err != nil {
+		t.Errorf("Expected token to survive a restart, got %v", err)
+	}
+}
+
+func TestRotateAndRetireKey(t *testing.T) {
+	ctx := context.Background()
+	store := &memoryKeyStore{}
+	first := newReplica(t, store)
+	second := newReplica(t, store)
+
+	oldToken, _, err := first.GenerateToken(testUser, "session-1")
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+	oldKeys, _ := first.SigningKeys(ctx)
+
+	// Keep the new key apart from the initial one in CreatedAt order
+	time.Sleep(time.Millisecond)
+	rotated, err := first.RotateKey(ctx)
+	if err != nil {
+		t.Fatalf("Failed to rotate key: %v", err)
+	}
+
+	newToken, _, err := first.GenerateToken(testUser, "session-1")
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+	if _, err := first.ValidateToken(oldToken); err != nil {
+		t.Errorf("Expected token signed by the previous key to stay valid, got %v", err)
+	}
+
+	// The other replica learns about the new key when a token names it
+	second.mu.Lock()
+	second.loadedAt = time.Time{}
+	second.mu.Unlock()
+	if _, err := second.ValidateToken(newToken); err != nil {
+		t.Errorf("Expected the other replica to accept the rotated key, got %v", err)
+	}
+
+	if err := first.RetireKey(ctx, rotated.ID); !errors.Is(err, ErrActiveSigningKey) {
+		t.Errorf("Expected retiring the active key to fail, got %v", err)
+	}
+	if err := first.RetireKey(ctx, "missing"); !errors.Is(err, ErrUnknownSigningKey) {
+		t.Errorf("Expected unknown key error, got %v", err)
+	}
+
+	if err := first.RetireKey(ctx, oldKeys[0].ID); err != nil {
+		t.Fatalf("Failed to retire key: %v", err)
+	}
+	if _, err := first.ValidateToken(oldToken); err == nil {
+		t.Error("Expected token signed by a retired key to be rejected")
+	}
+	if _, err := first.ValidateToken(newToken); err != nil {
+		t.Errorf("Expected token signed by the active key to stay valid, got %v", err)
+	}
+
+	keys, err := first.SigningKeys(ctx)
+	if err != nil {
+		t.Fatalf("Failed to list keys: %v", err)
+	}
+	if len(keys) != 2 || keys[0].RetiredAt == nil || !keys[1].Active {
+		t.Errorf("Unexpected key set: %+v", keys)
+	}
+}
+
+func TestStaticKeyCannotRotate(t *testing.T) {
+	service := NewJWTService("test-secret", 900, 3600)
+	if _, err := service.RotateKey(context.Background()); !errors.Is(err, ErrStaticSigningKey) {
+		t.Errorf("Expected rotation to be refused with JWT_SECRET, got %v", err)
+	}
+}
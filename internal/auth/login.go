@@ -0,0 +1,48 @@
+package auth
+
+import (
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/QuantumSolver/crm-relay/internal/models"
+)
+
+// unknownUserHash is the bcrypt hash of a random password. It is checked when a
+// login names no user so that the answer takes as long as for a wrong password.
+const unknownUserHash = "$2a$10$MidriTOF0Hp01tQcUAEHXeoQYUWfNCkVIakiaSzqFgTii5Grp9JgO"
+
+// VerifyLogin verifies a password for user, which is nil when the username does not exist
+func VerifyLogin(user *models.User, password string) bool {
+	if user == nil {
+		VerifyPassword(password, unknownUserHash)
+		return false
+	}
+	return VerifyPassword(password, user.PasswordHash)
+}
+
+// ClientIP returns the address a request came from. Behind trustedHops proxies it is
+// read from X-Forwarded-For, where each proxy appends the address it received the
+// request from; entries left of those are supplied by the client and ignored.
+func ClientIP(r *http.Request, trustedHops int) string {
+	if trustedHops > 0 {
+		var hops []string
+		for _, value := range r.Header.Values("X-Forwarded-For") {
+			for _, hop := range strings.Split(value, ",") {
+				hops = append(hops, strings.TrimSpace(hop))
+			}
+		}
+		if len(hops) > 0 {
+			ip := hops[max(len(hops)-trustedHops, 0)]
+			if net.ParseIP(ip) != nil {
+				return ip
+			}
+		}
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
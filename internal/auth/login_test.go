@@ -0,0 +1,57 @@
+package auth
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/QuantumSolver/crm-relay/internal/models"
+)
+
+func TestVerifyLogin(t *testing.T) {
+	hash, err := HashPassword("correct horse battery")
+	if err != nil {
+		t.Fatalf("Failed to hash password: %v", err)
+	}
+	user := &models.User{Username: "alice", PasswordHash: hash}
+
+	if !VerifyLogin(user, "correct horse battery") {
+		t.Error("Expected the right password to be accepted")
+	}
+	if VerifyLogin(user, "wrong password") {
+		t.Error("Expected a wrong password to be rejected")
+	}
+	if VerifyLogin(nil, "correct horse battery") {
+		t.Error("Expected a login for an unknown user to be rejected")
+	}
+}
+
+func TestClientIP(t *testing.T) {
+	tests := []struct {
+		name      string
+		forwarded []string
+		hops      int
+		want      string
+	}{
+		{"direct", nil, 0, "192.0.2.1"},
+		{"forwarded header ignored without trusted proxies", []string{"203.0.113.7"}, 0, "192.0.2.1"},
+		{"one proxy", []string{"203.0.113.7"}, 1, "203.0.113.7"},
+		{"spoofed entry ignored", []string{"10.9.9.9, 203.0.113.7"}, 1, "203.0.113.7"},
+		{"two proxies", []string{"10.9.9.9, 203.0.113.7, 198.51.100.2"}, 2, "203.0.113.7"},
+		{"header split across lines", []string{"10.9.9.9", "203.0.113.7"}, 1, "203.0.113.7"},
+		{"no header behind proxy", nil, 1, "192.0.2.1"},
+		{"malformed entry", []string{"not-an-ip"}, 1, "192.0.2.1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("POST", "/api/auth/login", nil)
+			r.RemoteAddr = "192.0.2.1:51234"
+			for _, value := range tt.forwarded {
+				r.Header.Add("X-Forwarded-For", value)
+			}
+			if got := ClientIP(r, tt.hops); got != tt.want {
+				t.Errorf("ClientIP() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package auth
+
+import (
+	"sort"
+
+	"github.com/QuantumSolver/crm-relay/internal/models"
+)
+
+// Permission names an action on the admin APIs
+type Permission string
+
+// Permissions checked by the admin API routes
+const (
+	PermMetricsRead    Permission = "metrics:read"
+	PermConfigRead     Permission = "config:read"
+	PermConfigWrite    Permission = "config:write"
+	PermEndpointsRead  Permission = "endpoints:read"
+	PermEndpointsWrite Permission = "endpoints:write"
+	PermRoutesRead     Permission = "routes:read"
+	PermRoutesWrite    Permission = "routes:write"
+	PermDLQRead        Permission = "dlq:read"
+	PermDLQWrite       Permission = "dlq:write" // replay, delete and bulk jobs
+	PermKeysRead       Permission = "keys:read"
+	PermKeysWrite      Permission = "keys:write"
+	PermUsersManage    Permission = "users:manage"
+	PermSigningKeys    Permission = "signing-keys:manage" // JWT signing key rotation
+)
+
+// PermAuthenticated marks routes open to any signed-in user regardless of role
+const PermAuthenticated Permission = ""
+
+var viewerPermissions = []Permission{
+	PermMetricsRead,
+	PermConfigRead,
+	PermEndpointsRead,
+	PermRoutesRead,
+	PermDLQRead,
+}
+
+var operatorPermissions = append([]Permission{
+	PermConfigWrite,
+	PermEndpointsWrite,
+	PermRoutesWrite,
+	PermDLQWrite,
+}, viewerPermissions...)
+
+var adminPermissions = append([]Permission{
+	PermKeysRead,
+	PermKeysWrite,
+	PermUsersManage,
+	PermSigningKeys,
+}, operatorPermissions...)
+
+// rolePermissions grants each role its permissions; unknown roles get none
+var rolePermissions = map[string]map[Permission]bool{
+	models.RoleAdmin:    permissionSet(adminPermissions),
+	models.RoleOperator: permissionSet(operatorPermissions),
+	models.RoleViewer:   permissionSet(viewerPermissions),
+}
+
+func permissionSet(permissions []Permission) map[Permission]bool {
+	set := make(map[Permission]bool, len(permissions))
+	for _, p := range permissions {
+		set[p] = true
+	}
+	return set
+}
+
+// ValidRole reports whether role is one of the known roles
+func ValidRole(role string) bool {
+	_, ok := rolePermissions[role]
+	return ok
+}
+
+// Allowed reports whether role grants permission
+func Allowed(role string, permission Permission) bool {
+	if permission == PermAuthenticated {
+		return true
+	}
+	return rolePermissions[role][permission]
+}
+
+// Permissions returns the permissions granted to role, sorted
+func Permissions(role string) []Permission {
+	permissions := make([]Permission, 0, len(rolePermissions[role]))
+	for p := range rolePermissions[role] {
+		permissions = append(permissions, p)
+	}
+	sort.Slice(permissions, func(i, j int) bool { return permissions[i] < permissions[j] })
+	return permissions
+}
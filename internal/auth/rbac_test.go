@@ -0,0 +1,43 @@
+package auth
+
+import (
+	"testing"
+
+	"github.com/QuantumSolver/crm-relay/internal/models"
+)
+
+func TestAllowed(t *testing.T) {
+	tests := []struct {
+		role       string
+		permission Permission
+		want       bool
+	}{
+		{models.RoleAdmin, PermKeysWrite, true},
+		{models.RoleOperator, PermDLQWrite, true},
+		{models.RoleOperator, PermKeysRead, false},
+		{models.RoleViewer, PermDLQRead, true},
+		{models.RoleViewer, PermDLQWrite, false},
+		{models.RoleViewer, PermKeysWrite, false},
+		{models.RoleViewer, PermAuthenticated, true},
+		{"unknown", PermMetricsRead, false},
+	}
+
+	for _, tt := range tests {
+		if got := Allowed(tt.role, tt.permission); got != tt.want {
+			t.Errorf("Allowed(%q, %q) = %v, want %v", tt.role, tt.permission, got, tt.want)
+		}
+	}
+}
+
+func TestRolesAreNested(t *testing.T) {
+	for _, p := range Permissions(models.RoleViewer) {
+		if !Allowed(models.RoleOperator, p) {
+			t.Errorf("Expected operator to have viewer permission %s", p)
+		}
+	}
+	for _, p := range Permissions(models.RoleOperator) {
+		if !Allowed(models.RoleAdmin, p) {
+			t.Errorf("Expected admin to have operator permission %s", p)
+		}
+	}
+}
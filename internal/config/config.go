@@ -6,7 +6,9 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/QuantumSolver/crm-relay/internal/logging"
 	"github.com/QuantumSolver/crm-relay/internal/models"
+	"github.com/QuantumSolver/crm-relay/pkg/relaysig"
 )
 
 // Load loads configuration from environment variables
@@ -20,17 +22,43 @@ func Load() (*models.Config, error) {
 		ConsumerGroup:     getEnv("CONSUMER_GROUP", "relay-group"),
 		ConsumerName:      getEnv("CONSUMER_NAME", "relay-client"),
 		DeadLetterQueue:   getEnv("DEAD_LETTER_QUEUE", "webhook-dlq"),
+		RetryQueue:        getEnv("RETRY_QUEUE", "webhook-retry"),
 		MessageTTL:        getEnvAsInt("MESSAGE_TTL", 86400),
+		IdempotencyTTL:    getEnvAsInt("IDEMPOTENCY_TTL", 86400),
+		DLQTTL:            getEnvAsInt("DLQ_TTL", 604800),
+		DLQMaxLen:         getEnvAsInt("DLQ_MAX_LEN", 0),
+		TrimInterval:      getEnvAsInt("TRIM_INTERVAL", 60),
+		DLQReplayRate:     getEnvAsInt("DLQ_REPLAY_RATE", 50),
 		APIKey:            getEnv("API_KEY", ""),
 		JWTSecret:         getEnv("JWT_SECRET", ""),
 		AdminUsername:     getEnv("ADMIN_USERNAME", "admin"),
 		AdminPassword:     getEnv("ADMIN_PASSWORD", ""),
-		JWTExpiration:     getEnvAsInt("JWT_EXPIRATION", 86400),
+		AdminPasswordFile: getEnv("ADMIN_PASSWORD_FILE", "admin-password.txt"),
+		JWTExpiration:     getEnvAsInt("JWT_EXPIRATION", 900),
+		JWTRefreshExpiration: getEnvAsInt("JWT_REFRESH_EXPIRATION", 604800),
+		LoginMaxAttempts:     getEnvAsInt("LOGIN_MAX_ATTEMPTS", 5),
+		LoginMaxIPAttempts:   getEnvAsInt("LOGIN_MAX_IP_ATTEMPTS", 20),
+		LoginAttemptWindow:   getEnvAsInt("LOGIN_ATTEMPT_WINDOW", 900),
+		LoginLockoutBase:     getEnvAsInt("LOGIN_LOCKOUT_BASE", 60),
+		LoginLockoutMax:      getEnvAsInt("LOGIN_LOCKOUT_MAX", 3600),
+		TrustedProxyHops:     getEnvAsInt("TRUSTED_PROXY_HOPS", 0),
 		LocalWebhookURL:   getEnv("LOCAL_WEBHOOK_URL", "http://localhost:3000/webhook"),
+		ForwardSigningSecret: getEnv("FORWARD_SIGNING_SECRET", ""),
 		MaxRetries:        getEnvAsInt("MAX_RETRIES", 3),
 		RetryDelay:        getEnvAsInt("RETRY_DELAY", 1000),
 		RetryMultiplier:   getEnvAsFloat("RETRY_MULTIPLIER", 2.0),
+		RetryPollInterval: getEnvAsInt("RETRY_POLL_INTERVAL", 1000),
+		ReclaimInterval:      getEnvAsInt("RECLAIM_INTERVAL", 30),
+		ReclaimMinIdle:       getEnvAsInt("RECLAIM_MIN_IDLE", 60),
+		ReclaimMaxDeliveries: getEnvAsInt("RECLAIM_MAX_DELIVERIES", 5),
+		ConsumerWorkers:      getEnvAsInt("CONSUMER_WORKERS", 4),
+		ConsumerOrdering:     getEnv("CONSUMER_ORDERING", models.ConsumerOrderingNone),
 		HealthCheckInterval: getEnvAsInt("HEALTH_CHECK_INTERVAL", 30),
+		MetricsAddr:         getEnv("METRICS_ADDR", ""),
+		TracingExporter:     getEnv("TRACING_EXPORTER", models.TracingExporterNone),
+		TracingSampleRatio:  getEnvAsFloat("TRACING_SAMPLE_RATIO", 1.0),
+		LogLevel:            getEnv("LOG_LEVEL", "info"),
+		LogFormat:           getEnv("LOG_FORMAT", models.LogFormatJSON),
 	}
 
 	if err := validate(cfg); err != nil {
@@ -100,6 +128,10 @@ func validate(cfg *models.Config) error {
 		errors = append(errors, "LOCAL_WEBHOOK_URL is required")
 	}
 
+	if _, err := relaysig.DecodeSecret(cfg.ForwardSigningSecret); err != nil {
+		errors = append(errors, "FORWARD_SIGNING_SECRET with whsec_ prefix must be base64-encoded")
+	}
+
 	if cfg.MaxRetries < 0 {
 		errors = append(errors, "MAX_RETRIES must be non-negative")
 	}
@@ -112,10 +144,100 @@ func validate(cfg *models.Config) error {
 		errors = append(errors, "RETRY_MULTIPLIER must be positive")
 	}
 
+	if cfg.RetryQueue == "" {
+		errors = append(errors, "RETRY_QUEUE is required")
+	}
+
+	if cfg.RetryPollInterval <= 0 {
+		errors = append(errors, "RETRY_POLL_INTERVAL must be positive")
+	}
+
+	if cfg.ReclaimInterval <= 0 {
+		errors = append(errors, "RECLAIM_INTERVAL must be positive")
+	}
+
+	if cfg.ReclaimMinIdle <= 0 {
+		errors = append(errors, "RECLAIM_MIN_IDLE must be positive")
+	}
+
+	if cfg.ReclaimMaxDeliveries <= 0 {
+		errors = append(errors, "RECLAIM_MAX_DELIVERIES must be positive")
+	}
+
+	if cfg.ConsumerWorkers <= 0 {
+		errors = append(errors, "CONSUMER_WORKERS must be positive")
+	}
+
+	if cfg.ConsumerOrdering != models.ConsumerOrderingNone && cfg.ConsumerOrdering != models.ConsumerOrderingPlatform {
+		errors = append(errors, "CONSUMER_ORDERING must be 'none' or 'platform'")
+	}
+
 	if cfg.MessageTTL <= 0 {
 		errors = append(errors, "MESSAGE_TTL must be positive")
 	}
 
+	if cfg.IdempotencyTTL <= 0 {
+		errors = append(errors, "IDEMPOTENCY_TTL must be positive")
+	}
+
+	if cfg.DLQTTL <= 0 {
+		errors = append(errors, "DLQ_TTL must be positive")
+	}
+
+	if cfg.DLQMaxLen < 0 {
+		errors = append(errors, "DLQ_MAX_LEN must be non-negative")
+	}
+
+	if cfg.TrimInterval <= 0 {
+		errors = append(errors, "TRIM_INTERVAL must be positive")
+	}
+
+	if cfg.DLQReplayRate <= 0 || cfg.DLQReplayRate > models.MaxDLQReplayRate {
+		errors = append(errors, fmt.Sprintf("DLQ_REPLAY_RATE must be between 1 and %d", models.MaxDLQReplayRate))
+	}
+
+	if cfg.TracingExporter != models.TracingExporterNone &&
+		cfg.TracingExporter != models.TracingExporterStdout &&
+		cfg.TracingExporter != models.TracingExporterOTLP {
+		errors = append(errors, "TRACING_EXPORTER must be 'none', 'stdout' or 'otlp'")
+	}
+
+	if cfg.TracingSampleRatio < 0 || cfg.TracingSampleRatio > 1 {
+		errors = append(errors, "TRACING_SAMPLE_RATIO must be between 0 and 1")
+	}
+
+	if cfg.JWTExpiration <= 0 {
+		errors = append(errors, "JWT_EXPIRATION must be positive")
+	}
+
+	if cfg.JWTRefreshExpiration < cfg.JWTExpiration {
+		errors = append(errors, "JWT_REFRESH_EXPIRATION must be at least JWT_EXPIRATION")
+	}
+
+	if cfg.LoginMaxAttempts <= 0 || cfg.LoginMaxIPAttempts <= 0 {
+		errors = append(errors, "LOGIN_MAX_ATTEMPTS and LOGIN_MAX_IP_ATTEMPTS must be positive")
+	}
+
+	if cfg.LoginAttemptWindow <= 0 || cfg.LoginLockoutBase <= 0 {
+		errors = append(errors, "LOGIN_ATTEMPT_WINDOW and LOGIN_LOCKOUT_BASE must be positive")
+	}
+
+	if cfg.LoginLockoutMax < cfg.LoginLockoutBase {
+		errors = append(errors, "LOGIN_LOCKOUT_MAX must be at least LOGIN_LOCKOUT_BASE")
+	}
+
+	if cfg.TrustedProxyHops < 0 {
+		errors = append(errors, "TRUSTED_PROXY_HOPS must not be negative")
+	}
+
+	if !logging.ValidLevel(cfg.LogLevel) {
+		errors = append(errors, "LOG_LEVEL must be 'debug', 'info', 'warn' or 'error'")
+	}
+
+	if cfg.LogFormat != models.LogFormatJSON && cfg.LogFormat != models.LogFormatText {
+		errors = append(errors, "LOG_FORMAT must be 'json' or 'text'")
+	}
+
 	if len(errors) > 0 {
 		return models.NewRelayError(
 			models.ErrCodeInvalidConfig,
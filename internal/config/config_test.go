@@ -35,6 +35,14 @@ func TestLoad(t *testing.T) {
 	if cfg.MaxRetries != 3 {
 		t.Errorf("Expected default MAX_RETRIES to be 3, got %d", cfg.MaxRetries)
 	}
+
+	if cfg.DLQMaxLen != 0 {
+		t.Errorf("Expected the DLQ cap to be disabled by default, got DLQ_MAX_LEN %d", cfg.DLQMaxLen)
+	}
+
+	if cfg.MetricsAddr != "" {
+		t.Errorf("Expected metrics to be disabled by default, got METRICS_ADDR '%s'", cfg.MetricsAddr)
+	}
 }
 
 func TestLoadMissingAPIKey(t *testing.T) {
@@ -67,3 +75,71 @@ func TestLoadInvalidRetryMultiplier(t *testing.T) {
 		t.Error("Expected error when RETRY_MULTIPLIER is invalid")
 	}
 }
+
+func TestLoadInvalidConsumerOrdering(t *testing.T) {
+	os.Setenv("API_KEY", "test-api-key")
+	os.Setenv("LOCAL_WEBHOOK_URL", "http://localhost:3000/webhook")
+	os.Setenv("CONSUMER_ORDERING", "endpoint")
+	defer func() {
+		os.Unsetenv("API_KEY")
+		os.Unsetenv("LOCAL_WEBHOOK_URL")
+		os.Unsetenv("CONSUMER_ORDERING")
+	}()
+
+	_, err := Load()
+	if err == nil {
+		t.Error("Expected error when CONSUMER_ORDERING is invalid")
+	}
+}
+
+func TestLoadRefreshShorterThanAccessToken(t *testing.T) {
+	os.Setenv("API_KEY", "test-api-key")
+	os.Setenv("LOCAL_WEBHOOK_URL", "http://localhost:3000/webhook")
+	os.Setenv("JWT_EXPIRATION", "3600")
+	os.Setenv("JWT_REFRESH_EXPIRATION", "600")
+	defer func() {
+		os.Unsetenv("API_KEY")
+		os.Unsetenv("LOCAL_WEBHOOK_URL")
+		os.Unsetenv("JWT_EXPIRATION")
+		os.Unsetenv("JWT_REFRESH_EXPIRATION")
+	}()
+
+	_, err := Load()
+	if err == nil {
+		t.Error("Expected error when JWT_REFRESH_EXPIRATION is shorter than JWT_EXPIRATION")
+	}
+}
+
+func TestLoadInvalidLoginLockout(t *testing.T) {
+	os.Setenv("API_KEY", "test-api-key")
+	os.Setenv("LOCAL_WEBHOOK_URL", "http://localhost:3000/webhook")
+	os.Setenv("LOGIN_LOCKOUT_BASE", "600")
+	os.Setenv("LOGIN_LOCKOUT_MAX", "60")
+	defer func() {
+		os.Unsetenv("API_KEY")
+		os.Unsetenv("LOCAL_WEBHOOK_URL")
+		os.Unsetenv("LOGIN_LOCKOUT_BASE")
+		os.Unsetenv("LOGIN_LOCKOUT_MAX")
+	}()
+
+	_, err := Load()
+	if err == nil {
+		t.Error("Expected error when LOGIN_LOCKOUT_MAX is shorter than LOGIN_LOCKOUT_BASE")
+	}
+}
+
+func TestLoadDLQReplayRateTooHigh(t *testing.T) {
+	os.Setenv("API_KEY", "test-api-key")
+	os.Setenv("LOCAL_WEBHOOK_URL", "http://localhost:3000/webhook")
+	os.Setenv("DLQ_REPLAY_RATE", "2000000000")
+	defer func() {
+		os.Unsetenv("API_KEY")
+		os.Unsetenv("LOCAL_WEBHOOK_URL")
+		os.Unsetenv("DLQ_REPLAY_RATE")
+	}()
+
+	_, err := Load()
+	if err == nil {
+		t.Error("Expected error when DLQ_REPLAY_RATE is above the limit")
+	}
+}
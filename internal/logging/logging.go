@@ -0,0 +1,151 @@
+// Package logging configures structured logging with slog and carries request IDs
+// through request contexts
+package logging
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/QuantumSolver/crm-relay/internal/models"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// RequestIDHeader carries the request ID on requests and responses
+const RequestIDHeader = "X-Request-ID"
+
+// validRequestID limits caller-supplied request IDs to what is safe to log and echo back
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+type requestIDKey struct{}
+
+// Setup installs the default logger for the configured level and format. Output of the
+// standard log package, e.g. from dependencies, is routed through it as well.
+func Setup(config *models.Config, service string) {
+	slog.SetDefault(New(os.Stderr, config.LogLevel, config.LogFormat).With("service", service))
+}
+
+// New creates a logger writing to w that adds request and trace IDs from the context
+func New(w io.Writer, level, format string) *slog.Logger {
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(level)); err != nil {
+		lvl = slog.LevelInfo
+	}
+
+	opts := &slog.HandlerOptions{Level: lvl}
+	var handler slog.Handler
+	if format == models.LogFormatText {
+		handler = slog.NewTextHandler(w, opts)
+	} else {
+		handler = slog.NewJSONHandler(w, opts)
+	}
+
+	return slog.New(contextHandler{handler})
+}
+
+// ValidLevel reports whether level is a level name New accepts
+func ValidLevel(level string) bool {
+	switch strings.ToLower(level) {
+	case "debug", "info", "warn", "error":
+		return true
+	}
+	return false
+}
+
+// contextHandler adds the request ID and trace IDs found in the context to each record
+type contextHandler struct {
+	slog.Handler
+}
+
+// Handle implements slog.Handler
+func (h contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if id := RequestID(ctx); id != "" {
+		record.AddAttrs(slog.String("request_id", id))
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		record.AddAttrs(
+			slog.String("trace_id", sc.TraceID().String()),
+			slog.String("span_id", sc.SpanID().String()),
+		)
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+// WithAttrs implements slog.Handler
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+// WithGroup implements slog.Handler
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
+
+// NewRequestID returns the caller's request ID if it is well-formed, or a new one
+func NewRequestID(incoming string) string {
+	if validRequestID.MatchString(incoming) {
+		return incoming
+	}
+	return uuid.New().String()
+}
+
+// WithRequestID returns ctx carrying the request ID
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID returns the request ID carried by ctx, if any
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// WebhookAttrs returns the log fields identifying a webhook
+func WebhookAttrs(webhook *models.Webhook) []any {
+	return []any{
+		"webhook_id", webhook.ID,
+		"platform", webhook.Platform,
+		"endpoint_id", webhook.EndpointID,
+	}
+}
+
+// MessageAttrs returns the log fields identifying a relay message and its stream entry
+func MessageAttrs(messageID string, message *models.RelayMessage) []any {
+	return append(WebhookAttrs(&message.Webhook),
+		"message_id", messageID,
+		"retry_count", message.RetryCount,
+	)
+}
+
+// StatusRecorder captures the status code and size of a response for request logging
+type StatusRecorder struct {
+	http.ResponseWriter
+	Status int
+	Bytes  int
+}
+
+// WriteHeader implements http.ResponseWriter
+func (r *StatusRecorder) WriteHeader(status int) {
+	r.Status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// Write implements http.ResponseWriter
+func (r *StatusRecorder) Write(b []byte) (int, error) {
+	if r.Status == 0 {
+		r.Status = http.StatusOK
+	}
+	n, err := r.ResponseWriter.Write(b)
+	r.Bytes += n
+	return n, err
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (r *StatusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
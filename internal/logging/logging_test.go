@@ -0,0 +1,36 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+func TestNewRequestID(t *testing.T) {
+	if id := NewRequestID("abc-123"); id != "abc-123" {
+		t.Errorf("Expected caller request ID to be kept, got %s", id)
+	}
+
+	for _, incoming := range []string{"", "has space", "line\nbreak"} {
+		if id := NewRequestID(incoming); id == incoming || id == "" {
+			t.Errorf("Expected a generated request ID for %q, got %q", incoming, id)
+		}
+	}
+}
+
+func TestLoggerAddsRequestID(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(&buf, "info", "json")
+
+	logger.DebugContext(context.Background(), "hidden")
+	logger.InfoContext(WithRequestID(context.Background(), "req-1"), "hello", "webhook_id", "wh-1")
+
+	var record map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("Expected a single JSON record, got %q: %v", buf.String(), err)
+	}
+	if record["msg"] != "hello" || record["request_id"] != "req-1" || record["webhook_id"] != "wh-1" {
+		t.Errorf("Unexpected record: %v", record)
+	}
+}
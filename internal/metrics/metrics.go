@@ -0,0 +1,243 @@
+// Package metrics exposes relay metrics in the Prometheus text format and as
+// rolling-window summaries for the dashboards
+package metrics
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "crm_relay"
+
+// Outcomes of an inbound webhook
+const (
+	OutcomeAccepted  = "accepted"
+	OutcomeDuplicate = "duplicate"
+	OutcomeRejected  = "rejected" // authentication or signature failure
+	OutcomeInvalid   = "invalid"  // malformed request
+	OutcomeError     = "error"    // the webhook could not be enqueued
+)
+
+// OutcomeSuccess is the outcome of a forward that the local service accepted; failed
+// forwards are labeled with their models.ErrorClass* value
+const OutcomeSuccess = "success"
+
+// latencyBuckets covers fast local calls up to the forwarder's 30 second timeout
+var latencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30}
+
+// NewRegistry creates a registry with the Go runtime and process collectors
+func NewRegistry() *prometheus.Registry {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	return registry
+}
+
+// Handler serves the metrics in a registry
+func Handler(registry *prometheus.Registry) http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{Registry: registry})
+}
+
+// NewServer creates a server for GET /metrics alone. The endpoint has no
+// authentication, so it listens on its own address rather than the public port.
+func NewServer(addr string, registry *prometheus.Registry) *http.Server {
+	mux := http.NewServeMux()
+	mux.Handle("GET /metrics", Handler(registry))
+	return &http.Server{
+		Addr:         addr,
+		Handler:      mux,
+		ReadTimeout:  10 * time.Second,
+		WriteTimeout: 10 * time.Second,
+		IdleTimeout:  60 * time.Second,
+	}
+}
+
+// ServerMetrics records webhook ingestion on the relay server
+type ServerMetrics struct {
+	webhooks    *prometheus.CounterVec
+	ingest      *prometheus.HistogramVec
+	ingestStats *RollingStats
+}
+
+// NewServerMetrics creates the relay server metrics and registers them
+func NewServerMetrics(registerer prometheus.Registerer) *ServerMetrics {
+	m := &ServerMetrics{
+		webhooks: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "webhooks_received_total",
+			Help:      "Inbound webhooks by platform, endpoint and outcome.",
+		}, []string{"platform", "endpoint", "outcome"}),
+		ingest: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "ingest_duration_seconds",
+			Help:      "Time to validate and enqueue an inbound webhook.",
+			Buckets:   latencyBuckets,
+		}, []string{"platform", "endpoint", "outcome"}),
+		ingestStats: NewRollingStats(),
+	}
+	registerer.MustRegister(m.webhooks, m.ingest)
+	return m
+}
+
+// ObserveWebhook records an inbound webhook and how long it took to handle
+func (m *ServerMetrics) ObserveWebhook(platform, endpoint, outcome string, duration time.Duration) {
+	m.webhooks.WithLabelValues(platform, endpoint, outcome).Inc()
+	m.ingest.WithLabelValues(platform, endpoint, outcome).Observe(duration.Seconds())
+	m.ingestStats.Observe(duration, outcome != OutcomeAccepted && outcome != OutcomeDuplicate)
+}
+
+// IngestSummary returns ingest latency, throughput and error rates over the rolling windows
+func (m *ServerMetrics) IngestSummary() Summary {
+	return m.ingestStats.Summary()
+}
+
+// ClientMetrics records forwarding on the relay client
+type ClientMetrics struct {
+	forwards     *prometheus.CounterVec
+	forward      *prometheus.HistogramVec
+	retries      *prometheus.CounterVec
+	deadLettered *prometheus.CounterVec
+	reclaimed    prometheus.Counter
+	forwardStats *RollingStats
+}
+
+// NewClientMetrics creates the relay client metrics and registers them
+func NewClientMetrics(registerer prometheus.Registerer) *ClientMetrics {
+	m := &ClientMetrics{
+		forwards: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "forwards_total",
+			Help:      "Forwarding attempts to local services by platform, endpoint and outcome.",
+		}, []string{"platform", "endpoint", "outcome"}),
+		forward: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "forward_duration_seconds",
+			Help:      "Time to forward a webhook to the local service.",
+			Buckets:   latencyBuckets,
+		}, []string{"platform", "endpoint", "outcome"}),
+		retries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "retries_scheduled_total",
+			Help:      "Failed forwards scheduled for another attempt.",
+		}, []string{"platform", "endpoint"}),
+		deadLettered: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "dead_lettered_total",
+			Help:      "Messages moved to the dead letter queue by reason.",
+		}, []string{"platform", "endpoint", "reason"}),
+		reclaimed: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "messages_reclaimed_total",
+			Help:      "Stale pending messages claimed from other consumers.",
+		}),
+		forwardStats: NewRollingStats(),
+	}
+	registerer.MustRegister(m.forwards, m.forward, m.retries, m.deadLettered, m.reclaimed)
+	return m
+}
+
+// ObserveForward records a forwarding attempt and its latency
+func (m *ClientMetrics) ObserveForward(platform, endpoint, outcome string, duration time.Duration) {
+	m.forwards.WithLabelValues(platform, endpoint, outcome).Inc()
+	m.forward.WithLabelValues(platform, endpoint, outcome).Observe(duration.Seconds())
+	m.forwardStats.Observe(duration, outcome != OutcomeSuccess)
+}
+
+// ForwardSummary returns forward latency, throughput and error rates over the rolling windows
+func (m *ClientMetrics) ForwardSummary() Summary {
+	return m.forwardStats.Summary()
+}
+
+// IncRetry records a retry being scheduled
+func (m *ClientMetrics) IncRetry(platform, endpoint string) {
+	m.retries.WithLabelValues(platform, endpoint).Inc()
+}
+
+// IncDeadLettered records a message being moved to the DLQ
+func (m *ClientMetrics) IncDeadLettered(platform, endpoint, reason string) {
+	m.deadLettered.WithLabelValues(platform, endpoint, reason).Inc()
+}
+
+// IncReclaimed records a stale message being reclaimed
+func (m *ClientMetrics) IncReclaimed() {
+	m.reclaimed.Inc()
+}
+
+// QueueSource reports the Redis queue sizes sampled on each scrape
+type QueueSource interface {
+	GetQueueDepth(ctx context.Context) (int64, error)
+	GetPendingMessages(ctx context.Context) (int64, error)
+	GetScheduledRetries(ctx context.Context) (int64, error)
+	GetDLQLength(ctx context.Context) (int64, error)
+}
+
+// queueCollector samples queue gauges from Redis when scraped
+type queueCollector struct {
+	source    QueueSource
+	timeout   time.Duration
+	depth     *prometheus.Desc
+	pending   *prometheus.Desc
+	retries   *prometheus.Desc
+	dlq       *prometheus.Desc
+	scrapeErr *prometheus.Desc
+}
+
+// NewQueueCollector creates a collector that reads queue sizes from source on every scrape
+func NewQueueCollector(source QueueSource) prometheus.Collector {
+	return &queueCollector{
+		source:    source,
+		timeout:   2 * time.Second,
+		depth:     prometheus.NewDesc(namespace+"_stream_length", "Entries in the webhook stream.", nil, nil),
+		pending:   prometheus.NewDesc(namespace+"_pending_messages", "Messages delivered to the consumer group but not acknowledged.", nil, nil),
+		retries:   prometheus.NewDesc(namespace+"_scheduled_retries", "Messages waiting in the retry queue.", nil, nil),
+		dlq:       prometheus.NewDesc(namespace+"_dlq_length", "Entries in the dead letter queue.", nil, nil),
+		scrapeErr: prometheus.NewDesc(namespace+"_queue_scrape_errors", "Queue gauges that could not be read from Redis on this scrape.", nil, nil),
+	}
+}
+
+// Describe implements prometheus.Collector
+func (c *queueCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.depth
+	ch <- c.pending
+	ch <- c.retries
+	ch <- c.dlq
+	ch <- c.scrapeErr
+}
+
+// Collect implements prometheus.Collector; gauges that fail to read are left out
+func (c *queueCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	gauges := []struct {
+		name string
+		desc *prometheus.Desc
+		read func(context.Context) (int64, error)
+	}{
+		{"stream length", c.depth, c.source.GetQueueDepth},
+		{"pending messages", c.pending, c.source.GetPendingMessages},
+		{"scheduled retries", c.retries, c.source.GetScheduledRetries},
+		{"DLQ length", c.dlq, c.source.GetDLQLength},
+	}
+
+	failed := 0
+	for _, gauge := range gauges {
+		value, err := gauge.read(ctx)
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to sample queue gauge", "gauge", gauge.name, "error", err)
+			failed++
+			continue
+		}
+		ch <- prometheus.MustNewConstMetric(gauge.desc, prometheus.GaugeValue, float64(value))
+	}
+
+	ch <- prometheus.MustNewConstMetric(c.scrapeErr, prometheus.GaugeValue, float64(failed))
+}
@@ -0,0 +1,85 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type fakeQueues struct {
+	dlqErr error
+}
+
+func (f fakeQueues) GetQueueDepth(ctx context.Context) (int64, error)       { return 12, nil }
+func (f fakeQueues) GetPendingMessages(ctx context.Context) (int64, error)  { return 3, nil }
+func (f fakeQueues) GetScheduledRetries(ctx context.Context) (int64, error) { return 2, nil }
+func (f fakeQueues) GetDLQLength(ctx context.Context) (int64, error)        { return 7, f.dlqErr }
+
+func gather(t *testing.T, collector prometheus.Collector) map[string]float64 {
+	t.Helper()
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(collector)
+
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("Failed to gather metrics: %v", err)
+	}
+
+	values := make(map[string]float64)
+	for _, family := range families {
+		values[family.GetName()] = family.GetMetric()[0].GetGauge().GetValue()
+	}
+	return values
+}
+
+func TestQueueCollector(t *testing.T) {
+	values := gather(t, NewQueueCollector(fakeQueues{}))
+
+	want := map[string]float64{
+		"crm_relay_stream_length":       12,
+		"crm_relay_pending_messages":    3,
+		"crm_relay_scheduled_retries":   2,
+		"crm_relay_dlq_length":          7,
+		"crm_relay_queue_scrape_errors": 0,
+	}
+	for name, value := range want {
+		if got, ok := values[name]; !ok || got != value {
+			t.Errorf("Expected %s=%v, got %v (present %v)", name, value, got, ok)
+		}
+	}
+}
+
+func TestQueueCollectorSkipsFailedGauges(t *testing.T) {
+	values := gather(t, NewQueueCollector(fakeQueues{dlqErr: errors.New("connection refused")}))
+
+	if _, ok := values["crm_relay_dlq_length"]; ok {
+		t.Error("Expected DLQ length to be left out when it cannot be read")
+	}
+	if values["crm_relay_queue_scrape_errors"] != 1 {
+		t.Errorf("Expected 1 scrape error, got %v", values["crm_relay_queue_scrape_errors"])
+	}
+}
+
+func TestNewServerServesOnlyMetrics(t *testing.T) {
+	server := NewServer(":0", NewRegistry())
+
+	tests := []struct {
+		target string
+		want   int
+	}{
+		{"/metrics", http.StatusOK},
+		{"/api/metrics", http.StatusNotFound},
+		{"/health", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		server.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.target, nil))
+		if rec.Code != tt.want {
+			t.Errorf("GET %s: expected status %d, got %d", tt.target, tt.want, rec.Code)
+		}
+	}
+}
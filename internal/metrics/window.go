@@ -0,0 +1,202 @@
+package metrics
+
+import (
+	"math"
+	"math/rand/v2"
+	"sort"
+	"sync"
+	"time"
+)
+
+// maxBucketSamples caps the latencies kept per bucket for percentiles; past it the
+// bucket keeps a uniform reservoir sample while count, sum and errors stay exact
+const maxBucketSamples = 512
+
+// windowSpecs are the rolling windows reported by Summary, each kept as 60 buckets
+var windowSpecs = []struct {
+	name string
+	span time.Duration
+}{
+	{"1m", time.Minute},
+	{"5m", 5 * time.Minute},
+	{"1h", time.Hour},
+}
+
+// WindowSummary describes the observations within one rolling window
+type WindowSummary struct {
+	Count      int64   `json:"count"`
+	Errors     int64   `json:"errors"`
+	RatePerSec float64 `json:"rate_per_sec"`
+	ErrorRate  float64 `json:"error_rate"` // fraction of observations that failed
+	MeanMs     float64 `json:"mean_ms"`
+	P50Ms      float64 `json:"p50_ms"`
+	P95Ms      float64 `json:"p95_ms"`
+	P99Ms      float64 `json:"p99_ms"`
+}
+
+// Summary describes a latency series over each rolling window
+type Summary struct {
+	Total        int64                    `json:"total"`
+	LastObserved *time.Time               `json:"last_observed,omitempty"`
+	Windows      map[string]WindowSummary `json:"windows"`
+}
+
+// Mean returns the mean latency in milliseconds over the named window, or 0
+func (s Summary) Mean(window string) float64 {
+	return s.Windows[window].MeanMs
+}
+
+// RollingStats tracks latency, throughput and errors over the 1m, 5m and 1h windows.
+// It is safe for concurrent use.
+type RollingStats struct {
+	mu      sync.Mutex
+	now     func() time.Time
+	started time.Time
+	last    time.Time
+	total   int64
+	windows []*window
+}
+
+// NewRollingStats creates empty rolling stats
+func NewRollingStats() *RollingStats {
+	return newRollingStats(time.Now)
+}
+
+func newRollingStats(now func() time.Time) *RollingStats {
+	s := &RollingStats{now: now, started: now()}
+	for _, spec := range windowSpecs {
+		s.windows = append(s.windows, newWindow(spec.span, 60))
+	}
+	return s
+}
+
+// Observe records one operation that took duration and whether it failed
+func (s *RollingStats) Observe(duration time.Duration, failed bool) {
+	ms := float64(duration) / float64(time.Millisecond)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.last = now
+	s.total++
+	for _, w := range s.windows {
+		w.add(now, ms, failed)
+	}
+}
+
+// Summary returns the current figures for every window
+func (s *RollingStats) Summary() Summary {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	summary := Summary{Total: s.total, Windows: make(map[string]WindowSummary, len(s.windows))}
+	if !s.last.IsZero() {
+		last := s.last
+		summary.LastObserved = &last
+	}
+
+	for i, w := range s.windows {
+		// Rates are averaged over the time actually covered right after startup
+		elapsed := min(now.Sub(s.started), w.span)
+		summary.Windows[windowSpecs[i].name] = w.summarize(now, elapsed)
+	}
+	return summary
+}
+
+// window is a ring of buckets covering span
+type window struct {
+	span       time.Duration
+	resolution time.Duration
+	buckets    []bucket
+}
+
+// bucket holds the observations of one resolution-sized slice of time
+type bucket struct {
+	start   int64 // slot index; the bucket is stale when it does not match the current slot
+	count   int64
+	errors  int64
+	sum     float64
+	samples []float64
+}
+
+func newWindow(span time.Duration, buckets int) *window {
+	w := &window{
+		span:       span,
+		resolution: span / time.Duration(buckets),
+		buckets:    make([]bucket, buckets),
+	}
+	for i := range w.buckets {
+		w.buckets[i].start = -1
+	}
+	return w
+}
+
+func (w *window) slot(t time.Time) int64 {
+	return t.UnixNano() / int64(w.resolution)
+}
+
+func (w *window) add(now time.Time, ms float64, failed bool) {
+	slot := w.slot(now)
+	b := &w.buckets[slot%int64(len(w.buckets))]
+	if b.start != slot {
+		*b = bucket{start: slot, samples: b.samples[:0]}
+	}
+
+	b.count++
+	b.sum += ms
+	if failed {
+		b.errors++
+	}
+	if len(b.samples) < maxBucketSamples {
+		b.samples = append(b.samples, ms)
+	} else if i := rand.Int64N(b.count); i < maxBucketSamples {
+		b.samples[i] = ms
+	}
+}
+
+func (w *window) summarize(now time.Time, elapsed time.Duration) WindowSummary {
+	var summary WindowSummary
+	var sum float64
+	var samples []float64
+
+	// Buckets from slots older than the window are stale and skipped
+	current := w.slot(now)
+	oldest := current - int64(len(w.buckets)) + 1
+	for i := range w.buckets {
+		b := &w.buckets[i]
+		if b.start < oldest || b.start > current {
+			continue
+		}
+		summary.Count += b.count
+		summary.Errors += b.errors
+		sum += b.sum
+		samples = append(samples, b.samples...)
+	}
+
+	if summary.Count == 0 {
+		return summary
+	}
+
+	if elapsed > 0 {
+		summary.RatePerSec = float64(summary.Count) / elapsed.Seconds()
+	}
+	summary.ErrorRate = float64(summary.Errors) / float64(summary.Count)
+	summary.MeanMs = sum / float64(summary.Count)
+
+	sort.Float64s(samples)
+	summary.P50Ms = percentile(samples, 0.50)
+	summary.P95Ms = percentile(samples, 0.95)
+	summary.P99Ms = percentile(samples, 0.99)
+	return summary
+}
+
+// percentile returns the nearest-rank percentile of sorted samples
+func percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p*float64(len(sorted)))) - 1
+	return sorted[max(rank, 0)]
+}
@@ -0,0 +1,56 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRollingStatsSummary(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	stats := newRollingStats(func() time.Time { return now })
+
+	// 100 observations of 1..100ms over the first minute, every tenth one failing
+	for i := 1; i <= 100; i++ {
+		stats.Observe(time.Duration(i)*time.Millisecond, i%10 == 0)
+	}
+	now = now.Add(time.Minute)
+
+	summary := stats.Summary()
+	if summary.Total != 100 || summary.LastObserved == nil {
+		t.Fatalf("Expected 100 observations with a last time, got %+v", summary)
+	}
+
+	// The 1 minute window has just rolled past the observations
+	if got := summary.Windows["1m"]; got.Count != 0 {
+		t.Errorf("Expected empty 1m window, got %+v", got)
+	}
+
+	got := summary.Windows["5m"]
+	if got.Count != 100 || got.Errors != 10 || got.ErrorRate != 0.1 {
+		t.Errorf("Expected 100 observations with 10 errors, got %+v", got)
+	}
+	if got.MeanMs != 50.5 || got.P50Ms != 50 || got.P95Ms != 95 || got.P99Ms != 99 {
+		t.Errorf("Unexpected latencies: %+v", got)
+	}
+	if got.RatePerSec != 100.0/60 {
+		t.Errorf("Expected rate over the elapsed minute, got %v", got.RatePerSec)
+	}
+
+	// Observations age out of the hour window
+	now = now.Add(time.Hour)
+	if got := stats.Summary().Windows["1h"]; got.Count != 0 {
+		t.Errorf("Expected empty 1h window, got %+v", got)
+	}
+}
+
+func TestRollingStatsCapsSamples(t *testing.T) {
+	stats := NewRollingStats()
+	for i := 0; i < 5*maxBucketSamples; i++ {
+		stats.Observe(10*time.Millisecond, false)
+	}
+
+	got := stats.Summary().Windows["1h"]
+	if got.Count != 5*maxBucketSamples || got.P99Ms != 10 {
+		t.Errorf("Expected exact count with sampled percentiles, got %+v", got)
+	}
+}
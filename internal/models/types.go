@@ -1,40 +1,255 @@
 package models
 
 import (
+	"strings"
 	"time"
 )
 
 // Webhook represents an incoming webhook from Meta platform
 type Webhook struct {
-	ID          string            `json:"id"`
-	Headers     map[string]string `json:"headers"`
-	Body        []byte            `json:"body"`
-	Timestamp   time.Time         `json:"timestamp"`
-	Signature   string            `json:"signature,omitempty"`
-	Platform    string            `json:"platform,omitempty"`
-	EndpointID  string            `json:"endpoint_id,omitempty"`
-	HTTPMethod  string            `json:"http_method,omitempty"`
+	ID             string            `json:"id"`
+	Headers        map[string]string `json:"headers"`
+	Body           []byte            `json:"body"`
+	Timestamp      time.Time         `json:"timestamp"`
+	Signature      string            `json:"signature,omitempty"`
+	Platform       string            `json:"platform,omitempty"`
+	EndpointID     string            `json:"endpoint_id,omitempty"`
+	HTTPMethod     string            `json:"http_method,omitempty"`
+	IdempotencyKey string            `json:"idempotency_key,omitempty"`
 }
 
 // RelayMessage represents a message in the Redis stream
 type RelayMessage struct {
-	MessageID      string    `json:"message_id"`
-	Webhook        Webhook   `json:"webhook"`
-	RetryCount     int       `json:"retry_count"`
-	CreatedAt      time.Time `json:"created_at"`
-	TargetEndpoint string    `json:"target_endpoint,omitempty"`
+	MessageID      string            `json:"message_id"`
+	Webhook        Webhook           `json:"webhook"`
+	RetryCount     int               `json:"retry_count"`
+	CreatedAt      time.Time         `json:"created_at"`
+	TargetEndpoint string            `json:"target_endpoint,omitempty"`
+	Attempts       []DeliveryAttempt `json:"attempts,omitempty"`
+	Replay         *ReplayInfo       `json:"replay,omitempty"`
+	TraceContext   map[string]string `json:"trace_context,omitempty"` // W3C traceparent/tracestate of the ingest span
 }
 
+// ReplayInfo marks a relay message that was replayed from the DLQ. The original IDs
+// refer to the first delivery, however many times the message has been replayed.
+type ReplayInfo struct {
+	OriginalWebhookID string    `json:"original_webhook_id"`
+	OriginalStreamID  string    `json:"original_stream_id"`
+	Count             int       `json:"count"`
+	ReplayedBy        string    `json:"replayed_by,omitempty"`
+	ReplayedAt        time.Time `json:"replayed_at"`
+}
+
+// MaxRecordedAttempts bounds the attempt history kept on a relay message
+const MaxRecordedAttempts = 20
+
+// DeliveryAttempt records one attempt to forward a message to the local service
+type DeliveryAttempt struct {
+	Timestamp  time.Time `json:"timestamp"`
+	URL        string    `json:"url,omitempty"`
+	StatusCode int       `json:"status_code,omitempty"`
+	LatencyMs  int64     `json:"latency_ms"`
+	Error      string    `json:"error,omitempty"`
+	Response   string    `json:"response,omitempty"` // truncated response body
+}
+
+// RecordAttempt appends an attempt, dropping the oldest once MaxRecordedAttempts is reached
+func (m *RelayMessage) RecordAttempt(attempt DeliveryAttempt) {
+	m.Attempts = append(m.Attempts, attempt)
+	if len(m.Attempts) > MaxRecordedAttempts {
+		m.Attempts = m.Attempts[len(m.Attempts)-MaxRecordedAttempts:]
+	}
+}
+
+// DLQEntry is a relay message in the dead letter queue together with why it was moved there
+type DLQEntry struct {
+	ID         string    `json:"id"` // DLQ stream ID, used to replay or delete the entry
+	OriginalID string    `json:"original_id"`
+	MovedAt    time.Time `json:"moved_at"`
+	ErrorCode  string    `json:"error_code,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	RelayMessage
+}
+
+// ReplayAudit links a replayed DLQ entry to the stream entry created for it
+type ReplayAudit struct {
+	DLQID           string    `json:"dlq_id"`
+	ReplayID        string    `json:"replay_id"`
+	WebhookID       string    `json:"webhook_id"`
+	Modified        []string  `json:"modified,omitempty"` // headers, body and/or target_url
+	TargetURL       string    `json:"target_url,omitempty"`
+	RetryCountReset bool      `json:"retry_count_reset"`
+	JobID           string    `json:"job_id,omitempty"`
+	ReplayedBy      string    `json:"replayed_by,omitempty"`
+	ReplayedAt      time.Time `json:"replayed_at"`
+}
+
+// DLQ error classes, derived from the last delivery attempt
+const (
+	ErrorClassNetwork        = "network" // no HTTP response was received
+	ErrorClassClient         = "4xx"
+	ErrorClassServer         = "5xx"
+	ErrorClassOther          = "other"
+	ErrorClassUnacknowledged = "unacknowledged" // no attempt was recorded, e.g. the consumer crashed mid-delivery
+)
+
+// ErrorClass classifies why the entry failed based on its last delivery attempt
+func (e *DLQEntry) ErrorClass() string {
+	if len(e.Attempts) == 0 {
+		return ErrorClassUnacknowledged
+	}
+	return e.Attempts[len(e.Attempts)-1].ErrorClass()
+}
+
+// ErrorClass classifies a failed attempt by its HTTP status
+func (a DeliveryAttempt) ErrorClass() string {
+	switch {
+	case a.StatusCode >= 500:
+		return ErrorClassServer
+	case a.StatusCode >= 400:
+		return ErrorClassClient
+	case a.StatusCode == 0:
+		return ErrorClassNetwork
+	default:
+		return ErrorClassOther
+	}
+}
+
+// DLQFilter narrows DLQ listings and bulk jobs; zero values match everything
+type DLQFilter struct {
+	Platform   string    `json:"platform,omitempty"`
+	EndpointID string    `json:"endpoint_id,omitempty"`
+	Since      time.Time `json:"since,omitempty"`
+	Until      time.Time `json:"until,omitempty"`
+	ErrorCode  string    `json:"error_code,omitempty"`
+	ErrorClass string    `json:"error_class,omitempty"` // network, 4xx, 5xx, other or unacknowledged
+	Query      string    `json:"q,omitempty"`           // case-insensitive substring of the webhook body
+}
+
+// Matches reports whether a DLQ entry satisfies the filter
+func (f DLQFilter) Matches(entry *DLQEntry) bool {
+	if f.Platform != "" && entry.Webhook.Platform != f.Platform {
+		return false
+	}
+	if f.EndpointID != "" && entry.Webhook.EndpointID != f.EndpointID {
+		return false
+	}
+	if f.ErrorCode != "" && entry.ErrorCode != f.ErrorCode {
+		return false
+	}
+	if f.ErrorClass != "" && entry.ErrorClass() != f.ErrorClass {
+		return false
+	}
+	if !f.Since.IsZero() && entry.MovedAt.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && entry.MovedAt.After(f.Until) {
+		return false
+	}
+	if f.Query != "" && !strings.Contains(strings.ToLower(string(entry.Webhook.Body)), strings.ToLower(f.Query)) {
+		return false
+	}
+	return true
+}
+
+// DLQJob is an asynchronous bulk replay or delete over DLQ entries matching a filter
+type DLQJob struct {
+	ID            string     `json:"id"`
+	Action        string     `json:"action"` // replay or delete
+	Filter        DLQFilter  `json:"filter"`
+	RatePerSecond int        `json:"rate_per_second,omitempty"` // replay only
+	Status        string     `json:"status"`
+	Total         int64      `json:"total"`
+	Processed     int64      `json:"processed"`
+	Succeeded     int64      `json:"succeeded"`
+	Failed        int64      `json:"failed"`
+	Error         string     `json:"error,omitempty"`
+	CreatedBy     string     `json:"created_by,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	StartedAt     *time.Time `json:"started_at,omitempty"`
+	FinishedAt    *time.Time `json:"finished_at,omitempty"`
+	UpdatedAt     *time.Time `json:"updated_at,omitempty"` // last progress save by the runner
+}
+
+// MaxDLQReplayRate bounds the messages per second a bulk DLQ replay re-injects
+const MaxDLQReplayRate = 1000
+
+// DLQ job actions
+const (
+	DLQJobActionReplay = "replay"
+	DLQJobActionDelete = "delete"
+)
+
+// DLQ job statuses
+const (
+	DLQJobStatusPending   = "pending"
+	DLQJobStatusRunning   = "running"
+	DLQJobStatusCompleted = "completed"
+	DLQJobStatusFailed    = "failed"
+	DLQJobStatusCancelled = "cancelled"
+)
+
 // User represents a user in the system
 type User struct {
 	ID           string    `json:"id"`
 	Username     string    `json:"username"`
 	PasswordHash string    `json:"password_hash"`
 	Role         string    `json:"role"`
+	Disabled     bool      `json:"disabled"`
 	CreatedAt    time.Time `json:"created_at"`
 	UpdatedAt    time.Time `json:"updated_at"`
 }
 
+// UserInfo is a user as returned by the API, without the password hash
+type UserInfo struct {
+	ID          string    `json:"id"`
+	Username    string    `json:"username"`
+	Role        string    `json:"role"`
+	Disabled    bool      `json:"disabled"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	Permissions []string  `json:"permissions,omitempty"` // set for the current user only
+}
+
+// Info returns the API view of the user
+func (u *User) Info() UserInfo {
+	return UserInfo{
+		ID:        u.ID,
+		Username:  u.Username,
+		Role:      u.Role,
+		Disabled:  u.Disabled,
+		CreatedAt: u.CreatedAt,
+		UpdatedAt: u.UpdatedAt,
+	}
+}
+
+// CreateUserRequest is the body of POST /api/users
+type CreateUserRequest struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+	Role     string `json:"role"`
+}
+
+// UpdateUserRequest is the body of PUT /api/users/{username}; omitted fields are left unchanged
+type UpdateUserRequest struct {
+	Role     *string `json:"role,omitempty"`
+	Disabled *bool   `json:"disabled,omitempty"`
+	Password *string `json:"password,omitempty"` // administrative reset
+}
+
+// ChangePasswordRequest is the body of PUT /api/auth/password
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password"`
+}
+
+// User roles, from most to least privileged
+const (
+	RoleAdmin    = "admin"    // everything, including API keys
+	RoleOperator = "operator" // day-to-day operations: endpoints, routes, config and DLQ actions
+	RoleViewer   = "viewer"   // read-only access to metrics, configuration and the DLQ
+)
+
 // APIKey represents an API key for webhook authentication
 type APIKey struct {
 	ID        string    `json:"id"`
@@ -48,14 +263,164 @@ type APIKey struct {
 
 // WebhookEndpoint represents a webhook endpoint configuration
 type WebhookEndpoint struct {
-	ID           string            `json:"id"`
-	Platform     string            `json:"platform"`
-	Path         string            `json:"path"`
-	HTTPMethod   string            `json:"http_method"`
-	Headers      map[string]string `json:"headers"`
-	RetryConfig  RetryConfig       `json:"retry_config"`
-	CreatedAt    time.Time         `json:"created_at"`
-	UpdatedAt    time.Time         `json:"updated_at"`
+	ID           string             `json:"id"`
+	Platform     string             `json:"platform"`
+	Path         string             `json:"path"`
+	HTTPMethod   string             `json:"http_method"`
+	Headers      map[string]string  `json:"headers"`
+	RetryConfig  RetryConfig        `json:"retry_config"`
+	AppSecret    string             `json:"app_secret,omitempty"`
+	VerifyToken  string             `json:"verify_token,omitempty"`
+	Signature    *SignatureConfig   `json:"signature,omitempty"`
+	Idempotency  *IdempotencyConfig `json:"idempotency,omitempty"`
+	CreatedAt    time.Time          `json:"created_at"`
+	UpdatedAt    time.Time          `json:"updated_at"`
+}
+
+// WebhookEndpointInfo is a webhook endpoint as returned by the API. Secrets are
+// write-only; only whether they are set is reported.
+type WebhookEndpointInfo struct {
+	ID             string             `json:"id"`
+	Platform       string             `json:"platform"`
+	Path           string             `json:"path"`
+	HTTPMethod     string             `json:"http_method"`
+	Headers        map[string]string  `json:"headers"`
+	RetryConfig    RetryConfig        `json:"retry_config"`
+	HasAppSecret   bool               `json:"has_app_secret"`
+	HasVerifyToken bool               `json:"has_verify_token"`
+	Signature      *SignatureInfo     `json:"signature,omitempty"`
+	Idempotency    *IdempotencyConfig `json:"idempotency,omitempty"`
+	CreatedAt      time.Time          `json:"created_at"`
+	UpdatedAt      time.Time          `json:"updated_at"`
+}
+
+// Info returns the API view of the endpoint
+func (e *WebhookEndpoint) Info() WebhookEndpointInfo {
+	return WebhookEndpointInfo{
+		ID:             e.ID,
+		Platform:       e.Platform,
+		Path:           e.Path,
+		HTTPMethod:     e.HTTPMethod,
+		Headers:        e.Headers,
+		RetryConfig:    e.RetryConfig,
+		HasAppSecret:   e.AppSecret != "",
+		HasVerifyToken: e.VerifyToken != "",
+		Signature:      e.Signature.Info(),
+		Idempotency:    e.Idempotency,
+		CreatedAt:      e.CreatedAt,
+		UpdatedAt:      e.UpdatedAt,
+	}
+}
+
+// SignatureConfig selects how inbound signatures are verified for an endpoint
+type SignatureConfig struct {
+	Scheme    string `json:"scheme"` // meta, github, stripe, shopify, slack or hmac
+	Secret    string `json:"secret"`
+	Header    string `json:"header,omitempty"`    // hmac only
+	Algorithm string `json:"algorithm,omitempty"` // hmac only: sha1, sha256 or sha512
+	Encoding  string `json:"encoding,omitempty"`  // hmac only: hex or base64
+	Prefix    string `json:"prefix,omitempty"`    // hmac only, e.g. "sha256="
+	Tolerance int    `json:"tolerance,omitempty"` // seconds, stripe and slack only
+}
+
+// SignatureInfo is a signature configuration as returned by the API, without its
+// secret
+type SignatureInfo struct {
+	Scheme    string `json:"scheme"`
+	SecretSet bool   `json:"secret_set"`
+	Header    string `json:"header,omitempty"`
+	Algorithm string `json:"algorithm,omitempty"`
+	Encoding  string `json:"encoding,omitempty"`
+	Prefix    string `json:"prefix,omitempty"`
+	Tolerance int    `json:"tolerance,omitempty"`
+}
+
+// Info returns the API view of the signature configuration, or nil if there is none
+func (s *SignatureConfig) Info() *SignatureInfo {
+	if s == nil {
+		return nil
+	}
+	return &SignatureInfo{
+		Scheme:    s.Scheme,
+		SecretSet: s.Secret != "",
+		Header:    s.Header,
+		Algorithm: s.Algorithm,
+		Encoding:  s.Encoding,
+		Prefix:    s.Prefix,
+		Tolerance: s.Tolerance,
+	}
+}
+
+// Signature schemes
+const (
+	SignatureSchemeMeta    = "meta"
+	SignatureSchemeGitHub  = "github"
+	SignatureSchemeStripe  = "stripe"
+	SignatureSchemeShopify = "shopify"
+	SignatureSchemeSlack   = "slack"
+	SignatureSchemeHMAC    = "hmac"
+)
+
+// IdempotencyConfig selects how duplicate deliveries to an endpoint are detected
+type IdempotencyConfig struct {
+	Source   string `json:"source"`              // header, json or body_hash
+	Header   string `json:"header,omitempty"`    // header source only
+	JSONPath string `json:"json_path,omitempty"` // json source only, e.g. "entry.0.id"
+	TTL      int    `json:"ttl,omitempty"`       // seconds, defaults to IDEMPOTENCY_TTL
+}
+
+// Idempotency key sources
+const (
+	IdempotencySourceHeader   = "header"
+	IdempotencySourceJSON     = "json"
+	IdempotencySourceBodyHash = "body_hash"
+)
+
+// IdempotencyRecord is stored for each seen idempotency key
+type IdempotencyRecord struct {
+	WebhookID string `json:"webhook_id"`
+	MessageID string `json:"message_id,omitempty"`
+}
+
+// Route maps webhooks for a platform or endpoint to a local service on the client side
+type Route struct {
+	ID            string            `json:"id"`
+	Platform      string            `json:"platform,omitempty"`
+	EndpointID    string            `json:"endpoint_id,omitempty"`
+	URL           string            `json:"url"`
+	HTTPMethod    string            `json:"http_method,omitempty"`
+	Headers       map[string]string `json:"headers,omitempty"`
+	SigningSecret string            `json:"signing_secret,omitempty"` // falls back to FORWARD_SIGNING_SECRET
+	CreatedAt     time.Time         `json:"created_at"`
+	UpdatedAt     time.Time         `json:"updated_at"`
+}
+
+// RouteInfo is a route as returned by the API, without its signing secret
+type RouteInfo struct {
+	ID               string            `json:"id"`
+	Platform         string            `json:"platform,omitempty"`
+	EndpointID       string            `json:"endpoint_id,omitempty"`
+	URL              string            `json:"url"`
+	HTTPMethod       string            `json:"http_method,omitempty"`
+	Headers          map[string]string `json:"headers,omitempty"`
+	HasSigningSecret bool              `json:"has_signing_secret"`
+	CreatedAt        time.Time         `json:"created_at"`
+	UpdatedAt        time.Time         `json:"updated_at"`
+}
+
+// Info returns the API view of the route
+func (r *Route) Info() RouteInfo {
+	return RouteInfo{
+		ID:               r.ID,
+		Platform:         r.Platform,
+		EndpointID:       r.EndpointID,
+		URL:              r.URL,
+		HTTPMethod:       r.HTTPMethod,
+		Headers:          r.Headers,
+		HasSigningSecret: r.SigningSecret != "",
+		CreatedAt:        r.CreatedAt,
+		UpdatedAt:        r.UpdatedAt,
+	}
 }
 
 // RetryConfig holds retry configuration
@@ -65,11 +430,86 @@ type RetryConfig struct {
 	RetryMultiplier float64 `json:"retry_multiplier"`
 }
 
+// IsZero reports whether no retry policy has been configured
+func (rc RetryConfig) IsZero() bool {
+	return rc == RetryConfig{}
+}
+
+// BackoffDelay returns the delay before the given retry attempt (1-based) using exponential backoff
+func (rc RetryConfig) BackoffDelay(retryCount int) time.Duration {
+	delay := time.Duration(rc.RetryDelay) * time.Millisecond
+	for i := 1; i < retryCount; i++ {
+		delay = time.Duration(float64(delay) * rc.RetryMultiplier)
+	}
+	return delay
+}
+
 // JWTClaims represents JWT token claims
 type JWTClaims struct {
-	UserID   string `json:"user_id"`
-	Username string `json:"username"`
-	Role     string `json:"role"`
+	UserID    string `json:"user_id"`
+	Username  string `json:"username"`
+	Role      string `json:"role"`
+	TokenID   string `json:"jti"`
+	SessionID string `json:"sid"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+// Session is a login that can be extended with its refresh token until it expires or
+// is revoked. Access tokens name their session and stop working when it is gone.
+type Session struct {
+	ID          string    `json:"id"`
+	Username    string    `json:"username"`
+	RefreshHash string    `json:"-"` // SHA-256 of the current refresh token
+	CreatedAt   time.Time `json:"created_at"`
+	RefreshedAt time.Time `json:"refreshed_at"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+// SigningKey is an HMAC key for access tokens, named by the kid header of the
+// tokens it signs. The newest key that is not retired signs new tokens.
+type SigningKey struct {
+	ID        string     `json:"id"`
+	Secret    string     `json:"secret"`
+	CreatedAt time.Time  `json:"created_at"`
+	RetiredAt *time.Time `json:"retired_at,omitempty"` // tokens signed by a retired key are rejected
+}
+
+// SigningKeyInfo describes a signing key without its secret
+type SigningKeyInfo struct {
+	ID        string     `json:"id"`
+	CreatedAt time.Time  `json:"created_at"`
+	RetiredAt *time.Time `json:"retired_at,omitempty"`
+	Active    bool       `json:"active"` // signs new tokens
+}
+
+// Login audit event types
+const (
+	AuthEventLoginFailed  = "login_failed"
+	AuthEventLockout      = "lockout"       // repeated failures locked out a username or client IP
+	AuthEventLoginBlocked = "login_blocked" // an attempt was refused during a lockout
+)
+
+// Reasons recorded on failed login events; responses never distinguish them
+const (
+	LoginFailureUnknownUser = "unknown_user"
+	LoginFailureBadPassword = "bad_password"
+	LoginFailureDisabled    = "disabled"
+)
+
+// AuthEvent is an entry in the login audit log
+type AuthEvent struct {
+	Type        string     `json:"type"`
+	Username    string     `json:"username"`
+	IP          string     `json:"ip"`
+	Reason      string     `json:"reason,omitempty"` // failure reason, or the locked scope: user or ip
+	LockedUntil *time.Time `json:"locked_until,omitempty"`
+	RequestID   string     `json:"request_id,omitempty"`
+	At          time.Time  `json:"at"`
+}
+
+// RefreshRequest is the body of POST /api/auth/refresh
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
 }
 
 // LoginRequest represents a login request
@@ -80,9 +520,11 @@ type LoginRequest struct {
 
 // LoginResponse represents a login response
 type LoginResponse struct {
-	Token     string `json:"token"`
-	User      User   `json:"user"`
-	ExpiresAt int64  `json:"expires_at"`
+	Token            string   `json:"token"`
+	User             UserInfo `json:"user"`
+	ExpiresAt        int64    `json:"expires_at"`
+	RefreshToken     string   `json:"refresh_token"`
+	RefreshExpiresAt int64    `json:"refresh_expires_at"`
 }
 
 // Config holds the configuration for both relay server and client
@@ -100,38 +542,100 @@ type Config struct {
 	ConsumerGroup      string `env:"CONSUMER_GROUP" envDefault:"relay-group"`
 	ConsumerName       string `env:"CONSUMER_NAME" envDefault:"relay-client"`
 	DeadLetterQueue    string `env:"DEAD_LETTER_QUEUE" envDefault:"webhook-dlq"`
+	RetryQueue         string `env:"RETRY_QUEUE" envDefault:"webhook-retry"`
 	MessageTTL         int    `env:"MESSAGE_TTL" envDefault:"86400"` // 24 hours in seconds
+	IdempotencyTTL     int    `env:"IDEMPOTENCY_TTL" envDefault:"86400"` // seconds
+
+	// Stream retention
+	DLQTTL       int `env:"DLQ_TTL" envDefault:"604800"` // 7 days in seconds
+	DLQMaxLen    int `env:"DLQ_MAX_LEN" envDefault:"0"` // 0 disables the cap
+	TrimInterval int `env:"TRIM_INTERVAL" envDefault:"60"` // seconds
+
+	// Bulk DLQ jobs
+	DLQReplayRate int `env:"DLQ_REPLAY_RATE" envDefault:"50"` // messages per second
 
 	// Authentication
 	APIKey string `env:"API_KEY" envDefault:""`
 
 	// JWT Authentication
-	JWTSecret      string `env:"JWT_SECRET" envDefault:""`
-	AdminUsername  string `env:"ADMIN_USERNAME" envDefault:"admin"`
-	AdminPassword  string `env:"ADMIN_PASSWORD" envDefault:""`
-	JWTExpiration  int    `env:"JWT_EXPIRATION" envDefault:"86400"` // 24 hours in seconds
+	JWTSecret            string `env:"JWT_SECRET" envDefault:""`
+	AdminUsername        string `env:"ADMIN_USERNAME" envDefault:"admin"`
+	AdminPassword        string `env:"ADMIN_PASSWORD" envDefault:""`
+	AdminPasswordFile    string `env:"ADMIN_PASSWORD_FILE" envDefault:"admin-password.txt"` // where a generated admin password is written
+	JWTExpiration        int    `env:"JWT_EXPIRATION" envDefault:"900"`                     // access token lifetime in seconds
+	JWTRefreshExpiration int    `env:"JWT_REFRESH_EXPIRATION" envDefault:"604800"`          // seconds a session survives without a refresh
+
+	// Login brute-force protection
+	LoginMaxAttempts   int `env:"LOGIN_MAX_ATTEMPTS" envDefault:"5"`     // failures per username before a lockout
+	LoginMaxIPAttempts int `env:"LOGIN_MAX_IP_ATTEMPTS" envDefault:"20"` // failures per client IP before a lockout
+	LoginAttemptWindow int `env:"LOGIN_ATTEMPT_WINDOW" envDefault:"900"` // seconds failures and past lockouts are remembered
+	LoginLockoutBase   int `env:"LOGIN_LOCKOUT_BASE" envDefault:"60"`    // seconds of the first lockout, doubled for each later one
+	LoginLockoutMax    int `env:"LOGIN_LOCKOUT_MAX" envDefault:"3600"`   // longest lockout in seconds
+	TrustedProxyHops   int `env:"TRUSTED_PROXY_HOPS" envDefault:"0"`     // proxies in front whose X-Forwarded-For entries are trusted
 
 	// Client configuration
-	LocalWebhookURL string `env:"LOCAL_WEBHOOK_URL" envDefault:"http://localhost:3000/webhook"`
+	LocalWebhookURL      string `env:"LOCAL_WEBHOOK_URL" envDefault:"http://localhost:3000/webhook"`
+	ForwardSigningSecret string `env:"FORWARD_SIGNING_SECRET" envDefault:""`
 
 	// Retry configuration
 	MaxRetries      int `env:"MAX_RETRIES" envDefault:"3"`
 	RetryDelay      int `env:"RETRY_DELAY" envDefault:"1000"` // milliseconds
 	RetryMultiplier float64 `env:"RETRY_MULTIPLIER" envDefault:"2.0"`
+	RetryPollInterval int   `env:"RETRY_POLL_INTERVAL" envDefault:"1000"` // milliseconds
+
+	// Pending entry recovery
+	ReclaimInterval      int `env:"RECLAIM_INTERVAL" envDefault:"30"`      // seconds
+	ReclaimMinIdle       int `env:"RECLAIM_MIN_IDLE" envDefault:"60"`      // seconds
+	ReclaimMaxDeliveries int `env:"RECLAIM_MAX_DELIVERIES" envDefault:"5"`
+
+	// Consumer concurrency
+	ConsumerWorkers  int    `env:"CONSUMER_WORKERS" envDefault:"4"`
+	ConsumerOrdering string `env:"CONSUMER_ORDERING" envDefault:"none"` // none or platform
 
 	// Health check
 	HealthCheckInterval int `env:"HEALTH_CHECK_INTERVAL" envDefault:"30"` // seconds
+
+	// Metrics
+	MetricsAddr string `env:"METRICS_ADDR"` // empty disables the Prometheus endpoint
+
+	// Tracing
+	TracingExporter    string  `env:"TRACING_EXPORTER" envDefault:"none"` // none, stdout or otlp
+	TracingSampleRatio float64 `env:"TRACING_SAMPLE_RATIO" envDefault:"1.0"`
+
+	// Logging
+	LogLevel  string `env:"LOG_LEVEL" envDefault:"info"` // debug, info, warn or error
+	LogFormat string `env:"LOG_FORMAT" envDefault:"json"` // json or text
 }
 
+// Log formats
+const (
+	LogFormatJSON = "json"
+	LogFormatText = "text"
+)
+
+// Tracing exporters
+const (
+	TracingExporterNone   = "none"
+	TracingExporterStdout = "stdout"
+	TracingExporterOTLP   = "otlp"
+)
+
+// Consumer ordering modes
+const (
+	ConsumerOrderingNone     = "none"
+	ConsumerOrderingPlatform = "platform"
+)
+
 // Metrics holds runtime metrics
 type Metrics struct {
 	WebhooksReceived   int64 `json:"webhooks_received"`
 	WebhooksProcessed  int64 `json:"webhooks_processed"`
 	WebhooksFailed     int64 `json:"webhooks_failed"`
 	WebhooksRetried    int64 `json:"webhooks_retried"`
+	MessagesReclaimed  int64 `json:"messages_reclaimed"`
+	SignatureFailures  int64 `json:"signature_failures"`
+	WebhooksDuplicate  int64 `json:"webhooks_duplicate"`
 	QueueDepth         int64 `json:"queue_depth"`
-	AverageLatency     int64 `json:"average_latency_ms"`
-	LastWebhookTime    time.Time `json:"last_webhook_time"`
 }
 
 // Error types
@@ -156,12 +660,15 @@ func (e *RelayError) Unwrap() error {
 const (
 	ErrCodeInvalidRequest   = "INVALID_REQUEST"
 	ErrCodeAuthentication   = "AUTHENTICATION_FAILED"
+	ErrCodeForbidden        = "FORBIDDEN"
 	ErrCodeRedisConnection  = "REDIS_CONNECTION_ERROR"
 	ErrCodeStreamWrite      = "STREAM_WRITE_ERROR"
 	ErrCodeStreamRead       = "STREAM_READ_ERROR"
 	ErrCodeWebhookForward   = "WEBHOOK_FORWARD_ERROR"
 	ErrCodeMaxRetriesExceeded = "MAX_RETRIES_EXCEEDED"
 	ErrCodeInvalidConfig    = "INVALID_CONFIG"
+	ErrCodeInvalidSignature = "INVALID_SIGNATURE"
+	ErrCodeLastAdmin        = "LAST_ADMIN"
 )
 
 // NewRelayError creates a new RelayError
@@ -1,7 +1,9 @@
 package models
 
 import (
+	"encoding/json"
 	"errors"
+	"strings"
 	"testing"
 	"time"
 )
@@ -80,3 +82,154 @@ func TestRelayMessage(t *testing.T) {
 		t.Errorf("Expected RetryCount to be 0, got %d", message.RetryCount)
 	}
 }
+
+func TestRetryConfigBackoffDelay(t *testing.T) {
+	rc := RetryConfig{MaxRetries: 5, RetryDelay: 1000, RetryMultiplier: 2.0}
+
+	expected := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second}
+	for i, want := range expected {
+		if got := rc.BackoffDelay(i + 1); got != want {
+			t.Errorf("Expected delay for attempt %d to be %v, got %v", i+1, want, got)
+		}
+	}
+}
+
+func TestRetryConfigIsZero(t *testing.T) {
+	if !(RetryConfig{}).IsZero() {
+		t.Error("Expected empty RetryConfig to be zero")
+	}
+
+	if (RetryConfig{MaxRetries: 0, RetryDelay: 500}).IsZero() {
+		t.Error("Expected RetryConfig with a delay to be non-zero")
+	}
+}
+
+func TestRelayMessageRecordAttempt(t *testing.T) {
+	var message RelayMessage
+	for i := 0; i < MaxRecordedAttempts+5; i++ {
+		message.RecordAttempt(DeliveryAttempt{StatusCode: 500 + i})
+	}
+
+	if len(message.Attempts) != MaxRecordedAttempts {
+		t.Fatalf("Expected %d attempts, got %d", MaxRecordedAttempts, len(message.Attempts))
+	}
+
+	if message.Attempts[0].StatusCode != 505 {
+		t.Errorf("Expected oldest attempts to be dropped, first status is %d", message.Attempts[0].StatusCode)
+	}
+
+	if last := message.Attempts[len(message.Attempts)-1].StatusCode; last != 500+MaxRecordedAttempts+4 {
+		t.Errorf("Expected newest attempt last, got status %d", last)
+	}
+}
+
+func TestDLQFilterMatches(t *testing.T) {
+	movedAt := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	entry := &DLQEntry{
+		ID:        "1705312800000-0",
+		MovedAt:   movedAt,
+		ErrorCode: ErrCodeWebhookForward,
+		RelayMessage: RelayMessage{
+			Webhook: Webhook{
+				Platform:   "whatsapp",
+				EndpointID: "ep-1",
+				Body:       []byte(`{"customer":"ACME Corp"}`),
+			},
+			Attempts: []DeliveryAttempt{{StatusCode: 503}},
+		},
+	}
+
+	tests := []struct {
+		name   string
+		filter DLQFilter
+		want   bool
+	}{
+		{"empty", DLQFilter{}, true},
+		{"platform", DLQFilter{Platform: "whatsapp"}, true},
+		{"other platform", DLQFilter{Platform: "messenger"}, false},
+		{"endpoint", DLQFilter{EndpointID: "ep-2"}, false},
+		{"error code", DLQFilter{ErrorCode: ErrCodeMaxRetriesExceeded}, false},
+		{"error class", DLQFilter{ErrorClass: ErrorClassServer}, true},
+		{"other error class", DLQFilter{ErrorClass: ErrorClassNetwork}, false},
+		{"in range", DLQFilter{Since: movedAt.Add(-time.Hour), Until: movedAt.Add(time.Hour)}, true},
+		{"before range", DLQFilter{Since: movedAt.Add(time.Minute)}, false},
+		{"after range", DLQFilter{Until: movedAt.Add(-time.Minute)}, false},
+		{"body search", DLQFilter{Query: "acme"}, true},
+		{"body miss", DLQFilter{Query: "globex"}, false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.filter.Matches(entry); got != tt.want {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.want, got)
+		}
+	}
+}
+
+func TestDLQEntryErrorClass(t *testing.T) {
+	tests := []struct {
+		attempts []DeliveryAttempt
+		want     string
+	}{
+		{nil, ErrorClassUnacknowledged},
+		{[]DeliveryAttempt{{Error: "connection refused"}}, ErrorClassNetwork},
+		{[]DeliveryAttempt{{StatusCode: 502}, {StatusCode: 404}}, ErrorClassClient},
+		{[]DeliveryAttempt{{StatusCode: 500}}, ErrorClassServer},
+		{[]DeliveryAttempt{{StatusCode: 302}}, ErrorClassOther},
+	}
+
+	for _, tt := range tests {
+		entry := &DLQEntry{RelayMessage: RelayMessage{Attempts: tt.attempts}}
+		if got := entry.ErrorClass(); got != tt.want {
+			t.Errorf("ErrorClass() with %+v = %q, want %q", tt.attempts, got, tt.want)
+		}
+	}
+}
+
+func TestWebhookEndpointInfoOmitsSecrets(t *testing.T) {
+	endpoint := &WebhookEndpoint{
+		ID:          "ep-1",
+		Platform:    "meta",
+		AppSecret:   "app-secret",
+		VerifyToken: "verify-token",
+		Signature:   &SignatureConfig{Scheme: SignatureSchemeStripe, Secret: "whsec_secret", Tolerance: 300},
+	}
+
+	data, err := json.Marshal(endpoint.Info())
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+
+	for _, secret := range []string{"app-secret", "verify-token", "whsec_secret"} {
+		if strings.Contains(string(data), secret) {
+			t.Errorf("Expected %q to be omitted, got %s", secret, data)
+		}
+	}
+
+	info := endpoint.Info()
+	if !info.HasAppSecret || !info.HasVerifyToken {
+		t.Errorf("Expected secrets to be reported as set, got %+v", info)
+	}
+
+	if info.Signature == nil || !info.Signature.SecretSet || info.Signature.Tolerance != 300 {
+		t.Errorf("Expected signature settings without the secret, got %+v", info.Signature)
+	}
+
+	if empty := (&WebhookEndpoint{}).Info(); empty.HasAppSecret || empty.HasVerifyToken || empty.Signature != nil {
+		t.Errorf("Expected unset secrets to be reported as unset, got %+v", empty)
+	}
+}
+
+func TestRouteInfoOmitsSigningSecret(t *testing.T) {
+	route := &Route{ID: "route-1", URL: "http://localhost:3000", SigningSecret: "whsec_secret"}
+
+	data, err := json.Marshal(route.Info())
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	if strings.Contains(string(data), "whsec_secret") {
+		t.Errorf("Expected the signing secret to be omitted, got %s", data)
+	}
+	if !route.Info().HasSigningSecret {
+		t.Error("Expected the signing secret to be reported as set")
+	}
+}
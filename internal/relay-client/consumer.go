@@ -2,43 +2,90 @@ package relayclient
 
 import (
 	"context"
-	"log"
+	"fmt"
+	"log/slog"
+	"sync"
 	"sync/atomic"
 	"time"
 
+	"github.com/QuantumSolver/crm-relay/internal/logging"
+	"github.com/QuantumSolver/crm-relay/internal/metrics"
 	"github.com/QuantumSolver/crm-relay/internal/models"
 	"github.com/QuantumSolver/crm-relay/internal/storage"
+	"github.com/QuantumSolver/crm-relay/internal/tracing"
 	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// MessageQueue is the stream the consumer reads from, with its retry queue and DLQ
+type MessageQueue interface {
+	ReadMessages(ctx context.Context, count int64, block time.Duration) ([]redis.XMessage, error)
+	ClaimStaleMessages(ctx context.Context, start string, minIdle time.Duration, count int64, skip func(id string) bool) ([]storage.ClaimedMessage, string, error)
+	AcknowledgeMessage(ctx context.Context, messageID string) error
+	ScheduleRetry(ctx context.Context, messageID string, message *models.RelayMessage, retryAt time.Time) error
+	MoveToDeadLetterQueue(ctx context.Context, messageID string, message *models.RelayMessage, cause error) error
+}
+
 // Consumer consumes messages from Redis stream
 type Consumer struct {
-	redisClient *storage.RedisClient
-	config      *models.Config
-	forwarder   *Forwarder
-	metrics     *models.Metrics
-	running     atomic.Bool
+	queue     MessageQueue
+	config    *models.Config
+	forwarder *Forwarder
+	router    *Router
+	metrics   *models.Metrics
+	prom      *metrics.ClientMetrics
+	running   atomic.Bool
+	pool      *workerPool
+	inFlight  sync.Map // IDs of entries dispatched and not yet processed
+	stop      chan struct{}
+	done      chan struct{}
 }
 
 // NewConsumer creates a new consumer
-func NewConsumer(redisClient *storage.RedisClient, config *models.Config, forwarder *Forwarder) *Consumer {
+func NewConsumer(queue MessageQueue, config *models.Config, forwarder *Forwarder, router *Router, prom *metrics.ClientMetrics) *Consumer {
 	return &Consumer{
-		redisClient: redisClient,
-		config:      config,
-		forwarder:   forwarder,
-		metrics:     &models.Metrics{},
+		queue:     queue,
+		config:    config,
+		forwarder: forwarder,
+		router:    router,
+		metrics:   &models.Metrics{},
+		prom:      prom,
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
 	}
 }
 
 // Start starts consuming messages
 func (c *Consumer) Start(ctx context.Context) {
 	c.running.Store(true)
-	log.Printf("Consumer started: Group=%s, Consumer=%s", c.config.ConsumerGroup, c.config.ConsumerName)
+	c.pool = newWorkerPool(c.config.ConsumerWorkers)
+	slog.Info("Consumer started",
+		"group", c.config.ConsumerGroup,
+		"consumer", c.config.ConsumerName,
+		"workers", c.config.ConsumerWorkers,
+		"ordering", c.config.ConsumerOrdering,
+	)
+
+	// Recover entries stranded in the pending list by earlier crashes
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		c.reclaimLoop(ctx)
+	}()
+
+	defer func() {
+		// Drain in-flight work before reporting the consumer as stopped
+		wg.Wait()
+		c.pool.Close()
+		close(c.done)
+	}()
 
 	for c.running.Load() {
 		select {
 		case <-ctx.Done():
-			log.Println("Consumer stopping due to context cancellation")
+			slog.Info("Consumer stopping due to context cancellation")
 			return
 		default:
 			c.consumeMessages(ctx)
@@ -46,18 +93,28 @@ func (c *Consumer) Start(ctx context.Context) {
 	}
 }
 
-// Stop stops the consumer
+// Stop stops the consumer and waits for in-flight messages to finish
 func (c *Consumer) Stop() {
-	c.running.Store(false)
-	log.Println("Consumer stopped")
+	if !c.running.Swap(false) {
+		return
+	}
+	close(c.stop)
+	<-c.done
+	slog.Info("Consumer stopped")
 }
 
-// consumeMessages reads and processes messages from the stream
+// consumeMessages reads messages from the stream and hands them to the worker pool
 func (c *Consumer) consumeMessages(ctx context.Context) {
+	// Read enough messages to keep every worker busy
+	count := int64(10)
+	if workers := int64(c.config.ConsumerWorkers); workers > count {
+		count = workers
+	}
+
 	// Read messages with blocking
-	messages, err := c.redisClient.ReadMessages(ctx, 10, 5*time.Second)
+	messages, err := c.queue.ReadMessages(ctx, count, 5*time.Second)
 	if err != nil {
-		log.Printf("Error reading messages: %v", err)
+		slog.Error("Error reading messages", "error", err)
 		time.Sleep(5 * time.Second)
 		return
 	}
@@ -66,102 +123,185 @@ func (c *Consumer) consumeMessages(ctx context.Context) {
 		return
 	}
 
-	log.Printf("Received %d messages from stream", len(messages))
+	slog.Debug("Received messages from stream", "count", len(messages))
 
-	// Process each message
+	// Dispatch each message
 	for _, message := range messages {
 		if !c.running.Load() {
 			break
 		}
 
-		c.processMessage(ctx, message)
+		c.dispatch(ctx, message)
 	}
 }
 
+// dispatch submits a message to the worker pool, keyed by platform when ordering is
+// enabled. A message that is already queued or being processed is skipped.
+func (c *Consumer) dispatch(ctx context.Context, message redis.XMessage) {
+	if _, loaded := c.inFlight.LoadOrStore(message.ID, struct{}{}); loaded {
+		return
+	}
+
+	key := ""
+	if c.config.ConsumerOrdering == models.ConsumerOrderingPlatform {
+		if relayMessage, err := storage.ParseMessage(message); err == nil {
+			key = "platform:" + relayMessage.Webhook.Platform
+		}
+	}
+
+	c.pool.Submit(key, func() {
+		defer c.inFlight.Delete(message.ID)
+		c.processMessage(ctx, message)
+	})
+}
+
+// isInFlight reports whether a message is queued in the worker pool or being processed
+func (c *Consumer) isInFlight(id string) bool {
+	_, ok := c.inFlight.Load(id)
+	return ok
+}
+
 // processMessage processes a single message
 func (c *Consumer) processMessage(ctx context.Context, message interface{}) {
 	// Parse message
 	redisMessage, ok := message.(redis.XMessage)
 	if !ok {
-		log.Printf("Invalid message type")
+		slog.Error("Invalid message type", "type", fmt.Sprintf("%T", message))
 		return
 	}
 
 	// Parse relay message
 	relayMessage, err := storage.ParseMessage(redisMessage)
 	if err != nil {
-		log.Printf("Failed to parse message %s: %v", redisMessage.ID, err)
+		slog.Error("Failed to parse message", "message_id", redisMessage.ID, "error", err)
 		return
 	}
 
+	// Continue the trace started when the webhook was ingested
+	ctx, span := tracing.Start(tracing.Extract(ctx, relayMessage.TraceContext), "webhook.consume",
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			attribute.String("messaging.system", "redis"),
+			attribute.String("messaging.destination.name", c.config.StreamName),
+			attribute.String("messaging.message.id", redisMessage.ID),
+			attribute.String("relay.webhook_id", relayMessage.Webhook.ID),
+			attribute.String("relay.platform", relayMessage.Webhook.Platform),
+			attribute.String("relay.endpoint_id", relayMessage.Webhook.EndpointID),
+			attribute.Int("relay.retry_count", relayMessage.RetryCount),
+		))
+	defer func() { tracing.End(span, err) }()
+
 	// Update metrics - Received
 	atomic.AddInt64(&c.metrics.WebhooksReceived, 1)
 
 	// Log routing information
-	if relayMessage.Webhook.Platform != "" {
-		log.Printf("Processing message: ID=%s, WebhookID=%s, Platform=%s, EndpointID=%s, HTTPMethod=%s, RetryCount=%d",
-			redisMessage.ID, relayMessage.Webhook.ID, relayMessage.Webhook.Platform,
-			relayMessage.Webhook.EndpointID, relayMessage.Webhook.HTTPMethod, relayMessage.RetryCount)
-	} else {
-		log.Printf("Processing message: ID=%s, WebhookID=%s, RetryCount=%d",
-			redisMessage.ID, relayMessage.Webhook.ID, relayMessage.RetryCount)
-	}
+	attrs := logging.MessageAttrs(redisMessage.ID, relayMessage)
+	slog.InfoContext(ctx, "Processing message", append(attrs, "http_method", relayMessage.Webhook.HTTPMethod)...)
 
 	// Forward webhook
-	err = c.forwarder.Forward(ctx, &relayMessage.Webhook)
+	start := time.Now()
+	err = c.forwarder.Forward(ctx, relayMessage)
+	c.observeForward(relayMessage, err, time.Since(start))
 	if err != nil {
-		log.Printf("Failed to forward webhook %s: %v", relayMessage.Webhook.ID, err)
+		slog.WarnContext(ctx, "Failed to forward webhook", append(attrs, "error", err)...)
 		c.handleForwardError(ctx, redisMessage.ID, relayMessage, err)
 		return
 	}
 
 	// Acknowledge message
-	if err := c.redisClient.AcknowledgeMessage(ctx, redisMessage.ID); err != nil {
-		log.Printf("Failed to acknowledge message %s: %v", redisMessage.ID, err)
+	if err := c.queue.AcknowledgeMessage(ctx, redisMessage.ID); err != nil {
+		slog.ErrorContext(ctx, "Failed to acknowledge message", append(attrs, "error", err)...)
 		return
 	}
 
 	// Update metrics
 	atomic.AddInt64(&c.metrics.WebhooksProcessed, 1)
 
-	log.Printf("Successfully processed and acknowledged message: ID=%s", redisMessage.ID)
+	slog.InfoContext(ctx, "Successfully processed and acknowledged message", attrs...)
 }
 
 // handleForwardError handles forwarding errors with retry logic
 func (c *Consumer) handleForwardError(ctx context.Context, messageID string, relayMessage *models.RelayMessage, err error) {
+	policy := c.retryPolicy(ctx, relayMessage)
+
 	// Increment retry count
 	relayMessage.RetryCount++
 	atomic.AddInt64(&c.metrics.WebhooksRetried, 1)
+	platform, endpointID := relayMessage.Webhook.Platform, relayMessage.Webhook.EndpointID
+	attrs := logging.MessageAttrs(messageID, relayMessage)
 
 	// Check if max retries exceeded
-	if relayMessage.RetryCount >= c.config.MaxRetries {
-		log.Printf("Max retries exceeded for webhook %s, moving to DLQ", relayMessage.Webhook.ID)
+	if relayMessage.RetryCount >= policy.MaxRetries {
+		slog.WarnContext(ctx, "Max retries exceeded, moving to DLQ", append(attrs, "max_retries", policy.MaxRetries)...)
 		atomic.AddInt64(&c.metrics.WebhooksFailed, 1)
+		c.prom.IncDeadLettered(platform, endpointID, deadLetterMaxRetries)
 
 		// Move to dead letter queue
-		if dlqErr := c.redisClient.MoveToDeadLetterQueue(ctx, messageID, relayMessage); dlqErr != nil {
-			log.Printf("Failed to move message to DLQ: %v", dlqErr)
+		if dlqErr := c.queue.MoveToDeadLetterQueue(ctx, messageID, relayMessage, err); dlqErr != nil {
+			slog.ErrorContext(ctx, "Failed to move message to DLQ", append(attrs, "error", dlqErr)...)
 		}
 
 		return
 	}
 
 	// Calculate retry delay with exponential backoff
-	delay := time.Duration(c.config.RetryDelay) * time.Millisecond
-	for i := 1; i < relayMessage.RetryCount; i++ {
-		delay = time.Duration(float64(delay) * c.config.RetryMultiplier)
+	delay := policy.BackoffDelay(relayMessage.RetryCount)
+
+	slog.InfoContext(ctx, "Scheduling retry",
+		append(attrs, "delay_ms", delay.Milliseconds(), "max_retries", policy.MaxRetries)...)
+
+	// Park the message in the retry queue; the scheduler re-adds it to the stream when due
+	c.prom.IncRetry(platform, endpointID)
+	if retryErr := c.queue.ScheduleRetry(ctx, messageID, relayMessage, time.Now().Add(delay)); retryErr != nil {
+		slog.ErrorContext(ctx, "Failed to schedule retry", append(attrs, "error", retryErr)...)
+	}
+}
+
+// retryPolicy returns the retry policy of the message's endpoint, falling back to the global config
+func (c *Consumer) retryPolicy(ctx context.Context, relayMessage *models.RelayMessage) models.RetryConfig {
+	global := models.RetryConfig{
+		MaxRetries:      c.config.MaxRetries,
+		RetryDelay:      c.config.RetryDelay,
+		RetryMultiplier: c.config.RetryMultiplier,
+	}
+
+	endpoint, err := c.router.Endpoint(ctx, relayMessage.Webhook.EndpointID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to load retry policy", "endpoint_id", relayMessage.Webhook.EndpointID, "error", err)
+		return global
 	}
 
-	log.Printf("Retrying webhook %s in %v (attempt %d/%d)",
-		relayMessage.Webhook.ID, delay, relayMessage.RetryCount, c.config.MaxRetries)
+	if endpoint == nil || endpoint.RetryConfig.IsZero() {
+		return global
+	}
+
+	policy := endpoint.RetryConfig
+	if policy.RetryMultiplier <= 0 {
+		policy.RetryMultiplier = global.RetryMultiplier
+	}
 
-	// Re-add to stream with updated retry count
-	// Note: In production, you might want to use a separate retry queue
-	// For simplicity, we'll just log and let the consumer pick it up again
-	time.Sleep(delay)
+	return policy
 }
 
 // GetMetrics returns the current metrics
 func (c *Consumer) GetMetrics() *models.Metrics {
 	return c.metrics
 }
+
+// Reasons a message is moved to the DLQ, used as a metric label
+const (
+	deadLetterMaxRetries    = "max_retries"
+	deadLetterMaxDeliveries = "max_deliveries"
+)
+
+// observeForward records a forwarding attempt, labeling failures by their error class
+func (c *Consumer) observeForward(relayMessage *models.RelayMessage, err error, duration time.Duration) {
+	outcome := metrics.OutcomeSuccess
+	if err != nil {
+		outcome = models.ErrorClassOther
+		if n := len(relayMessage.Attempts); n > 0 {
+			outcome = relayMessage.Attempts[n-1].ErrorClass()
+		}
+	}
+	c.prom.ObserveForward(relayMessage.Webhook.Platform, relayMessage.Webhook.EndpointID, outcome, duration)
+}
@@ -0,0 +1,258 @@
+package relayclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/QuantumSolver/crm-relay/internal/metrics"
+	"github.com/QuantumSolver/crm-relay/internal/models"
+	"github.com/QuantumSolver/crm-relay/internal/storage"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/redis/go-redis/v9"
+)
+
+// newTestRouter returns a router whose endpoint cache already holds endpoints, so
+// lookups never reach Redis
+func newTestRouter(config *models.Config, endpoints ...*models.WebhookEndpoint) *Router {
+	router := NewRouter(nil, config)
+	for _, endpoint := range endpoints {
+		router.endpoints.entries[endpoint.ID] = cachedEndpoint{endpoint: endpoint, expiresAt: time.Now().Add(time.Hour)}
+	}
+	return router
+}
+
+func TestRetryPolicy(t *testing.T) {
+	config := &models.Config{MaxRetries: 3, RetryDelay: 1000, RetryMultiplier: 2}
+	global := models.RetryConfig{MaxRetries: 3, RetryDelay: 1000, RetryMultiplier: 2}
+
+	router := newTestRouter(config,
+		&models.WebhookEndpoint{ID: "default"},
+		&models.WebhookEndpoint{ID: "override", RetryConfig: models.RetryConfig{MaxRetries: 10, RetryDelay: 500, RetryMultiplier: 3}},
+		&models.WebhookEndpoint{ID: "no-multiplier", RetryConfig: models.RetryConfig{MaxRetries: 1, RetryDelay: 200}},
+	)
+	consumer := NewConsumer(nil, config, NewForwarder(config, router), router, nil)
+
+	tests := []struct {
+		endpointID string
+		want       models.RetryConfig
+	}{
+		{"", global},
+		{"default", global},
+		{"override", models.RetryConfig{MaxRetries: 10, RetryDelay: 500, RetryMultiplier: 3}},
+		{"no-multiplier", models.RetryConfig{MaxRetries: 1, RetryDelay: 200, RetryMultiplier: 2}},
+	}
+
+	for _, tt := range tests {
+		message := &models.RelayMessage{Webhook: models.Webhook{EndpointID: tt.endpointID}}
+		if got := consumer.retryPolicy(context.Background(), message); got != tt.want {
+			t.Errorf("endpoint %q: expected %+v, got %+v", tt.endpointID, tt.want, got)
+		}
+	}
+}
+
+func TestDispatchSkipsMessagesInFlight(t *testing.T) {
+	consumer := NewConsumer(nil, &models.Config{ConsumerWorkers: 1}, nil, nil, nil)
+	consumer.pool = newWorkerPool(1)
+
+	// Keep the only worker busy so the next message waits in the pool
+	release := make(chan struct{})
+	consumer.pool.Submit("", func() { <-release })
+
+	queued := make(chan struct{})
+	go func() {
+		consumer.dispatch(context.Background(), redis.XMessage{ID: "1-0"})
+		close(queued)
+	}()
+
+	deadline := time.Now().Add(time.Second)
+	for !consumer.isInFlight("1-0") {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the message to be in flight")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	// A reclaim of the same entry must not queue it a second time; dispatching it
+	// again would block behind the busy worker
+	skipped := make(chan struct{})
+	go func() {
+		consumer.dispatch(context.Background(), redis.XMessage{ID: "1-0"})
+		close(skipped)
+	}()
+	select {
+	case <-skipped:
+	case <-time.After(time.Second):
+		t.Fatal("Expected the in-flight message to be skipped")
+	}
+
+	close(release)
+	<-queued
+	consumer.pool.Close()
+
+	if consumer.isInFlight("1-0") {
+		t.Error("Expected the message to leave the in-flight set once processed")
+	}
+}
+
+// memoryQueue is a MessageQueue and RetryQueue standing in for Redis. Like the
+// Redis implementation, ScheduleRetry queues the retry and acknowledges the entry
+// together or not at all.
+type memoryQueue struct {
+	mu          sync.Mutex
+	pending     map[string]bool // entries delivered and not yet acknowledged
+	retries     []scheduledRetry
+	stream      []*models.RelayMessage // retries promoted back into the stream
+	deadLetters []string
+	ops         []string
+	scheduleErr error
+}
+
+type scheduledRetry struct {
+	message *models.RelayMessage
+	retryAt time.Time
+}
+
+func newMemoryQueue(pending ...string) *memoryQueue {
+	q := &memoryQueue{pending: make(map[string]bool)}
+	for _, id := range pending {
+		q.pending[id] = true
+	}
+	return q
+}
+
+func (q *memoryQueue) ReadMessages(ctx context.Context, count int64, block time.Duration) ([]redis.XMessage, error) {
+	return nil, nil
+}
+
+func (q *memoryQueue) ClaimStaleMessages(ctx context.Context, start string, minIdle time.Duration, count int64, skip func(id string) bool) ([]storage.ClaimedMessage, string, error) {
+	return nil, "0-0", nil
+}
+
+func (q *memoryQueue) AcknowledgeMessage(ctx context.Context, messageID string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.ops = append(q.ops, "ack "+messageID)
+	delete(q.pending, messageID)
+	return nil
+}
+
+func (q *memoryQueue) ScheduleRetry(ctx context.Context, messageID string, message *models.RelayMessage, retryAt time.Time) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.scheduleErr != nil {
+		return q.scheduleErr
+	}
+	q.ops = append(q.ops, "schedule "+messageID)
+	q.retries = append(q.retries, scheduledRetry{message: message, retryAt: retryAt})
+	delete(q.pending, messageID)
+	return nil
+}
+
+func (q *memoryQueue) MoveToDeadLetterQueue(ctx context.Context, messageID string, message *models.RelayMessage, cause error) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.ops = append(q.ops, "dead-letter "+messageID)
+	q.deadLetters = append(q.deadLetters, messageID)
+	delete(q.pending, messageID)
+	return nil
+}
+
+func (q *memoryQueue) PromoteDueRetries(ctx context.Context, now time.Time, limit int64) (int64, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	sort.SliceStable(q.retries, func(i, j int) bool { return q.retries[i].retryAt.Before(q.retries[j].retryAt) })
+
+	var promoted int64
+	for promoted < limit && promoted < int64(len(q.retries)) && !q.retries[promoted].retryAt.After(now) {
+		q.stream = append(q.stream, q.retries[promoted].message)
+		promoted++
+	}
+	q.retries = q.retries[promoted:]
+	return promoted, nil
+}
+
+// newFailingConsumer returns a consumer whose local service always answers 503
+func newFailingConsumer(t *testing.T, queue *memoryQueue, config *models.Config) *Consumer {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	t.Cleanup(server.Close)
+
+	config.LocalWebhookURL = server.URL
+	router := newTestRouter(config)
+	forwarder := NewForwarder(config, router)
+	t.Cleanup(func() { forwarder.Close() })
+	return NewConsumer(queue, config, forwarder, router, metrics.NewClientMetrics(prometheus.NewRegistry()))
+}
+
+func streamMessage(t *testing.T, id string, message *models.RelayMessage) redis.XMessage {
+	t.Helper()
+	data, err := json.Marshal(message)
+	if err != nil {
+		t.Fatalf("Failed to marshal message: %v", err)
+	}
+	return redis.XMessage{ID: id, Values: map[string]interface{}{"data": string(data)}}
+}
+
+func TestHandleForwardErrorSchedulesRetry(t *testing.T) {
+	queue := newMemoryQueue("1-0")
+	consumer := newFailingConsumer(t, queue, &models.Config{MaxRetries: 3, RetryDelay: 1000, RetryMultiplier: 2})
+
+	before := time.Now()
+	consumer.processMessage(context.Background(), streamMessage(t, "1-0", &models.RelayMessage{Webhook: models.Webhook{ID: "wh-1"}}))
+
+	// The failed entry is acknowledged only by scheduling its retry, never on its own
+	if want := []string{"schedule 1-0"}; !slices.Equal(queue.ops, want) {
+		t.Fatalf("Expected operations %v, got %v", want, queue.ops)
+	}
+	if queue.pending["1-0"] {
+		t.Error("Expected the entry to leave the pending list once its retry is queued")
+	}
+
+	retry := queue.retries[0]
+	if retry.message.Webhook.ID != "wh-1" || retry.message.RetryCount != 1 {
+		t.Errorf("Expected wh-1 queued with retry count 1, got %+v", retry.message)
+	}
+	if retry.retryAt.Before(before.Add(time.Second)) {
+		t.Errorf("Expected the retry at least 1s out, got %v", retry.retryAt.Sub(before))
+	}
+}
+
+func TestHandleForwardErrorKeepsEntryPendingWhenRetryFails(t *testing.T) {
+	queue := newMemoryQueue("1-0")
+	queue.scheduleErr = errors.New("redis unavailable")
+	consumer := newFailingConsumer(t, queue, &models.Config{MaxRetries: 3, RetryDelay: 1000, RetryMultiplier: 2})
+
+	consumer.processMessage(context.Background(), streamMessage(t, "1-0", &models.RelayMessage{Webhook: models.Webhook{ID: "wh-1"}}))
+
+	// Left unacknowledged, the entry is redelivered by the reclaimer
+	if len(queue.ops) != 0 {
+		t.Errorf("Expected no acknowledgement, got %v", queue.ops)
+	}
+	if !queue.pending["1-0"] {
+		t.Error("Expected the entry to stay pending")
+	}
+}
+
+func TestHandleForwardErrorDeadLettersAfterMaxRetries(t *testing.T) {
+	queue := newMemoryQueue("1-0")
+	consumer := newFailingConsumer(t, queue, &models.Config{MaxRetries: 3, RetryDelay: 1000, RetryMultiplier: 2})
+
+	consumer.processMessage(context.Background(), streamMessage(t, "1-0", &models.RelayMessage{Webhook: models.Webhook{ID: "wh-1"}, RetryCount: 2}))
+
+	if want := []string{"dead-letter 1-0"}; !slices.Equal(queue.ops, want) {
+		t.Errorf("Expected operations %v, got %v", want, queue.ops)
+	}
+	if len(queue.retries) != 0 {
+		t.Errorf("Expected no retry, got %d", len(queue.retries))
+	}
+}
@@ -0,0 +1,332 @@
+package relayclient
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/QuantumSolver/crm-relay/internal/models"
+	"github.com/QuantumSolver/crm-relay/internal/storage"
+)
+
+// dlqReplayScope namespaces idempotency keys that guard DLQ replays
+const dlqReplayScope = "dlq-replay"
+
+// dlqJobBatch is how many DLQ entries a job reads per page
+const dlqJobBatch = 100
+
+// dlqJobCheckInterval is how often a running job checks for cancellation and
+// saves its progress
+const dlqJobCheckInterval = time.Second
+
+// dlqJobStaleAfter is how long a running job can go without saving progress
+// before it is treated as abandoned by a client that stopped
+const dlqJobStaleAfter = time.Minute
+
+// replayOptions adjusts the message that a DLQ replay enqueues
+type replayOptions struct {
+	Headers         map[string]string // replaces the original headers when set
+	Body            []byte            // replaces the original body when set
+	TargetURL       string            // overrides the resolved route when set
+	ResetRetryCount bool
+	Actor           string
+	JobID           string
+}
+
+// replayMessage builds the message to enqueue for a DLQ entry and lists the parts
+// of it that were modified
+func replayMessage(entry *models.DLQEntry, opts replayOptions) (*models.RelayMessage, []string) {
+	message := entry.RelayMessage
+	var modified []string
+
+	if opts.Headers != nil {
+		message.Webhook.Headers = opts.Headers
+		modified = append(modified, "headers")
+	}
+	if opts.Body != nil {
+		message.Webhook.Body = opts.Body
+		// The original signature no longer covers the edited body
+		message.Webhook.Signature = ""
+		modified = append(modified, "body")
+	}
+	if opts.TargetURL != "" {
+		message.TargetEndpoint = opts.TargetURL
+		modified = append(modified, "target_url")
+	}
+	if opts.ResetRetryCount {
+		message.RetryCount = 0
+	}
+
+	return &message, modified
+}
+
+// replayDLQEntry re-enqueues a DLQ entry and removes it from the DLQ. If the entry was
+// already replayed, it is left alone and the stream ID of the earlier replay is returned
+// with duplicate set.
+func replayDLQEntry(ctx context.Context, redisClient *storage.RedisClient, config *models.Config, entry *models.DLQEntry, opts replayOptions) (replayID string, duplicate bool, err error) {
+	// Guard against the same DLQ entry being replayed twice, e.g. by concurrent requests
+	ttl := time.Duration(config.IdempotencyTTL) * time.Second
+	original, err := redisClient.ReserveIdempotencyKey(ctx, dlqReplayScope, entry.ID, entry.Webhook.ID, ttl)
+	if err != nil {
+		return "", false, err
+	}
+	if original != nil {
+		return original.MessageID, true, nil
+	}
+
+	// Re-add to main stream
+	message, modified := replayMessage(entry, opts)
+	replayID, err = redisClient.AddReplay(ctx, message, entry.OriginalID, opts.Actor)
+	if err != nil {
+		if releaseErr := redisClient.ReleaseIdempotencyKey(ctx, dlqReplayScope, entry.ID); releaseErr != nil {
+			slog.ErrorContext(ctx, "Failed to release DLQ replay", "dlq_id", entry.ID, "error", releaseErr)
+		}
+		return "", false, err
+	}
+
+	record := &models.IdempotencyRecord{WebhookID: entry.Webhook.ID, MessageID: replayID}
+	if err := redisClient.CompleteIdempotencyKey(ctx, dlqReplayScope, entry.ID, record); err != nil {
+		slog.ErrorContext(ctx, "Failed to record DLQ replay", "dlq_id", entry.ID, "error", err)
+	}
+
+	audit := &models.ReplayAudit{
+		DLQID:           entry.ID,
+		ReplayID:        replayID,
+		WebhookID:       entry.Webhook.ID,
+		Modified:        modified,
+		TargetURL:       opts.TargetURL,
+		RetryCountReset: opts.ResetRetryCount,
+		JobID:           opts.JobID,
+		ReplayedBy:      opts.Actor,
+		ReplayedAt:      time.Now(),
+	}
+	if err := redisClient.RecordReplay(ctx, audit); err != nil {
+		slog.ErrorContext(ctx, "Failed to record DLQ replay audit", "dlq_id", entry.ID, "error", err)
+	}
+
+	// Remove from DLQ
+	if err := redisClient.DeleteDLQMessage(ctx, entry.ID); err != nil {
+		slog.ErrorContext(ctx, "Failed to delete message from DLQ", "dlq_id", entry.ID, "error", err)
+	}
+
+	return replayID, false, nil
+}
+
+// DLQJobRunner runs bulk DLQ jobs in the background
+type DLQJobRunner struct {
+	redisClient *storage.RedisClient
+	config      *models.Config
+	ctx         context.Context
+	cancel      context.CancelFunc
+	wg          sync.WaitGroup
+}
+
+// NewDLQJobRunner creates a new DLQ job runner
+func NewDLQJobRunner(redisClient *storage.RedisClient, config *models.Config) *DLQJobRunner {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &DLQJobRunner{
+		redisClient: redisClient,
+		config:      config,
+		ctx:         ctx,
+		cancel:      cancel,
+	}
+}
+
+// Submit starts running a saved job in the background
+func (jr *DLQJobRunner) Submit(job *models.DLQJob) {
+	jr.wg.Add(1)
+	go func() {
+		defer jr.wg.Done()
+		jr.run(job)
+	}()
+}
+
+// Stop cancels running jobs and waits for them to record their final state
+func (jr *DLQJobRunner) Stop() {
+	jr.cancel()
+	jr.wg.Wait()
+	slog.Info("DLQ job runner stopped")
+}
+
+// run processes every DLQ entry matching the job filter
+func (jr *DLQJobRunner) run(job *models.DLQJob) {
+	ctx := jr.ctx
+	filter := job.Filter
+
+	// Only touch entries that existed when the job was created; replays that fail
+	// again land in the DLQ with newer IDs and must not be picked up by this job
+	if filter.Until.IsZero() || filter.Until.After(job.CreatedAt) {
+		filter.Until = job.CreatedAt
+	}
+
+	startedAt := time.Now()
+	job.Status = models.DLQJobStatusRunning
+	job.StartedAt = &startedAt
+
+	total, err := jr.redisClient.CountDLQ(ctx, filter)
+	if err != nil {
+		jr.finish(job, models.DLQJobStatusFailed, err.Error())
+		return
+	}
+	job.Total = total
+	jr.save(job)
+
+	slog.Info("DLQ job started", "job_id", job.ID, "action", job.Action, "total", job.Total)
+
+	var limiter *time.Ticker
+	if job.Action == models.DLQJobActionReplay {
+		rate := min(max(job.RatePerSecond, 1), models.MaxDLQReplayRate)
+		limiter = time.NewTicker(time.Second / time.Duration(rate))
+		defer limiter.Stop()
+	}
+
+	cursor := ""
+	lastCheck := time.Now()
+	for {
+		entries, next, err := jr.redisClient.ScanDLQ(ctx, filter, cursor, dlqJobBatch)
+		if err != nil {
+			jr.finish(job, models.DLQJobStatusFailed, err.Error())
+			return
+		}
+
+		for _, entry := range entries {
+			if limiter != nil {
+				select {
+				case <-ctx.Done():
+					jr.finish(job, models.DLQJobStatusCancelled, "client shutting down")
+					return
+				case <-limiter.C:
+				}
+			}
+
+			if time.Since(lastCheck) >= dlqJobCheckInterval {
+				if jr.stopRequested(ctx, job) {
+					return
+				}
+				lastCheck = time.Now()
+			}
+
+			if err := jr.apply(ctx, job, entry); err != nil {
+				slog.Warn("DLQ job failed on entry", "job_id", job.ID, "dlq_id", entry.ID, "error", err)
+				job.Failed++
+			} else {
+				job.Succeeded++
+			}
+			job.Processed++
+		}
+
+		if next == "" {
+			jr.finish(job, models.DLQJobStatusCompleted, "")
+			return
+		}
+		cursor = next
+
+		if jr.stopRequested(ctx, job) {
+			return
+		}
+		lastCheck = time.Now()
+	}
+}
+
+// stopRequested finishes the job as cancelled when the runner is stopping or the
+// job was cancelled, and otherwise saves its progress
+func (jr *DLQJobRunner) stopRequested(ctx context.Context, job *models.DLQJob) bool {
+	if ctx.Err() != nil {
+		jr.finish(job, models.DLQJobStatusCancelled, "client shutting down")
+		return true
+	}
+
+	cancelled, err := jr.redisClient.IsDLQJobCancelled(ctx, job.ID)
+	if err != nil {
+		slog.Error("Failed to check DLQ job cancellation", "job_id", job.ID, "error", err)
+	}
+	if cancelled {
+		jr.finish(job, models.DLQJobStatusCancelled, "")
+		return true
+	}
+
+	jr.save(job)
+	return false
+}
+
+// apply performs the job action on a single DLQ entry
+func (jr *DLQJobRunner) apply(ctx context.Context, job *models.DLQJob, entry *models.DLQEntry) error {
+	if job.Action == models.DLQJobActionDelete {
+		return jr.redisClient.DeleteDLQMessage(ctx, entry.ID)
+	}
+
+	opts := replayOptions{ResetRetryCount: true, Actor: job.CreatedBy, JobID: job.ID}
+	_, _, err := replayDLQEntry(ctx, jr.redisClient, jr.config, entry, opts)
+	return err
+}
+
+// finish records the final state of a job
+func (jr *DLQJobRunner) finish(job *models.DLQJob, status, errMsg string) {
+	finishedAt := time.Now()
+	job.Status = status
+	job.Error = errMsg
+	job.FinishedAt = &finishedAt
+	jr.save(job)
+
+	slog.Info("DLQ job finished",
+		"job_id", job.ID,
+		"status", job.Status,
+		"processed", job.Processed,
+		"succeeded", job.Succeeded,
+		"failed", job.Failed,
+	)
+}
+
+// save persists job progress; it uses its own context so the final state is
+// recorded even while the runner is shutting down
+func (jr *DLQJobRunner) save(job *models.DLQJob) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	updatedAt := time.Now()
+	job.UpdatedAt = &updatedAt
+
+	if err := jr.redisClient.SaveDLQJob(ctx, job); err != nil {
+		slog.Error("Failed to save DLQ job", "job_id", job.ID, "error", err)
+	}
+}
+
+// FailStaleJobs marks jobs that stopped saving progress while pending or running
+// as failed; they belong to a client that crashed or was killed mid-job
+func (jr *DLQJobRunner) FailStaleJobs(ctx context.Context) (int, error) {
+	jobs, err := jr.redisClient.ListDLQJobs(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	failed := 0
+	now := time.Now()
+	for _, job := range jobs {
+		if !isStaleJob(job, now) {
+			continue
+		}
+		slog.Warn("Marking abandoned DLQ job as failed", "job_id", job.ID, "status", job.Status, "processed", job.Processed)
+		jr.finish(job, models.DLQJobStatusFailed, "client stopped while the job was running")
+		failed++
+	}
+
+	return failed, nil
+}
+
+// isStaleJob reports whether a pending or running job has gone longer than
+// dlqJobStaleAfter without saving progress
+func isStaleJob(job *models.DLQJob, now time.Time) bool {
+	if job.Status != models.DLQJobStatusPending && job.Status != models.DLQJobStatusRunning {
+		return false
+	}
+
+	lastSeen := job.CreatedAt
+	if job.StartedAt != nil {
+		lastSeen = *job.StartedAt
+	}
+	if job.UpdatedAt != nil {
+		lastSeen = *job.UpdatedAt
+	}
+	return now.Sub(lastSeen) > dlqJobStaleAfter
+}
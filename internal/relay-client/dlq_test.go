@@ -0,0 +1,99 @@
+package relayclient
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/QuantumSolver/crm-relay/internal/models"
+)
+
+func TestReplayMessage(t *testing.T) {
+	entry := &models.DLQEntry{
+		ID: "1700000000000-0",
+		RelayMessage: models.RelayMessage{
+			MessageID:  "msg-1",
+			Webhook:    models.Webhook{ID: "wh-1", Headers: map[string]string{"A": "1"}, Body: []byte("old"), Signature: "sig"},
+			RetryCount: 3,
+		},
+	}
+
+	message, modified := replayMessage(entry, replayOptions{})
+	if modified != nil || message.RetryCount != 3 || string(message.Webhook.Body) != "old" {
+		t.Errorf("Expected unchanged message, got %+v (modified %v)", message, modified)
+	}
+
+	message, modified = replayMessage(entry, replayOptions{
+		Headers:         map[string]string{"B": "2"},
+		Body:            []byte("new"),
+		TargetURL:       "https://example.com/hook",
+		ResetRetryCount: true,
+	})
+	if want := []string{"headers", "body", "target_url"}; !reflect.DeepEqual(modified, want) {
+		t.Errorf("Expected modified %v, got %v", want, modified)
+	}
+	if message.Webhook.Headers["B"] != "2" || string(message.Webhook.Body) != "new" || message.Webhook.Signature != "" {
+		t.Errorf("Expected edited webhook without signature, got %+v", message.Webhook)
+	}
+	if message.TargetEndpoint != "https://example.com/hook" || message.RetryCount != 0 {
+		t.Errorf("Expected target override and reset retry count, got %+v", message)
+	}
+
+	// The DLQ entry itself must not be modified
+	if string(entry.Webhook.Body) != "old" || entry.RetryCount != 3 || entry.Webhook.Signature != "sig" {
+		t.Errorf("Expected DLQ entry to be left untouched, got %+v", entry.RelayMessage)
+	}
+}
+
+func TestIsStaleJob(t *testing.T) {
+	now := time.Now()
+	ago := func(d time.Duration) *time.Time {
+		at := now.Add(-d)
+		return &at
+	}
+
+	tests := []struct {
+		name string
+		job  models.DLQJob
+		want bool
+	}{
+		{
+			name: "running with recent progress",
+			job:  models.DLQJob{Status: models.DLQJobStatusRunning, CreatedAt: now.Add(-time.Hour), StartedAt: ago(time.Hour), UpdatedAt: ago(time.Second)},
+			want: false,
+		},
+		{
+			name: "running without progress since the stale window",
+			job:  models.DLQJob{Status: models.DLQJobStatusRunning, CreatedAt: now.Add(-time.Hour), StartedAt: ago(time.Hour), UpdatedAt: ago(2 * dlqJobStaleAfter)},
+			want: true,
+		},
+		{
+			name: "running job saved before updated_at existed",
+			job:  models.DLQJob{Status: models.DLQJobStatusRunning, CreatedAt: now.Add(-time.Hour), StartedAt: ago(time.Hour)},
+			want: true,
+		},
+		{
+			name: "pending job just created",
+			job:  models.DLQJob{Status: models.DLQJobStatusPending, CreatedAt: now},
+			want: false,
+		},
+		{
+			name: "pending job never started",
+			job:  models.DLQJob{Status: models.DLQJobStatusPending, CreatedAt: now.Add(-time.Hour)},
+			want: true,
+		},
+		{
+			name: "finished jobs are left alone",
+			job:  models.DLQJob{Status: models.DLQJobStatusCompleted, CreatedAt: now.Add(-time.Hour), UpdatedAt: ago(time.Hour)},
+			want: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isStaleJob(&tt.job, now); got != tt.want {
+				t.Errorf("isStaleJob() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package relayclient
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/QuantumSolver/crm-relay/internal/models"
+	"github.com/QuantumSolver/crm-relay/internal/storage"
+)
+
+// endpointCacheTTL controls how long endpoint configuration is reused before re-reading Redis
+const endpointCacheTTL = 30 * time.Second
+
+// cachedEndpoint is a cache entry; a nil endpoint records that the ID does not exist
+type cachedEndpoint struct {
+	endpoint  *models.WebhookEndpoint
+	expiresAt time.Time
+}
+
+// endpointCache caches webhook endpoint configuration managed by the relay server
+type endpointCache struct {
+	redisClient *storage.RedisClient
+	mu          sync.RWMutex
+	entries     map[string]cachedEndpoint
+}
+
+// newEndpointCache creates a new endpoint cache
+func newEndpointCache(redisClient *storage.RedisClient) *endpointCache {
+	return &endpointCache{
+		redisClient: redisClient,
+		entries:     make(map[string]cachedEndpoint),
+	}
+}
+
+// Get returns the endpoint with the given ID, or nil if it does not exist
+func (c *endpointCache) Get(ctx context.Context, id string) (*models.WebhookEndpoint, error) {
+	if id == "" {
+		return nil, nil
+	}
+
+	c.mu.RLock()
+	entry, ok := c.entries[id]
+	c.mu.RUnlock()
+	if ok && time.Now().Before(entry.expiresAt) {
+		return entry.endpoint, nil
+	}
+
+	endpoint, err := c.redisClient.GetEndpoint(ctx, id)
+	if err != nil {
+		relayErr, ok := err.(*models.RelayError)
+		if !ok || relayErr.Code != models.ErrCodeInvalidRequest {
+			return nil, err
+		}
+		// Endpoint was deleted or never existed; cache the miss as well
+		endpoint = nil
+	}
+
+	c.mu.Lock()
+	c.entries[id] = cachedEndpoint{
+		endpoint:  endpoint,
+		expiresAt: time.Now().Add(endpointCacheTTL),
+	}
+	c.mu.Unlock()
+
+	return endpoint, nil
+}
@@ -4,24 +4,35 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/QuantumSolver/crm-relay/internal/logging"
 	"github.com/QuantumSolver/crm-relay/internal/models"
+	"github.com/QuantumSolver/crm-relay/internal/tracing"
+	"github.com/QuantumSolver/crm-relay/pkg/relaysig"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Forwarder forwards webhooks to the local endpoint
 type Forwarder struct {
 	config      *models.Config
+	router      *Router
 	httpClient  *http.Client
 }
 
 // NewForwarder creates a new forwarder
-func NewForwarder(config *models.Config) *Forwarder {
+func NewForwarder(config *models.Config, router *Router) *Forwarder {
 	return &Forwarder{
 		config: config,
+		router: router,
 		httpClient: &http.Client{
 			Timeout: 30 * time.Second,
 			Transport: &http.Transport{
@@ -33,10 +44,38 @@ func NewForwarder(config *models.Config) *Forwarder {
 	}
 }
 
-// Forward forwards a webhook to the local endpoint
-func (f *Forwarder) Forward(ctx context.Context, webhook *models.Webhook) error {
+// maxAttemptResponseBytes bounds the response body snippet kept on a delivery attempt
+const maxAttemptResponseBytes = 1024
+
+// Forward forwards a relay message to the local endpoint selected by the router
+// and records the outcome in the message's attempt history
+func (f *Forwarder) Forward(ctx context.Context, message *models.RelayMessage) (err error) {
+	webhook := &message.Webhook
+	route := f.router.Resolve(ctx, message)
+
+	ctx, span := tracing.Start(ctx, "webhook.forward", trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("http.request.method", route.Method),
+			attribute.String("url.full", route.URL),
+			attribute.String("relay.webhook_id", webhook.ID),
+			attribute.Int("relay.attempt", len(message.Attempts)+1),
+		))
+	defer func() { tracing.End(span, err) }()
+
+	attempt := models.DeliveryAttempt{
+		Timestamp: time.Now(),
+		URL:       route.URL,
+	}
+	defer func() {
+		attempt.LatencyMs = time.Since(attempt.Timestamp).Milliseconds()
+		if err != nil {
+			attempt.Error = err.Error()
+		}
+		message.RecordAttempt(attempt)
+	}()
+
 	// Create request
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, f.config.LocalWebhookURL, bytes.NewReader(webhook.Body))
+	req, err := http.NewRequestWithContext(ctx, route.Method, route.URL, bytes.NewReader(webhook.Body))
 	if err != nil {
 		return models.NewRelayError(
 			models.ErrCodeWebhookForward,
@@ -53,6 +92,15 @@ func (f *Forwarder) Forward(ctx context.Context, webhook *models.Webhook) error
 			key == "Transfer-Encoding" || key == "Upgrade" {
 			continue
 		}
+		// Skip headers the relay sets itself, so a sender cannot spoof them
+		if isRelayHeader(key) {
+			continue
+		}
+		req.Header.Set(key, value)
+	}
+
+	// Add route headers
+	for key, value := range route.Headers {
 		req.Header.Set(key, value)
 	}
 
@@ -63,6 +111,37 @@ func (f *Forwarder) Forward(ctx context.Context, webhook *models.Webhook) error
 		req.Header.Set("X-Relay-Signature", webhook.Signature)
 	}
 
+	// Propagate the trace to the local service
+	tracing.InjectHeaders(ctx, propagation.HeaderCarrier(req.Header))
+
+	// Mark replays so the local service can tell a late delivery from a new event;
+	// X-Relay-Timestamp still carries the time the webhook was first received
+	if message.Replay != nil {
+		req.Header.Set("X-Relay-Replay", strconv.Itoa(message.Replay.Count))
+		req.Header.Set("X-Relay-Replayed-At", message.Replay.ReplayedAt.Format(time.RFC3339))
+		req.Header.Set("X-Relay-Original-Stream-ID", message.Replay.OriginalStreamID)
+	}
+
+	// The idempotency key stays the same across retries and replays of a delivery
+	idempotencyKey := webhook.IdempotencyKey
+	if idempotencyKey == "" {
+		idempotencyKey = webhook.ID
+	}
+	req.Header.Set("Idempotency-Key", idempotencyKey)
+
+	// Sign the request so the local service can reject calls that did not come from the relay
+	if route.SigningSecret != "" {
+		secret, err := relaysig.DecodeSecret(route.SigningSecret)
+		if err != nil {
+			return models.NewRelayError(
+				models.ErrCodeWebhookForward,
+				"invalid signing secret",
+				err,
+			)
+		}
+		relaysig.SetHeaders(req.Header, secret, webhook.ID, time.Now(), webhook.Body)
+	}
+
 	// Send request
 	start := time.Now()
 	resp, err := f.httpClient.Do(req)
@@ -75,21 +154,29 @@ func (f *Forwarder) Forward(ctx context.Context, webhook *models.Webhook) error
 	}
 	defer resp.Body.Close()
 
+	attempt.StatusCode = resp.StatusCode
+	span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
 	latency := time.Since(start)
-	log.Printf("Webhook forwarded: ID=%s, Status=%d, Latency=%v", webhook.ID, resp.StatusCode, latency)
+	slog.InfoContext(ctx, "Webhook forwarded", append(logging.WebhookAttrs(webhook),
+		"method", route.Method,
+		"url", route.URL,
+		"status", resp.StatusCode,
+		"latency_ms", latency.Milliseconds(),
+	)...)
 
 	// Read response body
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		log.Printf("Failed to read response body: %v", err)
+		slog.WarnContext(ctx, "Failed to read response body", append(logging.WebhookAttrs(webhook), "error", err)...)
 	}
+	attempt.Response = truncateResponse(body)
 
 	// Check response status
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
 		return models.NewRelayError(
 			models.ErrCodeWebhookForward,
 			"local webhook returned non-success status",
-			nil,
+			fmt.Errorf("status %d", resp.StatusCode),
 		)
 	}
 
@@ -97,13 +184,28 @@ func (f *Forwarder) Forward(ctx context.Context, webhook *models.Webhook) error
 	if len(body) > 0 {
 		var respData map[string]interface{}
 		if err := json.Unmarshal(body, &respData); err == nil {
-			log.Printf("Local webhook response: %+v", respData)
+			slog.DebugContext(ctx, "Local webhook response", append(logging.WebhookAttrs(webhook), "response", respData)...)
 		}
 	}
 
 	return nil
 }
 
+// isRelayHeader reports whether a header name belongs to the relay: the X-Relay-*
+// headers, the Standard Webhooks signature headers and Idempotency-Key
+func isRelayHeader(key string) bool {
+	key = strings.ToLower(key)
+	return strings.HasPrefix(key, "x-relay-") || strings.HasPrefix(key, "webhook-") || key == "idempotency-key"
+}
+
+// truncateResponse returns at most maxAttemptResponseBytes of a response body as valid UTF-8
+func truncateResponse(body []byte) string {
+	if len(body) > maxAttemptResponseBytes {
+		body = body[:maxAttemptResponseBytes]
+	}
+	return strings.ToValidUTF8(string(body), "")
+}
+
 // Close closes the forwarder
 func (f *Forwarder) Close() error {
 	f.httpClient.CloseIdleConnections()
@@ -0,0 +1,154 @@
+package relayclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/QuantumSolver/crm-relay/internal/models"
+	"github.com/QuantumSolver/crm-relay/internal/tracing"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+func TestForwardRecordsAttempts(t *testing.T) {
+	status := http.StatusBadGateway
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		w.Write([]byte(strings.Repeat("x", 4096)))
+	}))
+	defer server.Close()
+
+	config := &models.Config{LocalWebhookURL: server.URL}
+	forwarder := NewForwarder(config, NewRouter(nil, config))
+	defer forwarder.Close()
+
+	message := &models.RelayMessage{Webhook: models.Webhook{ID: "wh-1", Body: []byte(`{}`)}}
+
+	if err := forwarder.Forward(context.Background(), message); err == nil {
+		t.Fatal("Expected error for non-success status")
+	}
+
+	status = http.StatusOK
+	if err := forwarder.Forward(context.Background(), message); err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+
+	if len(message.Attempts) != 2 {
+		t.Fatalf("Expected 2 attempts, got %d", len(message.Attempts))
+	}
+
+	failed := message.Attempts[0]
+	if failed.StatusCode != http.StatusBadGateway || failed.Error == "" || failed.URL != server.URL {
+		t.Errorf("Unexpected failed attempt: %+v", failed)
+	}
+	if len(failed.Response) != maxAttemptResponseBytes {
+		t.Errorf("Expected response truncated to %d bytes, got %d", maxAttemptResponseBytes, len(failed.Response))
+	}
+
+	if ok := message.Attempts[1]; ok.StatusCode != http.StatusOK || ok.Error != "" {
+		t.Errorf("Unexpected successful attempt: %+v", ok)
+	}
+}
+
+func TestForwardMarksReplays(t *testing.T) {
+	var header http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header.Clone()
+	}))
+	defer server.Close()
+
+	config := &models.Config{LocalWebhookURL: server.URL}
+	forwarder := NewForwarder(config, NewRouter(nil, config))
+	defer forwarder.Close()
+
+	message := &models.RelayMessage{Webhook: models.Webhook{ID: "wh-1", Body: []byte(`{}`)}}
+	if err := forwarder.Forward(context.Background(), message); err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	if header.Get("X-Relay-Replay") != "" {
+		t.Errorf("Expected no replay header on first delivery, got %q", header.Get("X-Relay-Replay"))
+	}
+
+	message.Replay = &models.ReplayInfo{OriginalWebhookID: "wh-1", OriginalStreamID: "1700000000000-0", Count: 2}
+	if err := forwarder.Forward(context.Background(), message); err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+	if header.Get("X-Relay-Replay") != "2" || header.Get("X-Relay-Original-Stream-ID") != "1700000000000-0" {
+		t.Errorf("Expected replay headers, got %v", header)
+	}
+	if header.Get("X-Relay-Webhook-ID") != "wh-1" || header.Get("Idempotency-Key") != "wh-1" {
+		t.Errorf("Expected replay to keep the webhook identity, got %v", header)
+	}
+}
+
+func TestForwardDropsSpoofedRelayHeaders(t *testing.T) {
+	var header http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header.Clone()
+	}))
+	defer server.Close()
+
+	config := &models.Config{LocalWebhookURL: server.URL}
+	forwarder := NewForwarder(config, NewRouter(nil, config))
+	defer forwarder.Close()
+
+	message := &models.RelayMessage{Webhook: models.Webhook{
+		ID:   "wh-1",
+		Body: []byte(`{}`),
+		Headers: map[string]string{
+			"Content-Type":      "application/json",
+			"X-Relay-Replay":    "1",
+			"x-relay-signature": "forged",
+			"Webhook-Signature": "v1,forged",
+			"Idempotency-Key":   "attacker-key",
+		},
+	}}
+	if err := forwarder.Forward(context.Background(), message); err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+
+	if header.Get("X-Relay-Replay") != "" || header.Get("X-Relay-Signature") != "" || header.Get("Webhook-Signature") != "" {
+		t.Errorf("Expected spoofed relay headers to be dropped, got %v", header)
+	}
+	if header.Get("Idempotency-Key") != "wh-1" {
+		t.Errorf("Expected the relay's idempotency key, got %q", header.Get("Idempotency-Key"))
+	}
+	if header.Get("Content-Type") != "application/json" {
+		t.Errorf("Expected other headers to be forwarded, got %v", header)
+	}
+}
+
+func TestForwardPropagatesTrace(t *testing.T) {
+	var traceparent string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get("Traceparent")
+	}))
+	defer server.Close()
+
+	config := &models.Config{LocalWebhookURL: server.URL}
+	forwarder := NewForwarder(config, NewRouter(nil, config))
+	defer forwarder.Close()
+
+	provider := sdktrace.NewTracerProvider()
+	defer provider.Shutdown(context.Background())
+
+	// The message carries the trace context stored at ingest
+	ingestCtx, span := provider.Tracer("test").Start(context.Background(), "webhook.ingest")
+	span.End()
+	message := &models.RelayMessage{
+		Webhook:      models.Webhook{ID: "wh-1", Body: []byte(`{}`)},
+		TraceContext: tracing.Inject(ingestCtx),
+	}
+
+	ctx := tracing.Extract(context.Background(), message.TraceContext)
+	if err := forwarder.Forward(ctx, message); err != nil {
+		t.Fatalf("Expected success, got %v", err)
+	}
+
+	traceID := span.SpanContext().TraceID().String()
+	if !strings.Contains(traceparent, traceID) {
+		t.Errorf("Expected traceparent with trace ID %s, got %q", traceID, traceparent)
+	}
+}
@@ -3,31 +3,47 @@ package relayclient
 import (
 	"context"
 	"encoding/json"
-	"log"
+	"fmt"
+	"io"
+	"log/slog"
 	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
 	"sync/atomic"
 	"time"
 
+	"github.com/QuantumSolver/crm-relay/internal/adminapi"
 	"github.com/QuantumSolver/crm-relay/internal/auth"
+	"github.com/QuantumSolver/crm-relay/internal/logging"
+	"github.com/QuantumSolver/crm-relay/internal/metrics"
 	"github.com/QuantumSolver/crm-relay/internal/models"
 	"github.com/QuantumSolver/crm-relay/internal/storage"
+	"github.com/QuantumSolver/crm-relay/pkg/relaysig"
 )
 
 // Handler handles HTTP requests for the relay client
 type Handler struct {
+	*adminapi.Handler
 	redisClient *storage.RedisClient
 	config      *models.Config
 	metrics     *models.Metrics
-	jwtService  *auth.JWTService
+	prom        *metrics.ClientMetrics
+	router      *Router
+	dlqJobs     *DLQJobRunner
 }
 
 // NewHandler creates a new handler
-func NewHandler(redisClient *storage.RedisClient, config *models.Config, jwtService *auth.JWTService, metrics *models.Metrics) *Handler {
+func NewHandler(redisClient *storage.RedisClient, config *models.Config, jwtService *auth.JWTService, metrics *models.Metrics, prom *metrics.ClientMetrics, router *Router, dlqJobs *DLQJobRunner) *Handler {
 	return &Handler{
+		Handler:     adminapi.NewHandler(redisClient, config, jwtService),
 		redisClient: redisClient,
 		config:      config,
 		metrics:     metrics,
-		jwtService:  jwtService,
+		prom:        prom,
+		router:      router,
+		dlqJobs:     dlqJobs,
 	}
 }
 
@@ -36,11 +52,11 @@ func (h *Handler) GetMetrics() *models.Metrics {
 	return h.metrics
 }
 
-// Auth endpoints
+// Configuration endpoints
 
-// HandleLogin handles login requests
-func (h *Handler) HandleLogin(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
+// HandleGetConfig handles requests to get the current configuration
+func (h *Handler) HandleGetConfig(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
 		sendErrorResponse(w, http.StatusMethodNotAllowed, models.NewRelayError(
 			models.ErrCodeInvalidRequest,
 			"method not allowed",
@@ -49,67 +65,58 @@ func (h *Handler) HandleLogin(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var loginReq models.LoginRequest
-	if err := json.NewDecoder(r.Body).Decode(&loginReq); err != nil {
-		sendErrorResponse(w, http.StatusBadRequest, models.NewRelayError(
-			models.ErrCodeInvalidRequest,
-			"invalid request body",
-			err,
-		))
-		return
-	}
-
-	// Validate credentials
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"local_endpoint": h.config.LocalWebhookURL,
+		"retry_config": map[string]interface{}{
+			"max_retries":        h.config.MaxRetries,
+			"retry_delay":        h.config.RetryDelay,
+			"backoff_multiplier": h.config.RetryMultiplier,
+		},
+	})
+}
 
-	user, err := h.redisClient.GetUser(ctx, loginReq.Username)
-	if err != nil {
-		sendErrorResponse(w, http.StatusUnauthorized, models.NewRelayError(
-			models.ErrCodeAuthentication,
-			"invalid credentials",
+// HandleUpdateLocalEndpoint handles requests to update the local webhook endpoint
+func (h *Handler) HandleUpdateLocalEndpoint(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		sendErrorResponse(w, http.StatusMethodNotAllowed, models.NewRelayError(
+			models.ErrCodeInvalidRequest,
+			"method not allowed",
 			nil,
 		))
 		return
 	}
 
-	// Verify password
-	if !auth.VerifyPassword(loginReq.Password, user.PasswordHash) {
-		sendErrorResponse(w, http.StatusUnauthorized, models.NewRelayError(
-			models.ErrCodeAuthentication,
-			"invalid credentials",
-			nil,
-		))
-		return
+	var req struct {
+		LocalWebhookURL string `json:"local_webhook_url"`
 	}
 
-	// Generate JWT token
-	token, expiresAt, err := h.jwtService.GenerateToken(user)
-	if err != nil {
-		log.Printf("Failed to generate token: %v", err)
-		sendErrorResponse(w, http.StatusInternalServerError, models.NewRelayError(
-			models.ErrCodeAuthentication,
-			"failed to generate token",
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendErrorResponse(w, http.StatusBadRequest, models.NewRelayError(
+			models.ErrCodeInvalidRequest,
+			"invalid request body",
 			err,
 		))
 		return
 	}
 
-	// Send response
+	// Update config (in a real implementation, this would persist to storage)
+	h.config.LocalWebhookURL = req.LocalWebhookURL
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(models.LoginResponse{
-		Token:     token,
-		User:      *user,
-		ExpiresAt: expiresAt,
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":           true,
+		"local_webhook_url": h.config.LocalWebhookURL,
 	})
 
-	log.Printf("User logged in: %s", user.Username)
+	slog.InfoContext(r.Context(), "Local webhook endpoint updated", "url", h.config.LocalWebhookURL)
 }
 
-// HandleGetCurrentUser handles requests to get the current user
-func (h *Handler) HandleGetCurrentUser(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
+// HandleUpdateRetryConfig handles requests to update retry configuration
+func (h *Handler) HandleUpdateRetryConfig(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
 		sendErrorResponse(w, http.StatusMethodNotAllowed, models.NewRelayError(
 			models.ErrCodeInvalidRequest,
 			"method not allowed",
@@ -118,41 +125,52 @@ func (h *Handler) HandleGetCurrentUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Get user from context
-	claims, ok := r.Context().Value("user").(*models.JWTClaims)
-	if !ok {
-		sendErrorResponse(w, http.StatusUnauthorized, models.NewRelayError(
-			models.ErrCodeAuthentication,
-			"user not authenticated",
-			nil,
-		))
-		return
+	var req struct {
+		MaxRetries      *int     `json:"max_retries"`
+		RetryDelay      *int     `json:"retry_delay"`
+		RetryMultiplier *float64 `json:"retry_multiplier"`
 	}
 
-	// Get user from storage
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-
-	user, err := h.redisClient.GetUser(ctx, claims.Username)
-	if err != nil {
-		sendErrorResponse(w, http.StatusNotFound, models.NewRelayError(
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendErrorResponse(w, http.StatusBadRequest, models.NewRelayError(
 			models.ErrCodeInvalidRequest,
-			"user not found",
+			"invalid request body",
 			err,
 		))
 		return
 	}
 
-	// Send response
+	// Update config
+	if req.MaxRetries != nil {
+		h.config.MaxRetries = *req.MaxRetries
+	}
+	if req.RetryDelay != nil {
+		h.config.RetryDelay = *req.RetryDelay
+	}
+	if req.RetryMultiplier != nil {
+		h.config.RetryMultiplier = *req.RetryMultiplier
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(user)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":          true,
+		"max_retries":      h.config.MaxRetries,
+		"retry_delay":      h.config.RetryDelay,
+		"retry_multiplier": h.config.RetryMultiplier,
+	})
+
+	slog.InfoContext(r.Context(), "Retry config updated",
+		"max_retries", h.config.MaxRetries,
+		"retry_delay", h.config.RetryDelay,
+		"retry_multiplier", h.config.RetryMultiplier,
+	)
 }
 
-// Configuration endpoints
+// Route endpoints
 
-// HandleGetConfig handles requests to get the current configuration
-func (h *Handler) HandleGetConfig(w http.ResponseWriter, r *http.Request) {
+// HandleListRoutes handles requests to list all routes
+func (h *Handler) HandleListRoutes(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		sendErrorResponse(w, http.StatusMethodNotAllowed, models.NewRelayError(
 			models.ErrCodeInvalidRequest,
@@ -162,21 +180,32 @@ func (h *Handler) HandleGetConfig(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	routes, err := h.redisClient.ListRoutes(ctx)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to list routes", "error", err)
+		sendErrorResponse(w, http.StatusInternalServerError, err.(*models.RelayError))
+		return
+	}
+
+	infos := make([]models.RouteInfo, 0, len(routes))
+	for _, route := range routes {
+		infos = append(infos, route.Info())
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"local_endpoint": h.config.LocalWebhookURL,
-		"retry_config": map[string]interface{}{
-			"max_retries":        h.config.MaxRetries,
-			"retry_delay":        h.config.RetryDelay,
-			"backoff_multiplier": h.config.RetryMultiplier,
-		},
+		"routes":        infos,
+		"default_route": h.config.LocalWebhookURL,
 	})
 }
 
-// HandleUpdateLocalEndpoint handles requests to update the local webhook endpoint
-func (h *Handler) HandleUpdateLocalEndpoint(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPut {
+// HandleCreateRoute handles requests to create a new route
+func (h *Handler) HandleCreateRoute(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
 		sendErrorResponse(w, http.StatusMethodNotAllowed, models.NewRelayError(
 			models.ErrCodeInvalidRequest,
 			"method not allowed",
@@ -186,7 +215,12 @@ func (h *Handler) HandleUpdateLocalEndpoint(w http.ResponseWriter, r *http.Reque
 	}
 
 	var req struct {
-		LocalWebhookURL string `json:"local_webhook_url"`
+		Platform      string            `json:"platform"`
+		EndpointID    string            `json:"endpoint_id"`
+		URL           string            `json:"url"`
+		HTTPMethod    string            `json:"http_method"`
+		Headers       map[string]string `json:"headers"`
+		SigningSecret string            `json:"signing_secret"`
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -198,21 +232,60 @@ func (h *Handler) HandleUpdateLocalEndpoint(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	// Update config (in a real implementation, this would persist to storage)
-	h.config.LocalWebhookURL = req.LocalWebhookURL
+	// Generate ID
+	id, err := auth.GenerateID()
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to generate ID", "error", err)
+		sendErrorResponse(w, http.StatusInternalServerError, models.NewRelayError(
+			models.ErrCodeInvalidRequest,
+			"failed to generate ID",
+			err,
+		))
+		return
+	}
+
+	route := &models.Route{
+		ID:            id,
+		Platform:      req.Platform,
+		EndpointID:    req.EndpointID,
+		URL:           req.URL,
+		HTTPMethod:    strings.ToUpper(req.HTTPMethod),
+		Headers:       req.Headers,
+		SigningSecret: req.SigningSecret,
+		CreatedAt:     time.Now(),
+		UpdatedAt:     time.Now(),
+	}
+
+	if err := validateRoute(route); err != nil {
+		sendErrorResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if status, err := h.checkRouteConflict(ctx, route); err != nil {
+		sendErrorResponse(w, status, err)
+		return
+	}
+
+	if err := h.redisClient.CreateRoute(ctx, route); err != nil {
+		slog.ErrorContext(r.Context(), "Failed to create route", "error", err)
+		sendErrorResponse(w, http.StatusInternalServerError, err.(*models.RelayError))
+		return
+	}
+
+	h.reloadRoutes(ctx)
 
 	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"success":           true,
-		"local_webhook_url": h.config.LocalWebhookURL,
-	})
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(route.Info())
 
-	log.Printf("Local webhook endpoint updated: %s", h.config.LocalWebhookURL)
+	slog.InfoContext(r.Context(), "Route created", "route_id", route.ID, "url", route.URL)
 }
 
-// HandleUpdateRetryConfig handles requests to update retry configuration
-func (h *Handler) HandleUpdateRetryConfig(w http.ResponseWriter, r *http.Request) {
+// HandleUpdateRoute handles requests to update a route
+func (h *Handler) HandleUpdateRoute(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPut {
 		sendErrorResponse(w, http.StatusMethodNotAllowed, models.NewRelayError(
 			models.ErrCodeInvalidRequest,
@@ -223,9 +296,12 @@ func (h *Handler) HandleUpdateRetryConfig(w http.ResponseWriter, r *http.Request
 	}
 
 	var req struct {
-		MaxRetries      *int     `json:"max_retries"`
-		RetryDelay      *int     `json:"retry_delay"`
-		RetryMultiplier *float64 `json:"retry_multiplier"`
+		Platform      *string            `json:"platform"`
+		EndpointID    *string            `json:"endpoint_id"`
+		URL           *string            `json:"url"`
+		HTTPMethod    *string            `json:"http_method"`
+		Headers       *map[string]string `json:"headers"`
+		SigningSecret *string            `json:"signing_secret"`
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -237,28 +313,198 @@ func (h *Handler) HandleUpdateRetryConfig(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	// Update config
-	if req.MaxRetries != nil {
-		h.config.MaxRetries = *req.MaxRetries
+	// Extract ID from URL path
+	id := r.URL.Path[len("/api/routes/"):]
+	if id == "" {
+		sendErrorResponse(w, http.StatusBadRequest, models.NewRelayError(
+			models.ErrCodeInvalidRequest,
+			"missing route ID",
+			nil,
+		))
+		return
 	}
-	if req.RetryDelay != nil {
-		h.config.RetryDelay = *req.RetryDelay
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	route, err := h.redisClient.GetRoute(ctx, id)
+	if err != nil {
+		sendErrorResponse(w, http.StatusNotFound, err.(*models.RelayError))
+		return
 	}
-	if req.RetryMultiplier != nil {
-		h.config.RetryMultiplier = *req.RetryMultiplier
+
+	// Update fields
+	if req.Platform != nil {
+		route.Platform = *req.Platform
+	}
+	if req.EndpointID != nil {
+		route.EndpointID = *req.EndpointID
+	}
+	if req.URL != nil {
+		route.URL = *req.URL
+	}
+	if req.HTTPMethod != nil {
+		route.HTTPMethod = strings.ToUpper(*req.HTTPMethod)
+	}
+	if req.Headers != nil {
+		route.Headers = *req.Headers
+	}
+	if req.SigningSecret != nil {
+		route.SigningSecret = *req.SigningSecret
+	}
+
+	if err := validateRoute(route); err != nil {
+		sendErrorResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if status, err := h.checkRouteConflict(ctx, route); err != nil {
+		sendErrorResponse(w, status, err)
+		return
+	}
+
+	if err := h.redisClient.UpdateRoute(ctx, route); err != nil {
+		slog.ErrorContext(r.Context(), "Failed to update route", "error", err)
+		sendErrorResponse(w, http.StatusInternalServerError, err.(*models.RelayError))
+		return
+	}
+
+	h.reloadRoutes(ctx)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(route.Info())
+
+	slog.InfoContext(r.Context(), "Route updated", "route_id", route.ID)
+}
+
+// HandleDeleteRoute handles requests to delete a route
+func (h *Handler) HandleDeleteRoute(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		sendErrorResponse(w, http.StatusMethodNotAllowed, models.NewRelayError(
+			models.ErrCodeInvalidRequest,
+			"method not allowed",
+			nil,
+		))
+		return
 	}
 
+	// Extract ID from URL path
+	id := r.URL.Path[len("/api/routes/"):]
+	if id == "" {
+		sendErrorResponse(w, http.StatusBadRequest, models.NewRelayError(
+			models.ErrCodeInvalidRequest,
+			"missing route ID",
+			nil,
+		))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := h.redisClient.DeleteRoute(ctx, id); err != nil {
+		relayErr := err.(*models.RelayError)
+		if relayErr.Code == models.ErrCodeInvalidRequest {
+			sendErrorResponse(w, http.StatusNotFound, relayErr)
+			return
+		}
+		slog.ErrorContext(r.Context(), "Failed to delete route", "error", err)
+		sendErrorResponse(w, http.StatusInternalServerError, relayErr)
+		return
+	}
+
+	h.reloadRoutes(ctx)
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"success":          true,
-		"max_retries":      h.config.MaxRetries,
-		"retry_delay":      h.config.RetryDelay,
-		"retry_multiplier": h.config.RetryMultiplier,
+		"success": true,
+		"message": "Route deleted successfully",
 	})
 
-	log.Printf("Retry config updated: MaxRetries=%d, RetryDelay=%d, RetryMultiplier=%.2f",
-		h.config.MaxRetries, h.config.RetryDelay, h.config.RetryMultiplier)
+	slog.InfoContext(r.Context(), "Route deleted", "route_id", id)
+}
+
+// reloadRoutes refreshes the router after the routing table changed
+func (h *Handler) reloadRoutes(ctx context.Context) {
+	if err := h.router.Reload(ctx); err != nil {
+		slog.ErrorContext(ctx, "Failed to reload routes", "error", err)
+	}
+}
+
+// checkRouteConflict rejects a route whose endpoint ID or platform is already routed
+func (h *Handler) checkRouteConflict(ctx context.Context, route *models.Route) (int, *models.RelayError) {
+	routes, err := h.redisClient.ListRoutes(ctx)
+	if err != nil {
+		return http.StatusInternalServerError, err.(*models.RelayError)
+	}
+
+	if other := conflictingRoute(routes, route); other != nil {
+		match := "platform " + route.Platform
+		if route.EndpointID != "" {
+			match = "endpoint " + route.EndpointID
+		}
+		return http.StatusConflict, models.NewRelayError(
+			models.ErrCodeInvalidRequest,
+			fmt.Sprintf("route %s already exists for %s", other.ID, match),
+			nil,
+		)
+	}
+
+	return 0, nil
+}
+
+// routeMethods are the HTTP methods a route may forward with
+var routeMethods = []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete}
+
+// validateRoute checks that a route has a match key and a usable destination
+func validateRoute(route *models.Route) *models.RelayError {
+	if route.Platform == "" && route.EndpointID == "" {
+		return models.NewRelayError(
+			models.ErrCodeInvalidRequest,
+			"route requires a platform or endpoint_id",
+			nil,
+		)
+	}
+
+	if route.HTTPMethod != "" && !slices.Contains(routeMethods, route.HTTPMethod) {
+		return models.NewRelayError(
+			models.ErrCodeInvalidRequest,
+			"http_method must be one of "+strings.Join(routeMethods, ", "),
+			nil,
+		)
+	}
+
+	if err := validateHTTPURL(route.URL); err != nil {
+		return models.NewRelayError(
+			models.ErrCodeInvalidRequest,
+			"route url must be an absolute http or https URL",
+			err,
+		)
+	}
+
+	if _, err := relaysig.DecodeSecret(route.SigningSecret); err != nil {
+		return models.NewRelayError(
+			models.ErrCodeInvalidRequest,
+			"signing_secret with whsec_ prefix must be base64-encoded",
+			err,
+		)
+	}
+
+	return nil
+}
+
+// validateHTTPURL checks that raw is an absolute http or https URL
+func validateHTTPURL(raw string) error {
+	parsed, err := url.Parse(raw)
+	if err != nil {
+		return err
+	}
+	if (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return fmt.Errorf("invalid URL %q", raw)
+	}
+	return nil
 }
 
 // Dead Letter Queue endpoints
@@ -274,25 +520,85 @@ func (h *Handler) HandleGetDLQMessages(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	query := r.URL.Query()
+	filter := models.DLQFilter{
+		Platform:   query.Get("platform"),
+		EndpointID: query.Get("endpoint_id"),
+		ErrorCode:  query.Get("error_code"),
+		ErrorClass: query.Get("error_class"),
+		Query:      query.Get("q"),
+	}
+
+	var err error
+	if filter.Since, err = parseTimeParam(query.Get("since")); err != nil {
+		sendErrorResponse(w, http.StatusBadRequest, models.NewRelayError(
+			models.ErrCodeInvalidRequest,
+			"since must be an RFC 3339 timestamp",
+			err,
+		))
+		return
+	}
+	if filter.Until, err = parseTimeParam(query.Get("until")); err != nil {
+		sendErrorResponse(w, http.StatusBadRequest, models.NewRelayError(
+			models.ErrCodeInvalidRequest,
+			"until must be an RFC 3339 timestamp",
+			err,
+		))
+		return
+	}
+
+	limit := dlqDefaultPageSize
+	if value := query.Get("limit"); value != "" {
+		limit, err = strconv.Atoi(value)
+		if err != nil || limit <= 0 || limit > dlqMaxPageSize {
+			sendErrorResponse(w, http.StatusBadRequest, models.NewRelayError(
+				models.ErrCodeInvalidRequest,
+				fmt.Sprintf("limit must be between 1 and %d", dlqMaxPageSize),
+				nil,
+			))
+			return
+		}
+	}
+
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
 	// Read messages from DLQ
-	messages, err := h.redisClient.ReadDLQMessages(ctx, 100)
+	messages, nextCursor, err := h.redisClient.ListDLQ(ctx, filter, query.Get("cursor"), limit)
 	if err != nil {
-		log.Printf("Failed to read DLQ messages: %v", err)
-		sendErrorResponse(w, http.StatusInternalServerError, err.(*models.RelayError))
+		relayErr := err.(*models.RelayError)
+		if relayErr.Code == models.ErrCodeInvalidRequest {
+			sendErrorResponse(w, http.StatusBadRequest, relayErr)
+			return
+		}
+		slog.ErrorContext(r.Context(), "Failed to read DLQ messages", "error", err)
+		sendErrorResponse(w, http.StatusInternalServerError, relayErr)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"messages": messages,
-		"count":    len(messages),
+		"messages":    messages,
+		"count":       len(messages),
+		"next_cursor": nextCursor,
 	})
 }
 
+// DLQ page sizes
+const (
+	dlqDefaultPageSize = 50
+	dlqMaxPageSize     = 500
+)
+
+// parseTimeParam parses an optional RFC 3339 query parameter
+func parseTimeParam(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
 // HandleReplayDLQMessage handles requests to replay a DLQ message
 func (h *Handler) HandleReplayDLQMessage(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
@@ -318,6 +624,43 @@ func (h *Handler) HandleReplayDLQMessage(w http.ResponseWriter, r *http.Request)
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
+	// The body is optional; without one the message is replayed unchanged
+	var req struct {
+		Headers         map[string]string `json:"headers"`
+		Body            *string           `json:"body"`
+		TargetURL       string            `json:"target_url"`
+		ResetRetryCount *bool             `json:"reset_retry_count"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		sendErrorResponse(w, http.StatusBadRequest, models.NewRelayError(
+			models.ErrCodeInvalidRequest,
+			"invalid request body",
+			err,
+		))
+		return
+	}
+
+	if req.TargetURL != "" && !h.router.IsKnownURL(req.TargetURL) {
+		sendErrorResponse(w, http.StatusBadRequest, models.NewRelayError(
+			models.ErrCodeInvalidRequest,
+			"target_url must be LOCAL_WEBHOOK_URL or the URL of a configured route",
+			nil,
+		))
+		return
+	}
+
+	opts := replayOptions{
+		Headers:         req.Headers,
+		TargetURL:       req.TargetURL,
+		ResetRetryCount: req.ResetRetryCount == nil || *req.ResetRetryCount,
+	}
+	if req.Body != nil {
+		opts.Body = []byte(*req.Body)
+	}
+	if claims, ok := r.Context().Value("user").(*models.JWTClaims); ok {
+		opts.Actor = claims.Username
+	}
+
 	// Get message from DLQ
 	message, err := h.redisClient.GetDLQMessage(ctx, messageID)
 	if err != nil {
@@ -325,28 +668,82 @@ func (h *Handler) HandleReplayDLQMessage(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	// Re-add to main stream
-	_, err = h.redisClient.AddWebhook(ctx, &message.Webhook)
+	replayID, duplicate, err := replayDLQEntry(ctx, h.redisClient, h.config, message, opts)
 	if err != nil {
-		log.Printf("Failed to replay message: %v", err)
+		slog.ErrorContext(r.Context(), "Failed to replay message", "error", err)
 		sendErrorResponse(w, http.StatusInternalServerError, err.(*models.RelayError))
 		return
 	}
 
-	// Remove from DLQ
-	if err := h.redisClient.DeleteDLQMessage(ctx, messageID); err != nil {
-		log.Printf("Failed to delete message from DLQ: %v", err)
+	if duplicate {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success":           true,
+			"duplicate":         true,
+			"message":           "Message was already replayed",
+			"message_id":        messageID,
+			"replay_message_id": replayID,
+		})
+		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"success":    true,
-		"message":    "Message replayed successfully",
-		"message_id": messageID,
+		"success":           true,
+		"message":           "Message replayed successfully",
+		"message_id":        messageID,
+		"replay_message_id": replayID,
 	})
 
-	log.Printf("DLQ message replayed: %s", messageID)
+	slog.InfoContext(r.Context(), "DLQ message replayed",
+		append(logging.WebhookAttrs(&message.Webhook), "dlq_id", messageID, "message_id", replayID)...)
+}
+
+// HandleListDLQReplays handles requests to list the DLQ replay audit log
+func (h *Handler) HandleListDLQReplays(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		sendErrorResponse(w, http.StatusMethodNotAllowed, models.NewRelayError(
+			models.ErrCodeInvalidRequest,
+			"method not allowed",
+			nil,
+		))
+		return
+	}
+
+	query := r.URL.Query()
+
+	limit := dlqDefaultPageSize
+	if value := query.Get("limit"); value != "" {
+		var err error
+		limit, err = strconv.Atoi(value)
+		if err != nil || limit <= 0 || limit > dlqMaxPageSize {
+			sendErrorResponse(w, http.StatusBadRequest, models.NewRelayError(
+				models.ErrCodeInvalidRequest,
+				fmt.Sprintf("limit must be between 1 and %d", dlqMaxPageSize),
+				err,
+			))
+			return
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	replays, err := h.redisClient.ListReplays(ctx, query.Get("dlq_id"), int64(limit))
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to list DLQ replays", "error", err)
+		sendErrorResponse(w, http.StatusInternalServerError, err.(*models.RelayError))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"replays": replays,
+		"count":   len(replays),
+	})
 }
 
 // HandleDeleteDLQMessage handles requests to delete a DLQ message
@@ -375,7 +772,7 @@ func (h *Handler) HandleDeleteDLQMessage(w http.ResponseWriter, r *http.Request)
 	defer cancel()
 
 	if err := h.redisClient.DeleteDLQMessage(ctx, messageID); err != nil {
-		log.Printf("Failed to delete DLQ message: %v", err)
+		slog.ErrorContext(r.Context(), "Failed to delete DLQ message", "error", err)
 		sendErrorResponse(w, http.StatusInternalServerError, err.(*models.RelayError))
 		return
 	}
@@ -388,7 +785,258 @@ func (h *Handler) HandleDeleteDLQMessage(w http.ResponseWriter, r *http.Request)
 		"message_id": messageID,
 	})
 
-	log.Printf("DLQ message deleted: %s", messageID)
+	slog.InfoContext(r.Context(), "DLQ message deleted", "dlq_id", messageID)
+}
+
+// Bulk DLQ job endpoints
+
+// HandleCreateDLQJob starts a bulk replay or delete over DLQ entries matching a filter.
+// With dry_run set it only reports how many entries match.
+func (h *Handler) HandleCreateDLQJob(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		sendErrorResponse(w, http.StatusMethodNotAllowed, models.NewRelayError(
+			models.ErrCodeInvalidRequest,
+			"method not allowed",
+			nil,
+		))
+		return
+	}
+
+	var req struct {
+		Action        string           `json:"action"`
+		Filter        models.DLQFilter `json:"filter"`
+		RatePerSecond int              `json:"rate_per_second"`
+		DryRun        bool             `json:"dry_run"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendErrorResponse(w, http.StatusBadRequest, models.NewRelayError(
+			models.ErrCodeInvalidRequest,
+			"invalid request body",
+			err,
+		))
+		return
+	}
+
+	if req.Action != models.DLQJobActionReplay && req.Action != models.DLQJobActionDelete {
+		sendErrorResponse(w, http.StatusBadRequest, models.NewRelayError(
+			models.ErrCodeInvalidRequest,
+			"action must be 'replay' or 'delete'",
+			nil,
+		))
+		return
+	}
+
+	switch req.Filter.ErrorClass {
+	case "", models.ErrorClassNetwork, models.ErrorClassClient, models.ErrorClassServer,
+		models.ErrorClassOther, models.ErrorClassUnacknowledged:
+	default:
+		sendErrorResponse(w, http.StatusBadRequest, models.NewRelayError(
+			models.ErrCodeInvalidRequest,
+			"error_class must be one of network, 4xx, 5xx, other or unacknowledged",
+			nil,
+		))
+		return
+	}
+
+	if req.RatePerSecond < 0 || req.RatePerSecond > models.MaxDLQReplayRate {
+		sendErrorResponse(w, http.StatusBadRequest, models.NewRelayError(
+			models.ErrCodeInvalidRequest,
+			fmt.Sprintf("rate_per_second must be between 1 and %d", models.MaxDLQReplayRate),
+			nil,
+		))
+		return
+	}
+	if req.RatePerSecond == 0 {
+		req.RatePerSecond = h.config.DLQReplayRate
+	}
+
+	if req.DryRun {
+		// Counting walks the whole DLQ, so allow more time than a regular request
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		matched, err := h.redisClient.CountDLQ(ctx, req.Filter)
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Failed to count DLQ messages", "error", err)
+			sendErrorResponse(w, http.StatusInternalServerError, err.(*models.RelayError))
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"dry_run": true,
+			"action":  req.Action,
+			"matched": matched,
+		})
+		return
+	}
+
+	// Generate ID
+	id, err := auth.GenerateID()
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to generate ID", "error", err)
+		sendErrorResponse(w, http.StatusInternalServerError, models.NewRelayError(
+			models.ErrCodeInvalidRequest,
+			"failed to generate ID",
+			err,
+		))
+		return
+	}
+
+	job := &models.DLQJob{
+		ID:        id,
+		Action:    req.Action,
+		Filter:    req.Filter,
+		Status:    models.DLQJobStatusPending,
+		CreatedAt: time.Now(),
+	}
+	if req.Action == models.DLQJobActionReplay {
+		job.RatePerSecond = req.RatePerSecond
+	}
+	if claims, ok := r.Context().Value("user").(*models.JWTClaims); ok {
+		job.CreatedBy = claims.Username
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := h.redisClient.SaveDLQJob(ctx, job); err != nil {
+		slog.ErrorContext(r.Context(), "Failed to create DLQ job", "error", err)
+		sendErrorResponse(w, http.StatusInternalServerError, err.(*models.RelayError))
+		return
+	}
+
+	h.dlqJobs.Submit(job)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(job)
+
+	slog.InfoContext(r.Context(), "DLQ job created", "job_id", job.ID, "action", job.Action)
+}
+
+// HandleListDLQJobs handles requests to list bulk DLQ jobs
+func (h *Handler) HandleListDLQJobs(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		sendErrorResponse(w, http.StatusMethodNotAllowed, models.NewRelayError(
+			models.ErrCodeInvalidRequest,
+			"method not allowed",
+			nil,
+		))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	jobs, err := h.redisClient.ListDLQJobs(ctx)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to list DLQ jobs", "error", err)
+		sendErrorResponse(w, http.StatusInternalServerError, err.(*models.RelayError))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"jobs":  jobs,
+		"count": len(jobs),
+	})
+}
+
+// HandleGetDLQJob handles requests to get the progress of a bulk DLQ job
+func (h *Handler) HandleGetDLQJob(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		sendErrorResponse(w, http.StatusMethodNotAllowed, models.NewRelayError(
+			models.ErrCodeInvalidRequest,
+			"method not allowed",
+			nil,
+		))
+		return
+	}
+
+	// Extract ID from URL path
+	id := r.URL.Path[len("/api/dlq/jobs/"):]
+	if id == "" {
+		sendErrorResponse(w, http.StatusBadRequest, models.NewRelayError(
+			models.ErrCodeInvalidRequest,
+			"missing job ID",
+			nil,
+		))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	job, err := h.redisClient.GetDLQJob(ctx, id)
+	if err != nil {
+		sendErrorResponse(w, http.StatusNotFound, err.(*models.RelayError))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(job)
+}
+
+// HandleCancelDLQJob handles requests to cancel a running bulk DLQ job
+func (h *Handler) HandleCancelDLQJob(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		sendErrorResponse(w, http.StatusMethodNotAllowed, models.NewRelayError(
+			models.ErrCodeInvalidRequest,
+			"method not allowed",
+			nil,
+		))
+		return
+	}
+
+	// Extract ID from URL path (/api/dlq/jobs/{id}/cancel)
+	id := strings.TrimSuffix(r.URL.Path[len("/api/dlq/jobs/"):], "/cancel")
+	if id == "" || strings.Contains(id, "/") {
+		sendErrorResponse(w, http.StatusBadRequest, models.NewRelayError(
+			models.ErrCodeInvalidRequest,
+			"missing job ID",
+			nil,
+		))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	job, err := h.redisClient.GetDLQJob(ctx, id)
+	if err != nil {
+		sendErrorResponse(w, http.StatusNotFound, err.(*models.RelayError))
+		return
+	}
+
+	if job.Status != models.DLQJobStatusPending && job.Status != models.DLQJobStatusRunning {
+		sendErrorResponse(w, http.StatusConflict, models.NewRelayError(
+			models.ErrCodeInvalidRequest,
+			"job is already "+job.Status,
+			nil,
+		))
+		return
+	}
+
+	if err := h.redisClient.CancelDLQJob(ctx, id); err != nil {
+		slog.ErrorContext(r.Context(), "Failed to cancel DLQ job", "error", err)
+		sendErrorResponse(w, http.StatusInternalServerError, err.(*models.RelayError))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "Cancellation requested",
+		"job_id":  id,
+	})
+
+	slog.InfoContext(r.Context(), "DLQ job cancellation requested", "job_id", id)
 }
 
 // Metrics endpoints
@@ -410,24 +1058,35 @@ func (h *Handler) HandleGetMetrics(w http.ResponseWriter, r *http.Request) {
 	// Get queue depth
 	queueDepth, err := h.redisClient.GetQueueDepth(ctx)
 	if err != nil {
-		log.Printf("Failed to get queue depth: %v", err)
+		slog.ErrorContext(r.Context(), "Failed to get queue depth", "error", err)
 	}
 
 	// Get pending messages
 	pendingMessages, err := h.redisClient.GetPendingMessages(ctx)
 	if err != nil {
-		log.Printf("Failed to get pending messages: %v", err)
+		slog.ErrorContext(r.Context(), "Failed to get pending messages", "error", err)
+	}
+
+	// Get scheduled retries
+	scheduledRetries, err := h.redisClient.GetScheduledRetries(ctx)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to get scheduled retries", "error", err)
 	}
 
+	// average_latency_ms is kept for existing dashboards and reports the 5 minute mean
+	forward := h.prom.ForwardSummary()
 	metrics := map[string]interface{}{
 		"webhooks_received":  atomic.LoadInt64(&h.metrics.WebhooksReceived),
 		"webhooks_processed": atomic.LoadInt64(&h.metrics.WebhooksProcessed),
 		"webhooks_failed":    atomic.LoadInt64(&h.metrics.WebhooksFailed),
 		"webhooks_retried":   atomic.LoadInt64(&h.metrics.WebhooksRetried),
+		"messages_reclaimed": atomic.LoadInt64(&h.metrics.MessagesReclaimed),
 		"queue_depth":        queueDepth,
 		"pending_messages":   pendingMessages,
-		"average_latency_ms": atomic.LoadInt64(&h.metrics.AverageLatency),
-		"last_webhook_time":  h.metrics.LastWebhookTime,
+		"scheduled_retries":  scheduledRetries,
+		"average_latency_ms": forward.Mean("5m"),
+		"last_webhook_time":  forward.LastObserved,
+		"forward":            forward,
 		"config": map[string]interface{}{
 			"local_webhook_url": h.config.LocalWebhookURL,
 			"max_retries":       h.config.MaxRetries,
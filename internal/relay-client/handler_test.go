@@ -0,0 +1,49 @@
+package relayclient
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/QuantumSolver/crm-relay/internal/models"
+)
+
+func TestHandleCreateDLQJobRejectsInvalidRate(t *testing.T) {
+	h := &Handler{config: &models.Config{DLQReplayRate: 50}}
+
+	for _, body := range []string{
+		`{"action":"replay","rate_per_second":-1}`,
+		`{"action":"replay","rate_per_second":1001}`,
+		`{"action":"replay","rate_per_second":2000000000}`,
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/api/dlq/jobs", strings.NewReader(body))
+		rec := httptest.NewRecorder()
+
+		h.HandleCreateDLQJob(rec, req)
+
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status 400, got %d", body, rec.Code)
+		}
+	}
+}
+
+func TestValidateRouteMethod(t *testing.T) {
+	tests := []struct {
+		method string
+		valid  bool
+	}{
+		{"", true},
+		{"POST", true},
+		{"PATCH", true},
+		{"TRACE", false},
+		{"POST\r\nX-Injected: 1", false},
+	}
+
+	for _, tt := range tests {
+		route := &models.Route{Platform: "meta", URL: "http://localhost/hook", HTTPMethod: tt.method}
+		if err := validateRoute(route); (err == nil) != tt.valid {
+			t.Errorf("method %q: expected valid %v, got %v", tt.method, tt.valid, err)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package relayclient
+
+import (
+	"hash/fnv"
+	"sync"
+)
+
+// workerPool runs submitted jobs on a fixed number of goroutines. Jobs submitted
+// with the same non-empty key always run on the same worker, so they execute in
+// submission order; jobs without a key go to whichever worker is free first.
+type workerPool struct {
+	lanes  []chan func()
+	shared chan func()
+	wg     sync.WaitGroup
+}
+
+// newWorkerPool starts a pool with the given number of workers
+func newWorkerPool(workers int) *workerPool {
+	if workers < 1 {
+		workers = 1
+	}
+
+	p := &workerPool{
+		lanes:  make([]chan func(), workers),
+		shared: make(chan func()),
+	}
+
+	for i := range p.lanes {
+		p.lanes[i] = make(chan func())
+		p.wg.Add(1)
+		go p.work(p.lanes[i])
+	}
+
+	return p
+}
+
+// work runs jobs from the worker's own lane and the shared queue until both are closed
+func (p *workerPool) work(lane chan func()) {
+	defer p.wg.Done()
+
+	shared := p.shared
+	for lane != nil || shared != nil {
+		select {
+		case job, ok := <-lane:
+			if !ok {
+				lane = nil
+				continue
+			}
+			job()
+		case job, ok := <-shared:
+			if !ok {
+				shared = nil
+				continue
+			}
+			job()
+		}
+	}
+}
+
+// Submit hands a job to the pool, blocking while every eligible worker is busy
+func (p *workerPool) Submit(key string, job func()) {
+	if key == "" {
+		p.shared <- job
+		return
+	}
+
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	p.lanes[h.Sum32()%uint32(len(p.lanes))] <- job
+}
+
+// Close stops accepting jobs and waits for in-flight jobs to finish
+func (p *workerPool) Close() {
+	close(p.shared)
+	for _, lane := range p.lanes {
+		close(lane)
+	}
+	p.wg.Wait()
+}
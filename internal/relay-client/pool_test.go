@@ -0,0 +1,86 @@
+package relayclient
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWorkerPoolPreservesOrderPerKey(t *testing.T) {
+	pool := newWorkerPool(4)
+
+	var mu sync.Mutex
+	seen := make(map[string][]int)
+
+	for i := 0; i < 50; i++ {
+		for _, key := range []string{"whatsapp", "instagram", "messenger"} {
+			key, i := key, i
+			pool.Submit(key, func() {
+				mu.Lock()
+				seen[key] = append(seen[key], i)
+				mu.Unlock()
+			})
+		}
+	}
+	pool.Close()
+
+	for key, order := range seen {
+		if len(order) != 50 {
+			t.Fatalf("Expected 50 jobs for %s, got %d", key, len(order))
+		}
+		for i, v := range order {
+			if v != i {
+				t.Fatalf("Expected jobs for %s to run in order, got %v", key, order)
+			}
+		}
+	}
+}
+
+func TestWorkerPoolRunsUnkeyedJobsConcurrently(t *testing.T) {
+	pool := newWorkerPool(3)
+
+	var running, peak int32
+	release := make(chan struct{})
+	for i := 0; i < 3; i++ {
+		pool.Submit("", func() {
+			n := atomic.AddInt32(&running, 1)
+			for {
+				p := atomic.LoadInt32(&peak)
+				if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+					break
+				}
+			}
+			<-release
+			atomic.AddInt32(&running, -1)
+		})
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for atomic.LoadInt32(&peak) < 3 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+	pool.Close()
+
+	if peak := atomic.LoadInt32(&peak); peak != 3 {
+		t.Errorf("Expected 3 jobs to run concurrently, got %d", peak)
+	}
+}
+
+func TestWorkerPoolCloseWaitsForInFlightJobs(t *testing.T) {
+	pool := newWorkerPool(2)
+
+	var done int32
+	for i := 0; i < 4; i++ {
+		pool.Submit("", func() {
+			time.Sleep(10 * time.Millisecond)
+			atomic.AddInt32(&done, 1)
+		})
+	}
+	pool.Close()
+
+	if n := atomic.LoadInt32(&done); n != 4 {
+		t.Errorf("Expected all 4 jobs to finish before Close returned, got %d", n)
+	}
+}
@@ -0,0 +1,108 @@
+package relayclient
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"github.com/QuantumSolver/crm-relay/internal/logging"
+	"github.com/QuantumSolver/crm-relay/internal/models"
+	"github.com/QuantumSolver/crm-relay/internal/storage"
+)
+
+// reclaimBatchSize bounds how many pending entries are inspected per reclaim
+const reclaimBatchSize = 10
+
+// reclaimLoop periodically recovers entries left in the pending list by consumers
+// that crashed or restarted before acknowledging them
+func (c *Consumer) reclaimLoop(ctx context.Context) {
+	slog.Info("Pending entry recovery started",
+		"interval_s", c.config.ReclaimInterval,
+		"min_idle_s", c.config.ReclaimMinIdle,
+		"max_deliveries", c.config.ReclaimMaxDeliveries,
+	)
+
+	ticker := time.NewTicker(time.Duration(c.config.ReclaimInterval) * time.Second)
+	defer ticker.Stop()
+
+	cursor := "0-0"
+	for c.running.Load() {
+		select {
+		case <-ctx.Done():
+			return
+		case <-c.stop:
+			return
+		case <-ticker.C:
+			cursor = c.reclaimStaleMessages(ctx, cursor)
+		}
+	}
+}
+
+// reclaimStaleMessages claims and re-processes one batch of stale entries and
+// returns the cursor to continue from
+func (c *Consumer) reclaimStaleMessages(ctx context.Context, cursor string) string {
+	minIdle := time.Duration(c.config.ReclaimMinIdle) * time.Second
+
+	// This consumer's own entries also go idle while they wait in the pool or are
+	// slow to forward; they are not stale, so they are never claimed
+	claimed, next, err := c.queue.ClaimStaleMessages(ctx, cursor, minIdle, reclaimBatchSize, c.isInFlight)
+	if err != nil {
+		slog.Error("Error reclaiming stale messages", "error", err)
+		return cursor
+	}
+
+	if len(claimed) == 0 {
+		return next
+	}
+
+	slog.Info("Reclaimed stale messages from pending list", "count", len(claimed))
+
+	for _, entry := range claimed {
+		if !c.running.Load() {
+			break
+		}
+
+		atomic.AddInt64(&c.metrics.MessagesReclaimed, 1)
+		c.prom.IncReclaimed()
+
+		if entry.DeliveryCount > int64(c.config.ReclaimMaxDeliveries) {
+			c.deadLetterReclaimed(ctx, entry)
+			continue
+		}
+
+		c.dispatch(ctx, entry.Message)
+	}
+
+	return next
+}
+
+// deadLetterReclaimed moves an entry that keeps getting redelivered to the DLQ
+func (c *Consumer) deadLetterReclaimed(ctx context.Context, entry storage.ClaimedMessage) {
+	relayMessage, err := storage.ParseMessage(entry.Message)
+	if err != nil {
+		// An unparseable entry can never be forwarded, so stop it cycling through the PEL
+		slog.Error("Discarding unparseable message",
+			"message_id", entry.Message.ID, "deliveries", entry.DeliveryCount, "error", err)
+		if ackErr := c.queue.AcknowledgeMessage(ctx, entry.Message.ID); ackErr != nil {
+			slog.Error("Failed to acknowledge message", "message_id", entry.Message.ID, "error", ackErr)
+		}
+		return
+	}
+
+	attrs := logging.MessageAttrs(entry.Message.ID, relayMessage)
+	slog.Warn("Message exceeded max deliveries, moving to DLQ",
+		append(attrs, "deliveries", entry.DeliveryCount, "max_deliveries", c.config.ReclaimMaxDeliveries)...)
+	atomic.AddInt64(&c.metrics.WebhooksFailed, 1)
+	c.prom.IncDeadLettered(relayMessage.Webhook.Platform, relayMessage.Webhook.EndpointID, deadLetterMaxDeliveries)
+
+	cause := models.NewRelayError(
+		models.ErrCodeMaxRetriesExceeded,
+		fmt.Sprintf("message was delivered %d times without being acknowledged", entry.DeliveryCount),
+		nil,
+	)
+	if err := c.queue.MoveToDeadLetterQueue(ctx, entry.Message.ID, relayMessage, cause); err != nil {
+		slog.Error("Failed to move message to DLQ", append(attrs, "error", err)...)
+	}
+}
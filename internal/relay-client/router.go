@@ -0,0 +1,226 @@
+package relayclient
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/QuantumSolver/crm-relay/internal/models"
+	"github.com/QuantumSolver/crm-relay/internal/storage"
+)
+
+// routeRefreshInterval controls how often routes are reloaded so that changes made
+// through another client replica are picked up
+const routeRefreshInterval = 30 * time.Second
+
+// ResolvedRoute is the concrete destination for a single forward attempt
+type ResolvedRoute struct {
+	URL     string
+	Method  string
+	Headers map[string]string
+	// SigningSecret signs the forwarded request; empty disables signing
+	SigningSecret string
+}
+
+// Router maps relay messages to local services using the routing table stored in Redis
+type Router struct {
+	redisClient *storage.RedisClient
+	config      *models.Config
+	endpoints   *endpointCache
+	mu          sync.RWMutex
+	byEndpoint  map[string]*models.Route
+	byPlatform  map[string]*models.Route
+}
+
+// NewRouter creates a new router
+func NewRouter(redisClient *storage.RedisClient, config *models.Config) *Router {
+	return &Router{
+		redisClient: redisClient,
+		config:      config,
+		endpoints:   newEndpointCache(redisClient),
+		byEndpoint:  make(map[string]*models.Route),
+		byPlatform:  make(map[string]*models.Route),
+	}
+}
+
+// Start periodically reloads the routing table until the context is cancelled
+func (rt *Router) Start(ctx context.Context) {
+	ticker := time.NewTicker(routeRefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := rt.Reload(ctx); err != nil {
+				slog.Error("Failed to reload routes", "error", err)
+			}
+		}
+	}
+}
+
+// Reload replaces the in-memory routing table with the routes stored in Redis
+func (rt *Router) Reload(ctx context.Context) error {
+	routes, err := rt.redisClient.ListRoutes(ctx)
+	if err != nil {
+		return err
+	}
+
+	byEndpoint, byPlatform := buildRouteTables(routes)
+
+	rt.mu.Lock()
+	rt.byEndpoint = byEndpoint
+	rt.byPlatform = byPlatform
+	rt.mu.Unlock()
+
+	return nil
+}
+
+// buildRouteTables indexes routes by endpoint ID and by platform. Routes sharing a
+// match key are rejected by the API, but if any are stored the oldest wins, so every
+// reload and every replica forwards the same way.
+func buildRouteTables(routes []*models.Route) (map[string]*models.Route, map[string]*models.Route) {
+	byEndpoint := make(map[string]*models.Route)
+	byPlatform := make(map[string]*models.Route)
+	for _, route := range routes {
+		table, key := byPlatform, route.Platform
+		if route.EndpointID != "" {
+			table, key = byEndpoint, route.EndpointID
+		}
+		if key == "" {
+			continue
+		}
+
+		if current, ok := table[key]; ok {
+			slog.Warn("Duplicate route ignored", "match", key, "route_id", current.ID, "duplicate_id", route.ID)
+			if !routeOlder(route, current) {
+				continue
+			}
+		}
+		table[key] = route
+	}
+	return byEndpoint, byPlatform
+}
+
+// IsKnownURL reports whether url is LocalWebhookURL or the URL of a loaded route,
+// the only destinations a replay may be sent to
+func (rt *Router) IsKnownURL(url string) bool {
+	if url == rt.config.LocalWebhookURL {
+		return true
+	}
+
+	rt.mu.RLock()
+	defer rt.mu.RUnlock()
+	for _, table := range []map[string]*models.Route{rt.byEndpoint, rt.byPlatform} {
+		for _, route := range table {
+			if route.URL == url {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// routeOlder orders routes by creation time, then by ID
+func routeOlder(a, b *models.Route) bool {
+	if !a.CreatedAt.Equal(b.CreatedAt) {
+		return a.CreatedAt.Before(b.CreatedAt)
+	}
+	return a.ID < b.ID
+}
+
+// conflictingRoute returns a route other than route that is matched on the same
+// endpoint ID or, for platform routes, the same platform
+func conflictingRoute(routes []*models.Route, route *models.Route) *models.Route {
+	for _, other := range routes {
+		if other.ID == route.ID {
+			continue
+		}
+		if route.EndpointID != "" && other.EndpointID == route.EndpointID {
+			return other
+		}
+		if route.EndpointID == "" && other.EndpointID == "" && other.Platform == route.Platform {
+			return other
+		}
+	}
+	return nil
+}
+
+// Endpoint returns the cached server-side configuration for an endpoint ID, or nil if unknown
+func (rt *Router) Endpoint(ctx context.Context, id string) (*models.WebhookEndpoint, error) {
+	return rt.endpoints.Get(ctx, id)
+}
+
+// Resolve picks the destination for a message. An explicit target endpoint wins,
+// then a route for the webhook's endpoint ID, then a route for its platform, and
+// finally the default LocalWebhookURL.
+func (rt *Router) Resolve(ctx context.Context, message *models.RelayMessage) ResolvedRoute {
+	webhook := &message.Webhook
+
+	// A replay sent to an explicit target carries none of the route or endpoint
+	// headers and secrets, which belong to the destination they were configured for
+	if message.TargetEndpoint != "" {
+		resolved := ResolvedRoute{
+			URL:           message.TargetEndpoint,
+			Method:        strings.ToUpper(webhook.HTTPMethod),
+			Headers:       make(map[string]string),
+			SigningSecret: rt.config.ForwardSigningSecret,
+		}
+		if resolved.Method == "" {
+			resolved.Method = http.MethodPost
+		}
+		return resolved
+	}
+
+	rt.mu.RLock()
+	route, ok := rt.byEndpoint[webhook.EndpointID]
+	if !ok {
+		route = rt.byPlatform[webhook.Platform]
+	}
+	rt.mu.RUnlock()
+
+	resolved := ResolvedRoute{
+		URL:           rt.config.LocalWebhookURL,
+		Method:        webhook.HTTPMethod,
+		Headers:       make(map[string]string),
+		SigningSecret: rt.config.ForwardSigningSecret,
+	}
+
+	// Extra headers configured on the server-side endpoint
+	endpoint, err := rt.Endpoint(ctx, webhook.EndpointID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to load endpoint for routing", "endpoint_id", webhook.EndpointID, "error", err)
+	}
+	if endpoint != nil {
+		for key, value := range endpoint.Headers {
+			resolved.Headers[key] = value
+		}
+		if resolved.Method == "" {
+			resolved.Method = endpoint.HTTPMethod
+		}
+	}
+
+	if route != nil {
+		resolved.URL = route.URL
+		if route.HTTPMethod != "" {
+			resolved.Method = route.HTTPMethod
+		}
+		for key, value := range route.Headers {
+			resolved.Headers[key] = value
+		}
+		if route.SigningSecret != "" {
+			resolved.SigningSecret = route.SigningSecret
+		}
+	}
+
+	resolved.Method = strings.ToUpper(resolved.Method)
+	if resolved.Method == "" {
+		resolved.Method = http.MethodPost
+	}
+
+	return resolved
+}
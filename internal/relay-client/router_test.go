@@ -0,0 +1,156 @@
+package relayclient
+
+import (
+	"context"
+	"maps"
+	"testing"
+	"time"
+
+	"github.com/QuantumSolver/crm-relay/internal/models"
+)
+
+func TestResolve(t *testing.T) {
+	config := &models.Config{LocalWebhookURL: "http://localhost/default", ForwardSigningSecret: "global-secret"}
+	router := newTestRouter(config,
+		&models.WebhookEndpoint{
+			ID:         "ep-1",
+			HTTPMethod: "put",
+			Headers:    map[string]string{"X-Tenant": "endpoint", "X-Source": "endpoint"},
+		},
+		&models.WebhookEndpoint{ID: "ep-2"},
+	)
+	router.byEndpoint["ep-1"] = &models.Route{
+		EndpointID:    "ep-1",
+		URL:           "http://localhost/endpoint-route",
+		Headers:       map[string]string{"X-Tenant": "route"},
+		SigningSecret: "route-secret",
+	}
+	router.byEndpoint["ep-2"] = &models.Route{EndpointID: "ep-2", URL: "http://localhost/other-route", HTTPMethod: "patch"}
+	router.byPlatform["meta"] = &models.Route{Platform: "meta", URL: "http://localhost/platform-route"}
+
+	tests := []struct {
+		name    string
+		message models.RelayMessage
+		want    ResolvedRoute
+	}{
+		{
+			name:    "default",
+			message: models.RelayMessage{Webhook: models.Webhook{Platform: "slack"}},
+			want:    ResolvedRoute{URL: "http://localhost/default", Method: "POST", Headers: map[string]string{}, SigningSecret: "global-secret"},
+		},
+		{
+			name:    "platform route",
+			message: models.RelayMessage{Webhook: models.Webhook{Platform: "meta", HTTPMethod: "get"}},
+			want:    ResolvedRoute{URL: "http://localhost/platform-route", Method: "GET", Headers: map[string]string{}, SigningSecret: "global-secret"},
+		},
+		{
+			name:    "endpoint route beats platform route",
+			message: models.RelayMessage{Webhook: models.Webhook{Platform: "meta", EndpointID: "ep-2", HTTPMethod: "POST"}},
+			want:    ResolvedRoute{URL: "http://localhost/other-route", Method: "PATCH", Headers: map[string]string{}, SigningSecret: "global-secret"},
+		},
+		{
+			name:    "route headers override endpoint headers",
+			message: models.RelayMessage{Webhook: models.Webhook{Platform: "meta", EndpointID: "ep-1"}},
+			want: ResolvedRoute{
+				URL:           "http://localhost/endpoint-route",
+				Method:        "PUT",
+				Headers:       map[string]string{"X-Tenant": "route", "X-Source": "endpoint"},
+				SigningSecret: "route-secret",
+			},
+		},
+		{
+			name:    "target endpoint drops route and endpoint headers and secrets",
+			message: models.RelayMessage{Webhook: models.Webhook{Platform: "meta", EndpointID: "ep-1", HTTPMethod: "post"}, TargetEndpoint: "http://localhost/target"},
+			want: ResolvedRoute{
+				URL:           "http://localhost/target",
+				Method:        "POST",
+				Headers:       map[string]string{},
+				SigningSecret: "global-secret",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		got := router.Resolve(context.Background(), &tt.message)
+		if got.URL != tt.want.URL || got.Method != tt.want.Method || got.SigningSecret != tt.want.SigningSecret {
+			t.Errorf("%s: expected %s %s (secret %q), got %s %s (secret %q)",
+				tt.name, tt.want.Method, tt.want.URL, tt.want.SigningSecret, got.Method, got.URL, got.SigningSecret)
+		}
+		if !maps.Equal(got.Headers, tt.want.Headers) {
+			t.Errorf("%s: expected headers %v, got %v", tt.name, tt.want.Headers, got.Headers)
+		}
+	}
+}
+
+func TestIsKnownURL(t *testing.T) {
+	router := newTestRouter(&models.Config{LocalWebhookURL: "http://localhost/default"})
+	router.byEndpoint["ep-1"] = &models.Route{EndpointID: "ep-1", URL: "http://localhost/endpoint-route"}
+	router.byPlatform["meta"] = &models.Route{Platform: "meta", URL: "http://localhost/platform-route"}
+
+	for url, want := range map[string]bool{
+		"http://localhost/default":        true,
+		"http://localhost/endpoint-route": true,
+		"http://localhost/platform-route": true,
+		"http://attacker.example.com/":    false,
+		"http://localhost/default/extra":  false,
+	} {
+		if got := router.IsKnownURL(url); got != want {
+			t.Errorf("IsKnownURL(%q) = %v, want %v", url, got, want)
+		}
+	}
+}
+
+func TestBuildRouteTablesPrefersOldestDuplicate(t *testing.T) {
+	now := time.Now()
+	routes := []*models.Route{
+		{ID: "newer", Platform: "meta", URL: "http://localhost/newer", CreatedAt: now},
+		{ID: "older", Platform: "meta", URL: "http://localhost/older", CreatedAt: now.Add(-time.Hour)},
+		{ID: "b", EndpointID: "ep-1", URL: "http://localhost/b", CreatedAt: now},
+		{ID: "a", EndpointID: "ep-1", URL: "http://localhost/a", CreatedAt: now},
+	}
+
+	// The scan order must not matter
+	for _, order := range [][]int{{0, 1, 2, 3}, {3, 2, 1, 0}} {
+		shuffled := make([]*models.Route, 0, len(routes))
+		for _, i := range order {
+			shuffled = append(shuffled, routes[i])
+		}
+
+		byEndpoint, byPlatform := buildRouteTables(shuffled)
+		if got := byPlatform["meta"].ID; got != "older" {
+			t.Errorf("order %v: expected the older platform route, got %s", order, got)
+		}
+		if got := byEndpoint["ep-1"].ID; got != "a" {
+			t.Errorf("order %v: expected the endpoint route with the lower ID, got %s", order, got)
+		}
+	}
+}
+
+func TestConflictingRoute(t *testing.T) {
+	routes := []*models.Route{
+		{ID: "r1", Platform: "meta"},
+		{ID: "r2", EndpointID: "ep-1", Platform: "meta"},
+	}
+
+	tests := []struct {
+		name  string
+		route *models.Route
+		want  string
+	}{
+		{"same platform", &models.Route{ID: "new", Platform: "meta"}, "r1"},
+		{"same endpoint", &models.Route{ID: "new", EndpointID: "ep-1"}, "r2"},
+		{"other platform", &models.Route{ID: "new", Platform: "stripe"}, ""},
+		{"endpoint route on a routed platform", &models.Route{ID: "new", EndpointID: "ep-2", Platform: "meta"}, ""},
+		{"updating itself", &models.Route{ID: "r1", Platform: "meta"}, ""},
+	}
+
+	for _, tt := range tests {
+		got := ""
+		if other := conflictingRoute(routes, tt.route); other != nil {
+			got = other.ID
+		}
+		if got != tt.want {
+			t.Errorf("%s: expected conflict %q, got %q", tt.name, tt.want, got)
+		}
+	}
+}
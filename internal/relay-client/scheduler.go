@@ -0,0 +1,77 @@
+package relayclient
+
+import (
+	"context"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"github.com/QuantumSolver/crm-relay/internal/models"
+)
+
+// retryPromoteBatch bounds how many due retries are promoted per Redis round trip
+const retryPromoteBatch = 100
+
+// RetryQueue holds delayed retries until they are due
+type RetryQueue interface {
+	PromoteDueRetries(ctx context.Context, now time.Time, limit int64) (int64, error)
+}
+
+// RetryScheduler promotes delayed retries back into the main stream once they are due
+type RetryScheduler struct {
+	queue   RetryQueue
+	config  *models.Config
+	running atomic.Bool
+}
+
+// NewRetryScheduler creates a new retry scheduler
+func NewRetryScheduler(queue RetryQueue, config *models.Config) *RetryScheduler {
+	return &RetryScheduler{
+		queue:  queue,
+		config: config,
+	}
+}
+
+// Start polls the retry queue until the context is cancelled or Stop is called
+func (s *RetryScheduler) Start(ctx context.Context) {
+	s.running.Store(true)
+	slog.Info("Retry scheduler started", "queue", s.config.RetryQueue, "interval_ms", s.config.RetryPollInterval)
+
+	ticker := time.NewTicker(time.Duration(s.config.RetryPollInterval) * time.Millisecond)
+	defer ticker.Stop()
+
+	for s.running.Load() {
+		select {
+		case <-ctx.Done():
+			slog.Info("Retry scheduler stopping due to context cancellation")
+			return
+		case <-ticker.C:
+			s.promoteDueRetries(ctx)
+		}
+	}
+}
+
+// Stop stops the retry scheduler
+func (s *RetryScheduler) Stop() {
+	s.running.Store(false)
+	slog.Info("Retry scheduler stopped")
+}
+
+// promoteDueRetries drains all retries that are currently due
+func (s *RetryScheduler) promoteDueRetries(ctx context.Context) {
+	for s.running.Load() {
+		promoted, err := s.queue.PromoteDueRetries(ctx, time.Now(), retryPromoteBatch)
+		if err != nil {
+			slog.Error("Failed to promote due retries", "error", err)
+			return
+		}
+
+		if promoted > 0 {
+			slog.Info("Promoted due retries to stream", "count", promoted)
+		}
+
+		if promoted < retryPromoteBatch {
+			return
+		}
+	}
+}
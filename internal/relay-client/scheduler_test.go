@@ -0,0 +1,44 @@
+package relayclient
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/QuantumSolver/crm-relay/internal/models"
+)
+
+func TestRetrySchedulerPromotesDueRetries(t *testing.T) {
+	queue := newMemoryQueue()
+	now := time.Now()
+
+	// More due retries than one batch, so the scheduler has to drain several
+	due := retryPromoteBatch + 5
+	for i := range due {
+		queue.retries = append(queue.retries, scheduledRetry{
+			message: &models.RelayMessage{Webhook: models.Webhook{ID: fmt.Sprintf("due-%d", i)}},
+			retryAt: now.Add(-time.Duration(i) * time.Millisecond),
+		})
+	}
+	queue.retries = append(queue.retries, scheduledRetry{
+		message: &models.RelayMessage{Webhook: models.Webhook{ID: "later"}},
+		retryAt: now.Add(time.Hour),
+	})
+
+	scheduler := NewRetryScheduler(queue, &models.Config{})
+	scheduler.running.Store(true)
+	scheduler.promoteDueRetries(context.Background())
+
+	if len(queue.stream) != due {
+		t.Errorf("Expected %d retries promoted, got %d", due, len(queue.stream))
+	}
+	for _, message := range queue.stream {
+		if message.Webhook.ID == "later" {
+			t.Error("Expected the retry that is not yet due to stay queued")
+		}
+	}
+	if len(queue.retries) != 1 || queue.retries[0].message.Webhook.ID != "later" {
+		t.Errorf("Expected only the later retry to remain, got %d", len(queue.retries))
+	}
+}
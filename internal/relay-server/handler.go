@@ -2,35 +2,57 @@ package relayserver
 
 import (
 	"context"
+	"crypto/subtle"
 	"encoding/json"
 	"io"
-	"log"
+	"log/slog"
 	"net/http"
 	"strings"
 	"sync/atomic"
 	"time"
 
+	"github.com/QuantumSolver/crm-relay/internal/adminapi"
 	"github.com/QuantumSolver/crm-relay/internal/auth"
+	"github.com/QuantumSolver/crm-relay/internal/logging"
+	"github.com/QuantumSolver/crm-relay/internal/metrics"
 	"github.com/QuantumSolver/crm-relay/internal/models"
 	"github.com/QuantumSolver/crm-relay/internal/storage"
+	"github.com/QuantumSolver/crm-relay/internal/tracing"
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// WebhookStore is the storage the webhook ingest path uses
+type WebhookStore interface {
+	GetEndpointByPath(ctx context.Context, path string) (*models.WebhookEndpoint, error)
+	GetAPIKeyByValue(ctx context.Context, key string) (*models.APIKey, error)
+	ReserveIdempotencyKey(ctx context.Context, scope, key, webhookID string, ttl time.Duration) (*models.IdempotencyRecord, error)
+	CompleteIdempotencyKey(ctx context.Context, scope, key string, record *models.IdempotencyRecord) error
+	ReleaseIdempotencyKey(ctx context.Context, scope, key string) error
+	AddWebhook(ctx context.Context, webhook *models.Webhook) (string, error)
+}
+
 // Handler handles HTTP requests for the relay server
 type Handler struct {
+	*adminapi.Handler
 	redisClient *storage.RedisClient
+	webhooks    WebhookStore
 	config      *models.Config
 	metrics     *models.Metrics
-	jwtService  *auth.JWTService
+	prom        *metrics.ServerMetrics
 }
 
 // NewHandler creates a new handler
-func NewHandler(redisClient *storage.RedisClient, config *models.Config, jwtService *auth.JWTService) *Handler {
+func NewHandler(redisClient *storage.RedisClient, config *models.Config, jwtService *auth.JWTService, prom *metrics.ServerMetrics) *Handler {
 	return &Handler{
+		Handler:     adminapi.NewHandler(redisClient, config, jwtService),
 		redisClient: redisClient,
+		webhooks:    redisClient,
 		config:      config,
 		metrics:     &models.Metrics{},
-		jwtService:  jwtService,
+		prom:        prom,
 	}
 }
 
@@ -54,9 +76,61 @@ func (h *Handler) HandleWebhook(w http.ResponseWriter, r *http.Request) {
 		platform = strings.TrimPrefix(r.URL.Path, "/webhook/")
 	}
 
-	// Validate API key
+	// Trace the ingest; the span context is stored on the message so the client's
+	// consume and forward spans join the same trace
+	spanCtx, span := tracing.Start(logging.WithRequestID(context.Background(), logging.RequestID(r.Context())), "webhook.ingest", trace.WithSpanKind(trace.SpanKindServer))
+
+	// Record every webhook with the outcome of its last return path
+	var endpoint *models.WebhookEndpoint
+	outcome := metrics.OutcomeInvalid
+	defer func() {
+		h.observeWebhook(platform, endpoint, outcome, time.Since(start))
+		h.endIngestSpan(span, platform, endpoint, outcome)
+	}()
+
+	// Get endpoint configuration if platform is specified
+	if platform != "" {
+		ctx, cancel := context.WithTimeout(spanCtx, 5*time.Second)
+		defer cancel()
+
+		// Only a missing endpoint means an unregistered platform; failing open on a
+		// lookup error would skip signature and idempotency checks
+		found, err := h.webhooks.GetEndpointByPath(ctx, "/webhook/"+platform)
+		if err != nil && !isNotFound(err) {
+			slog.ErrorContext(spanCtx, "Failed to look up endpoint", "platform", platform, "error", err)
+			outcome = metrics.OutcomeError
+			sendErrorResponse(w, http.StatusServiceUnavailable, models.NewRelayError(
+				models.ErrCodeRedisConnection,
+				"failed to look up endpoint",
+				nil,
+			))
+			return
+		}
+		endpoint = found
+	}
+
+	// Resolve the signature verifier configured on the endpoint
+	var verifier SignatureVerifier
+	if endpoint != nil {
+		v, err := endpointVerifier(endpoint)
+		if err != nil {
+			slog.ErrorContext(spanCtx, "Invalid signature configuration", "platform", platform, "endpoint_id", endpoint.ID, "error", err)
+			outcome = metrics.OutcomeError
+			sendErrorResponse(w, http.StatusInternalServerError, models.NewRelayError(
+				models.ErrCodeInvalidConfig,
+				"endpoint signature configuration is invalid",
+				nil,
+			))
+			return
+		}
+		verifier = v
+	}
+
+	// Validate API key. Platforms that cannot send custom headers (Stripe, GitHub,
+	// Shopify, Slack, Meta) authenticate through their signature instead.
 	apiKey := r.Header.Get("X-API-Key")
-	if apiKey == "" {
+	if apiKey == "" && verifier == nil {
+		outcome = metrics.OutcomeRejected
 		sendErrorResponse(w, http.StatusUnauthorized, models.NewRelayError(
 			models.ErrCodeAuthentication,
 			"missing API key",
@@ -66,12 +140,13 @@ func (h *Handler) HandleWebhook(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// If platform is specified, validate API key against platform
-	if platform != "" {
-		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	if apiKey != "" && platform != "" {
+		ctx, cancel := context.WithTimeout(spanCtx, 5*time.Second)
 		defer cancel()
 
-		storedKey, err := h.redisClient.GetAPIKeyByValue(ctx, apiKey)
+		storedKey, err := h.webhooks.GetAPIKeyByValue(ctx, apiKey)
 		if err != nil || !storedKey.IsActive || storedKey.Platform != platform {
+			outcome = metrics.OutcomeRejected
 			sendErrorResponse(w, http.StatusUnauthorized, models.NewRelayError(
 				models.ErrCodeAuthentication,
 				"invalid API key for platform",
@@ -79,8 +154,9 @@ func (h *Handler) HandleWebhook(w http.ResponseWriter, r *http.Request) {
 			))
 			return
 		}
-	} else if apiKey != h.config.APIKey {
+	} else if apiKey != "" && apiKey != h.config.APIKey {
 		// Fallback to legacy API key validation
+		outcome = metrics.OutcomeRejected
 		sendErrorResponse(w, http.StatusUnauthorized, models.NewRelayError(
 			models.ErrCodeAuthentication,
 			"invalid API key",
@@ -111,6 +187,21 @@ func (h *Handler) HandleWebhook(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Verify the platform signature before accepting the payload
+	if verifier != nil {
+		if err := verifier.Verify(r.Header, body, time.Now()); err != nil {
+			atomic.AddInt64(&h.metrics.SignatureFailures, 1)
+			outcome = metrics.OutcomeRejected
+			slog.WarnContext(spanCtx, "Rejected webhook with invalid signature", "platform", platform, "endpoint_id", endpoint.ID, "error", err)
+			sendErrorResponse(w, http.StatusUnauthorized, models.NewRelayError(
+				models.ErrCodeInvalidSignature,
+				"invalid webhook signature",
+				err,
+			))
+			return
+		}
+	}
+
 	// Collect headers
 	headers := make(map[string]string)
 	for key, values := range r.Header {
@@ -119,20 +210,18 @@ func (h *Handler) HandleWebhook(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	// Get endpoint configuration if platform is specified
+	// Routing metadata; the target endpoint will be set by the client
 	var endpointID string
 	var httpMethod string
+	if endpoint != nil {
+		endpointID = endpoint.ID
+		httpMethod = endpoint.HTTPMethod
+	}
 
-	if platform != "" {
-		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-		defer cancel()
-
-		endpoint, err := h.redisClient.GetEndpointByPath(ctx, "/webhook/"+platform)
-		if err == nil {
-			endpointID = endpoint.ID
-			httpMethod = endpoint.HTTPMethod
-			// Target endpoint will be set by the client based on routing metadata
-		}
+	// Prefer the SHA-256 signature when the platform sends both
+	signature := r.Header.Get("X-Hub-Signature-256")
+	if signature == "" {
+		signature = r.Header.Get("X-Hub-Signature")
 	}
 
 	// Create webhook
@@ -141,28 +230,85 @@ func (h *Handler) HandleWebhook(w http.ResponseWriter, r *http.Request) {
 		Headers:    headers,
 		Body:       body,
 		Timestamp:  time.Now(),
-		Signature:  r.Header.Get("X-Hub-Signature"),
+		Signature:  signature,
 		Platform:   platform,
 		EndpointID: endpointID,
 		HTTPMethod: httpMethod,
 	}
+	span.SetAttributes(attribute.String("relay.webhook_id", webhook.ID))
 
-	// Add to Redis stream
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(spanCtx, 5*time.Second)
 	defer cancel()
 
-	messageID, err := h.redisClient.AddWebhook(ctx, webhook)
+	// Drop duplicate deliveries before they reach the stream
+	if endpoint != nil && endpoint.Idempotency != nil {
+		key, err := deriveIdempotencyKey(*endpoint.Idempotency, r.Header, body)
+		if err != nil {
+			slog.WarnContext(ctx, "Failed to derive idempotency key", append(logging.WebhookAttrs(webhook), "error", err)...)
+		}
+		webhook.IdempotencyKey = key
+	}
+
+	if webhook.IdempotencyKey != "" {
+		ttl := time.Duration(h.config.IdempotencyTTL) * time.Second
+		if endpoint.Idempotency.TTL > 0 {
+			ttl = time.Duration(endpoint.Idempotency.TTL) * time.Second
+		}
+
+		original, err := h.webhooks.ReserveIdempotencyKey(ctx, endpoint.ID, webhook.IdempotencyKey, webhook.ID, ttl)
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to reserve idempotency key", append(logging.WebhookAttrs(webhook), "error", err)...)
+			outcome = metrics.OutcomeError
+			sendErrorResponse(w, http.StatusInternalServerError, err.(*models.RelayError))
+			return
+		}
+
+		if original != nil {
+			atomic.AddInt64(&h.metrics.WebhooksDuplicate, 1)
+			outcome = metrics.OutcomeDuplicate
+
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusOK)
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"success":    true,
+				"duplicate":  true,
+				"message_id": original.MessageID,
+				"webhook_id": original.WebhookID,
+				"platform":   platform,
+			})
+
+			slog.InfoContext(ctx, "Duplicate webhook dropped",
+				append(logging.WebhookAttrs(webhook), "original_webhook_id", original.WebhookID, "message_id", original.MessageID)...)
+			return
+		}
+	}
+
+	// Add to Redis stream
+	messageID, err := h.webhooks.AddWebhook(ctx, webhook)
 	if err != nil {
-		log.Printf("Failed to add webhook to stream: %v", err)
+		slog.ErrorContext(ctx, "Failed to add webhook to stream", append(logging.WebhookAttrs(webhook), "error", err)...)
+		outcome = metrics.OutcomeError
+		if webhook.IdempotencyKey != "" {
+			// Let the platform's retry go through
+			if err := h.webhooks.ReleaseIdempotencyKey(ctx, endpoint.ID, webhook.IdempotencyKey); err != nil {
+				slog.ErrorContext(ctx, "Failed to release idempotency key", append(logging.WebhookAttrs(webhook), "error", err)...)
+			}
+		}
 		sendErrorResponse(w, http.StatusInternalServerError, err.(*models.RelayError))
 		return
 	}
 
+	if webhook.IdempotencyKey != "" {
+		record := &models.IdempotencyRecord{WebhookID: webhook.ID, MessageID: messageID}
+		if err := h.webhooks.CompleteIdempotencyKey(ctx, endpoint.ID, webhook.IdempotencyKey, record); err != nil {
+			slog.ErrorContext(ctx, "Failed to record idempotency key", append(logging.WebhookAttrs(webhook), "error", err)...)
+		}
+	}
+
 	// Update metrics
+	outcome = metrics.OutcomeAccepted
 	atomic.AddInt64(&h.metrics.WebhooksReceived, 1)
-	h.metrics.LastWebhookTime = time.Now()
 	latency := time.Since(start).Milliseconds()
-	atomic.StoreInt64(&h.metrics.AverageLatency, latency)
 
 	// Send success response
 	w.Header().Set("Content-Type", "application/json")
@@ -175,7 +321,71 @@ func (h *Handler) HandleWebhook(w http.ResponseWriter, r *http.Request) {
 		"timestamp":  webhook.Timestamp,
 	})
 
-	log.Printf("Webhook received and queued: ID=%s, MessageID=%s, Platform=%s, Latency=%dms", webhook.ID, messageID, platform, latency)
+	slog.InfoContext(spanCtx, "Webhook received and queued",
+		append(logging.WebhookAttrs(webhook), "message_id", messageID, "latency_ms", latency)...)
+}
+
+// isNotFound reports whether a storage error means the record does not exist
+func isNotFound(err error) bool {
+	relayErr, ok := err.(*models.RelayError)
+	return ok && relayErr.Code == models.ErrCodeInvalidRequest
+}
+
+// HandleWebhookVerification answers Meta's subscription handshake by echoing
+// hub.challenge when hub.verify_token matches the endpoint's verify token
+func (h *Handler) HandleWebhookVerification(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		sendErrorResponse(w, http.StatusMethodNotAllowed, models.NewRelayError(
+			models.ErrCodeInvalidRequest,
+			"method not allowed",
+			nil,
+		))
+		return
+	}
+
+	query := r.URL.Query()
+	mode := query.Get("hub.mode")
+	token := query.Get("hub.verify_token")
+	challenge := query.Get("hub.challenge")
+
+	if mode != "subscribe" || token == "" || challenge == "" {
+		sendErrorResponse(w, http.StatusBadRequest, models.NewRelayError(
+			models.ErrCodeInvalidRequest,
+			"invalid verification request",
+			nil,
+		))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	endpoint, err := h.webhooks.GetEndpointByPath(ctx, r.URL.Path)
+	if err != nil && !isNotFound(err) {
+		slog.ErrorContext(r.Context(), "Failed to look up endpoint", "path", r.URL.Path, "error", err)
+		sendErrorResponse(w, http.StatusServiceUnavailable, models.NewRelayError(
+			models.ErrCodeRedisConnection,
+			"failed to look up endpoint",
+			nil,
+		))
+		return
+	}
+	if err != nil || endpoint.VerifyToken == "" ||
+		subtle.ConstantTimeCompare([]byte(token), []byte(endpoint.VerifyToken)) != 1 {
+		slog.WarnContext(r.Context(), "Rejected webhook verification", "path", r.URL.Path)
+		sendErrorResponse(w, http.StatusForbidden, models.NewRelayError(
+			models.ErrCodeAuthentication,
+			"verification token mismatch",
+			nil,
+		))
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain")
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(challenge))
+
+	slog.InfoContext(r.Context(), "Webhook verification succeeded", "path", r.URL.Path, "endpoint_id", endpoint.ID)
 }
 
 // HandleHealth handles health check requests
@@ -191,6 +401,7 @@ func (h *Handler) HandleHealth(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Prepare health response
+	ingest := h.prom.IngestSummary()
 	health := map[string]interface{}{
 		"status":    "healthy",
 		"timestamp": time.Now(),
@@ -203,8 +414,10 @@ func (h *Handler) HandleHealth(w http.ResponseWriter, r *http.Request) {
 			"webhooks_processed": atomic.LoadInt64(&h.metrics.WebhooksProcessed),
 			"webhooks_failed":    atomic.LoadInt64(&h.metrics.WebhooksFailed),
 			"webhooks_retried":   atomic.LoadInt64(&h.metrics.WebhooksRetried),
-			"average_latency_ms": atomic.LoadInt64(&h.metrics.AverageLatency),
-			"last_webhook_time":  h.metrics.LastWebhookTime,
+			"signature_failures": atomic.LoadInt64(&h.metrics.SignatureFailures),
+			"webhooks_duplicate": atomic.LoadInt64(&h.metrics.WebhooksDuplicate),
+			"average_latency_ms": ingest.Mean("5m"),
+			"last_webhook_time":  ingest.LastObserved,
 		},
 	}
 
@@ -224,117 +437,32 @@ func (h *Handler) GetMetrics() *models.Metrics {
 	return h.metrics
 }
 
-// Auth endpoints
-
-// HandleLogin handles login requests
-func (h *Handler) HandleLogin(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		sendErrorResponse(w, http.StatusMethodNotAllowed, models.NewRelayError(
-			models.ErrCodeInvalidRequest,
-			"method not allowed",
-			nil,
-		))
-		return
-	}
-
-	var loginReq models.LoginRequest
-	if err := json.NewDecoder(r.Body).Decode(&loginReq); err != nil {
-		sendErrorResponse(w, http.StatusBadRequest, models.NewRelayError(
-			models.ErrCodeInvalidRequest,
-			"invalid request body",
-			err,
-		))
-		return
-	}
-
-	// Validate credentials
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-
-	user, err := h.redisClient.GetUser(ctx, loginReq.Username)
-	if err != nil {
-		sendErrorResponse(w, http.StatusUnauthorized, models.NewRelayError(
-			models.ErrCodeAuthentication,
-			"invalid credentials",
-			nil,
-		))
-		return
+// endIngestSpan annotates the ingest span with the routing and outcome of a webhook and ends it
+func (h *Handler) endIngestSpan(span trace.Span, platform string, endpoint *models.WebhookEndpoint, outcome string) {
+	span.SetAttributes(
+		attribute.String("relay.platform", platform),
+		attribute.String("relay.outcome", outcome),
+	)
+	if endpoint != nil {
+		span.SetAttributes(attribute.String("relay.endpoint_id", endpoint.ID))
 	}
-
-	// Verify password
-	if !auth.VerifyPassword(loginReq.Password, user.PasswordHash) {
-		sendErrorResponse(w, http.StatusUnauthorized, models.NewRelayError(
-			models.ErrCodeAuthentication,
-			"invalid credentials",
-			nil,
-		))
-		return
+	if outcome == metrics.OutcomeError {
+		span.SetStatus(codes.Error, "webhook could not be enqueued")
 	}
-
-	// Generate JWT token
-	token, expiresAt, err := h.jwtService.GenerateToken(user)
-	if err != nil {
-		log.Printf("Failed to generate token: %v", err)
-		sendErrorResponse(w, http.StatusInternalServerError, models.NewRelayError(
-			models.ErrCodeAuthentication,
-			"failed to generate token",
-			err,
-		))
-		return
-	}
-
-	// Send response
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(models.LoginResponse{
-		Token:     token,
-		User:      *user,
-		ExpiresAt: expiresAt,
-	})
-
-	log.Printf("User logged in: %s", user.Username)
+	span.End()
 }
 
-// HandleGetCurrentUser handles requests to get the current user
-func (h *Handler) HandleGetCurrentUser(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		sendErrorResponse(w, http.StatusMethodNotAllowed, models.NewRelayError(
-			models.ErrCodeInvalidRequest,
-			"method not allowed",
-			nil,
-		))
-		return
-	}
-
-	// Get user from context
-	claims, ok := r.Context().Value("user").(*models.JWTClaims)
-	if !ok {
-		sendErrorResponse(w, http.StatusUnauthorized, models.NewRelayError(
-			models.ErrCodeAuthentication,
-			"user not authenticated",
-			nil,
-		))
-		return
+// observeWebhook records an inbound webhook in the Prometheus metrics. Paths that
+// do not match a registered endpoint share one label so they cannot blow up cardinality.
+func (h *Handler) observeWebhook(platform string, endpoint *models.WebhookEndpoint, outcome string, duration time.Duration) {
+	endpointID := ""
+	if endpoint != nil {
+		endpointID = endpoint.ID
+	} else if platform != "" {
+		platform = "unregistered"
 	}
 
-	// Get user from storage
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-
-	user, err := h.redisClient.GetUser(ctx, claims.Username)
-	if err != nil {
-		sendErrorResponse(w, http.StatusNotFound, models.NewRelayError(
-			models.ErrCodeInvalidRequest,
-			"user not found",
-			err,
-		))
-		return
-	}
-
-	// Send response
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(user)
+	h.prom.ObserveWebhook(platform, endpointID, outcome, duration)
 }
 
 // API Key management endpoints
@@ -355,7 +483,7 @@ func (h *Handler) HandleListAPIKeys(w http.ResponseWriter, r *http.Request) {
 
 	apiKeys, err := h.redisClient.ListAPIKeys(ctx)
 	if err != nil {
-		log.Printf("Failed to list API keys: %v", err)
+		slog.ErrorContext(r.Context(), "Failed to list API keys", "error", err)
 		sendErrorResponse(w, http.StatusInternalServerError, err.(*models.RelayError))
 		return
 	}
@@ -395,7 +523,7 @@ func (h *Handler) HandleCreateAPIKey(w http.ResponseWriter, r *http.Request) {
 	// Generate API key
 	key, err := auth.GenerateAPIKey()
 	if err != nil {
-		log.Printf("Failed to generate API key: %v", err)
+		slog.ErrorContext(r.Context(), "Failed to generate API key", "error", err)
 		sendErrorResponse(w, http.StatusInternalServerError, models.NewRelayError(
 			models.ErrCodeInvalidRequest,
 			"failed to generate API key",
@@ -407,7 +535,7 @@ func (h *Handler) HandleCreateAPIKey(w http.ResponseWriter, r *http.Request) {
 	// Generate ID
 	id, err := auth.GenerateID()
 	if err != nil {
-		log.Printf("Failed to generate ID: %v", err)
+		slog.ErrorContext(r.Context(), "Failed to generate ID", "error", err)
 		sendErrorResponse(w, http.StatusInternalServerError, models.NewRelayError(
 			models.ErrCodeInvalidRequest,
 			"failed to generate ID",
@@ -430,7 +558,7 @@ func (h *Handler) HandleCreateAPIKey(w http.ResponseWriter, r *http.Request) {
 	defer cancel()
 
 	if err := h.redisClient.CreateAPIKey(ctx, apiKey); err != nil {
-		log.Printf("Failed to create API key: %v", err)
+		slog.ErrorContext(r.Context(), "Failed to create API key", "error", err)
 		sendErrorResponse(w, http.StatusInternalServerError, err.(*models.RelayError))
 		return
 	}
@@ -439,7 +567,7 @@ func (h *Handler) HandleCreateAPIKey(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(apiKey)
 
-	log.Printf("API key created: %s for platform %s", apiKey.Name, apiKey.Platform)
+	slog.InfoContext(r.Context(), "API key created", "api_key_id", apiKey.ID, "name", apiKey.Name, "platform", apiKey.Platform)
 }
 
 // HandleUpdateAPIKey handles requests to update an API key
@@ -496,7 +624,7 @@ func (h *Handler) HandleUpdateAPIKey(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := h.redisClient.UpdateAPIKey(ctx, apiKey); err != nil {
-		log.Printf("Failed to update API key: %v", err)
+		slog.ErrorContext(r.Context(), "Failed to update API key", "error", err)
 		sendErrorResponse(w, http.StatusInternalServerError, err.(*models.RelayError))
 		return
 	}
@@ -505,7 +633,7 @@ func (h *Handler) HandleUpdateAPIKey(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(apiKey)
 
-	log.Printf("API key updated: %s", apiKey.ID)
+	slog.InfoContext(r.Context(), "API key updated", "api_key_id", apiKey.ID)
 }
 
 // HandleDeleteAPIKey handles requests to delete an API key
@@ -534,7 +662,7 @@ func (h *Handler) HandleDeleteAPIKey(w http.ResponseWriter, r *http.Request) {
 	defer cancel()
 
 	if err := h.redisClient.DeleteAPIKey(ctx, id); err != nil {
-		log.Printf("Failed to delete API key: %v", err)
+		slog.ErrorContext(r.Context(), "Failed to delete API key", "error", err)
 		sendErrorResponse(w, http.StatusInternalServerError, err.(*models.RelayError))
 		return
 	}
@@ -546,7 +674,7 @@ func (h *Handler) HandleDeleteAPIKey(w http.ResponseWriter, r *http.Request) {
 		"message": "API key deleted successfully",
 	})
 
-	log.Printf("API key deleted: %s", id)
+	slog.InfoContext(r.Context(), "API key deleted", "api_key_id", id)
 }
 
 // Webhook Endpoint management endpoints
@@ -567,15 +695,20 @@ func (h *Handler) HandleListEndpoints(w http.ResponseWriter, r *http.Request) {
 
 	endpoints, err := h.redisClient.ListEndpoints(ctx)
 	if err != nil {
-		log.Printf("Failed to list endpoints: %v", err)
+		slog.ErrorContext(r.Context(), "Failed to list endpoints", "error", err)
 		sendErrorResponse(w, http.StatusInternalServerError, err.(*models.RelayError))
 		return
 	}
 
+	infos := make([]models.WebhookEndpointInfo, 0, len(endpoints))
+	for _, endpoint := range endpoints {
+		infos = append(infos, endpoint.Info())
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"endpoints": endpoints,
+		"endpoints": infos,
 	})
 }
 
@@ -591,10 +724,15 @@ func (h *Handler) HandleCreateEndpoint(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var req struct {
-		Platform   string            `json:"platform"`
-		Path       string            `json:"path"`
-		HTTPMethod string            `json:"http_method"`
-		Headers    map[string]string `json:"headers"`
+		Platform    string                    `json:"platform"`
+		Path        string                    `json:"path"`
+		HTTPMethod  string                    `json:"http_method"`
+		Headers     map[string]string         `json:"headers"`
+		RetryConfig *models.RetryConfig       `json:"retry_config"`
+		AppSecret   string                    `json:"app_secret"`
+		VerifyToken string                    `json:"verify_token"`
+		Signature   *models.SignatureConfig   `json:"signature"`
+		Idempotency *models.IdempotencyConfig `json:"idempotency"`
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -609,7 +747,7 @@ func (h *Handler) HandleCreateEndpoint(w http.ResponseWriter, r *http.Request) {
 	// Generate ID
 	id, err := auth.GenerateID()
 	if err != nil {
-		log.Printf("Failed to generate ID: %v", err)
+		slog.ErrorContext(r.Context(), "Failed to generate ID", "error", err)
 		sendErrorResponse(w, http.StatusInternalServerError, models.NewRelayError(
 			models.ErrCodeInvalidRequest,
 			"failed to generate ID",
@@ -618,35 +756,68 @@ func (h *Handler) HandleCreateEndpoint(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Without an override the retry config stays zero, so the client applies the
+	// global policy as it is configured when the webhook is delivered
+	var retryConfig models.RetryConfig
+	if req.RetryConfig != nil {
+		if err := validateRetryConfig(*req.RetryConfig); err != nil {
+			sendErrorResponse(w, http.StatusBadRequest, err)
+			return
+		}
+		retryConfig = *req.RetryConfig
+	}
+
+	if req.Signature != nil {
+		if _, err := NewSignatureVerifier(*req.Signature); err != nil {
+			sendErrorResponse(w, http.StatusBadRequest, models.NewRelayError(
+				models.ErrCodeInvalidRequest,
+				"invalid signature configuration",
+				err,
+			))
+			return
+		}
+	}
+
+	if req.Idempotency != nil {
+		if err := validateIdempotencyConfig(*req.Idempotency); err != nil {
+			sendErrorResponse(w, http.StatusBadRequest, models.NewRelayError(
+				models.ErrCodeInvalidRequest,
+				"invalid idempotency configuration",
+				err,
+			))
+			return
+		}
+	}
+
 	endpoint := &models.WebhookEndpoint{
-		ID:         id,
-		Platform:   req.Platform,
-		Path:       req.Path,
-		HTTPMethod: req.HTTPMethod,
-		Headers:    req.Headers,
-		RetryConfig: models.RetryConfig{
-			MaxRetries:      h.config.MaxRetries,
-			RetryDelay:      h.config.RetryDelay,
-			RetryMultiplier: h.config.RetryMultiplier,
-		},
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
+		ID:          id,
+		Platform:    req.Platform,
+		Path:        req.Path,
+		HTTPMethod:  req.HTTPMethod,
+		Headers:     req.Headers,
+		RetryConfig: retryConfig,
+		AppSecret:   req.AppSecret,
+		VerifyToken: req.VerifyToken,
+		Signature:   req.Signature,
+		Idempotency: req.Idempotency,
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
 	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
 	if err := h.redisClient.CreateEndpoint(ctx, endpoint); err != nil {
-		log.Printf("Failed to create endpoint: %v", err)
+		slog.ErrorContext(r.Context(), "Failed to create endpoint", "error", err)
 		sendErrorResponse(w, http.StatusInternalServerError, err.(*models.RelayError))
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(endpoint)
+	json.NewEncoder(w).Encode(endpoint.Info())
 
-	log.Printf("Webhook endpoint created: %s for platform %s", endpoint.Path, endpoint.Platform)
+	slog.InfoContext(r.Context(), "Webhook endpoint created", "endpoint_id", endpoint.ID, "path", endpoint.Path, "platform", endpoint.Platform)
 }
 
 // HandleUpdateEndpoint handles requests to update a webhook endpoint
@@ -660,13 +831,7 @@ func (h *Handler) HandleUpdateEndpoint(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var req struct {
-		Platform   *string            `json:"platform"`
-		Path       *string            `json:"path"`
-		HTTPMethod *string            `json:"http_method"`
-		Headers    *map[string]string `json:"headers"`
-	}
-
+	var req endpointUpdate
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		sendErrorResponse(w, http.StatusBadRequest, models.NewRelayError(
 			models.ErrCodeInvalidRequest,
@@ -696,7 +861,40 @@ func (h *Handler) HandleUpdateEndpoint(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Update fields
+	if err := applyEndpointUpdate(endpoint, req); err != nil {
+		sendErrorResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := h.redisClient.UpdateEndpoint(ctx, endpoint); err != nil {
+		slog.ErrorContext(r.Context(), "Failed to update endpoint", "error", err)
+		sendErrorResponse(w, http.StatusInternalServerError, err.(*models.RelayError))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(endpoint.Info())
+
+	slog.InfoContext(r.Context(), "Webhook endpoint updated", "endpoint_id", endpoint.ID)
+}
+
+// endpointUpdate is a partial endpoint update; absent and null fields are left unchanged
+type endpointUpdate struct {
+	Platform    *string                   `json:"platform"`
+	Path        *string                   `json:"path"`
+	HTTPMethod  *string                   `json:"http_method"`
+	Headers     *map[string]string        `json:"headers"`
+	RetryConfig *models.RetryConfig       `json:"retry_config"`
+	AppSecret   *string                   `json:"app_secret"`
+	VerifyToken *string                   `json:"verify_token"`
+	Signature   *models.SignatureConfig   `json:"signature"`
+	Idempotency *models.IdempotencyConfig `json:"idempotency"`
+}
+
+// applyEndpointUpdate applies an update to an endpoint. A signature with an empty
+// scheme or an idempotency config with an empty source removes that setting.
+func applyEndpointUpdate(endpoint *models.WebhookEndpoint, req endpointUpdate) *models.RelayError {
 	if req.Platform != nil {
 		endpoint.Platform = *req.Platform
 	}
@@ -709,18 +907,54 @@ func (h *Handler) HandleUpdateEndpoint(w http.ResponseWriter, r *http.Request) {
 	if req.Headers != nil {
 		endpoint.Headers = *req.Headers
 	}
-
-	if err := h.redisClient.UpdateEndpoint(ctx, endpoint); err != nil {
-		log.Printf("Failed to update endpoint: %v", err)
-		sendErrorResponse(w, http.StatusInternalServerError, err.(*models.RelayError))
-		return
+	if req.RetryConfig != nil {
+		if err := validateRetryConfig(*req.RetryConfig); err != nil {
+			return err
+		}
+		endpoint.RetryConfig = *req.RetryConfig
+	}
+	if req.AppSecret != nil {
+		endpoint.AppSecret = *req.AppSecret
+	}
+	if req.VerifyToken != nil {
+		endpoint.VerifyToken = *req.VerifyToken
+	}
+	switch {
+	case req.Signature == nil:
+	case req.Signature.Scheme == "":
+		// An empty scheme removes signature verification
+		endpoint.Signature = nil
+	default:
+		// The secret is write-only, so an update that omits it keeps the stored one
+		if req.Signature.Secret == "" && endpoint.Signature != nil {
+			req.Signature.Secret = endpoint.Signature.Secret
+		}
+		if _, err := NewSignatureVerifier(*req.Signature); err != nil {
+			return models.NewRelayError(
+				models.ErrCodeInvalidRequest,
+				"invalid signature configuration",
+				err,
+			)
+		}
+		endpoint.Signature = req.Signature
+	}
+	switch {
+	case req.Idempotency == nil:
+	case req.Idempotency.Source == "":
+		// An empty source turns deduplication off
+		endpoint.Idempotency = nil
+	default:
+		if err := validateIdempotencyConfig(*req.Idempotency); err != nil {
+			return models.NewRelayError(
+				models.ErrCodeInvalidRequest,
+				"invalid idempotency configuration",
+				err,
+			)
+		}
+		endpoint.Idempotency = req.Idempotency
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(endpoint)
-
-	log.Printf("Webhook endpoint updated: %s", endpoint.ID)
+	return nil
 }
 
 // HandleDeleteEndpoint handles requests to delete a webhook endpoint
@@ -749,7 +983,7 @@ func (h *Handler) HandleDeleteEndpoint(w http.ResponseWriter, r *http.Request) {
 	defer cancel()
 
 	if err := h.redisClient.DeleteEndpoint(ctx, id); err != nil {
-		log.Printf("Failed to delete endpoint: %v", err)
+		slog.ErrorContext(r.Context(), "Failed to delete endpoint", "error", err)
 		sendErrorResponse(w, http.StatusInternalServerError, err.(*models.RelayError))
 		return
 	}
@@ -761,7 +995,19 @@ func (h *Handler) HandleDeleteEndpoint(w http.ResponseWriter, r *http.Request) {
 		"message": "Endpoint deleted successfully",
 	})
 
-	log.Printf("Webhook endpoint deleted: %s", id)
+	slog.InfoContext(r.Context(), "Webhook endpoint deleted", "endpoint_id", id)
+}
+
+// validateRetryConfig checks an endpoint retry policy
+func validateRetryConfig(rc models.RetryConfig) *models.RelayError {
+	if rc.MaxRetries < 0 || rc.RetryDelay < 0 || rc.RetryMultiplier <= 0 {
+		return models.NewRelayError(
+			models.ErrCodeInvalidRequest,
+			"invalid retry_config: max_retries and retry_delay must be non-negative and retry_multiplier positive",
+			nil,
+		)
+	}
+	return nil
 }
 
 // Metrics and monitoring endpoints
@@ -783,24 +1029,29 @@ func (h *Handler) HandleGetMetrics(w http.ResponseWriter, r *http.Request) {
 	// Get queue depth
 	queueDepth, err := h.redisClient.GetQueueDepth(ctx)
 	if err != nil {
-		log.Printf("Failed to get queue depth: %v", err)
+		slog.ErrorContext(r.Context(), "Failed to get queue depth", "error", err)
 	}
 
 	// Get pending messages
 	pendingMessages, err := h.redisClient.GetPendingMessages(ctx)
 	if err != nil {
-		log.Printf("Failed to get pending messages: %v", err)
+		slog.ErrorContext(r.Context(), "Failed to get pending messages", "error", err)
 	}
 
+	// average_latency_ms is kept for existing dashboards and reports the 5 minute mean
+	ingest := h.prom.IngestSummary()
 	metrics := map[string]interface{}{
 		"webhooks_received":  atomic.LoadInt64(&h.metrics.WebhooksReceived),
 		"webhooks_processed": atomic.LoadInt64(&h.metrics.WebhooksProcessed),
 		"webhooks_failed":    atomic.LoadInt64(&h.metrics.WebhooksFailed),
 		"webhooks_retried":   atomic.LoadInt64(&h.metrics.WebhooksRetried),
+		"signature_failures": atomic.LoadInt64(&h.metrics.SignatureFailures),
+		"webhooks_duplicate": atomic.LoadInt64(&h.metrics.WebhooksDuplicate),
 		"queue_depth":        queueDepth,
 		"pending_messages":   pendingMessages,
-		"average_latency_ms": atomic.LoadInt64(&h.metrics.AverageLatency),
-		"last_webhook_time":  h.metrics.LastWebhookTime,
+		"average_latency_ms": ingest.Mean("5m"),
+		"last_webhook_time":  ingest.LastObserved,
+		"ingest":             ingest,
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -824,7 +1075,7 @@ func (h *Handler) HandleGetQueueDepth(w http.ResponseWriter, r *http.Request) {
 
 	queueDepth, err := h.redisClient.GetQueueDepth(ctx)
 	if err != nil {
-		log.Printf("Failed to get queue depth: %v", err)
+		slog.ErrorContext(r.Context(), "Failed to get queue depth", "error", err)
 		sendErrorResponse(w, http.StatusInternalServerError, err.(*models.RelayError))
 		return
 	}
@@ -852,7 +1103,7 @@ func (h *Handler) HandleGetPendingMessages(w http.ResponseWriter, r *http.Reques
 
 	pendingMessages, err := h.redisClient.GetPendingMessages(ctx)
 	if err != nil {
-		log.Printf("Failed to get pending messages: %v", err)
+		slog.ErrorContext(r.Context(), "Failed to get pending messages", "error", err)
 		sendErrorResponse(w, http.StatusInternalServerError, err.(*models.RelayError))
 		return
 	}
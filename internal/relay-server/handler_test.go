@@ -0,0 +1,231 @@
+package relayserver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/QuantumSolver/crm-relay/internal/metrics"
+	"github.com/QuantumSolver/crm-relay/internal/models"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// memoryWebhookStore is a WebhookStore standing in for Redis
+type memoryWebhookStore struct {
+	endpoints   map[string]*models.WebhookEndpoint // by path
+	apiKeys     map[string]*models.APIKey          // by key value
+	idempotency map[string]models.IdempotencyRecord
+	webhooks    []*models.Webhook
+	addErr      error
+	lookupErr   error
+}
+
+func (s *memoryWebhookStore) GetEndpointByPath(ctx context.Context, path string) (*models.WebhookEndpoint, error) {
+	if s.lookupErr != nil {
+		return nil, s.lookupErr
+	}
+	endpoint, ok := s.endpoints[path]
+	if !ok {
+		return nil, models.NewRelayError(models.ErrCodeInvalidRequest, "endpoint not found", nil)
+	}
+	return endpoint, nil
+}
+
+func (s *memoryWebhookStore) GetAPIKeyByValue(ctx context.Context, key string) (*models.APIKey, error) {
+	apiKey, ok := s.apiKeys[key]
+	if !ok {
+		return nil, models.NewRelayError(models.ErrCodeAuthentication, "API key not found", nil)
+	}
+	return apiKey, nil
+}
+
+func (s *memoryWebhookStore) ReserveIdempotencyKey(ctx context.Context, scope, key, webhookID string, ttl time.Duration) (*models.IdempotencyRecord, error) {
+	if record, ok := s.idempotency[scope+":"+key]; ok {
+		return &record, nil
+	}
+	s.idempotency[scope+":"+key] = models.IdempotencyRecord{WebhookID: webhookID}
+	return nil, nil
+}
+
+func (s *memoryWebhookStore) CompleteIdempotencyKey(ctx context.Context, scope, key string, record *models.IdempotencyRecord) error {
+	if _, ok := s.idempotency[scope+":"+key]; ok {
+		s.idempotency[scope+":"+key] = *record
+	}
+	return nil
+}
+
+func (s *memoryWebhookStore) ReleaseIdempotencyKey(ctx context.Context, scope, key string) error {
+	delete(s.idempotency, scope+":"+key)
+	return nil
+}
+
+func (s *memoryWebhookStore) AddWebhook(ctx context.Context, webhook *models.Webhook) (string, error) {
+	if s.addErr != nil {
+		return "", s.addErr
+	}
+	s.webhooks = append(s.webhooks, webhook)
+	return fmt.Sprintf("%d-0", len(s.webhooks)), nil
+}
+
+// newIdempotentHandler returns a handler for an "acme" endpoint that deduplicates
+// on the X-Event-Id header
+func newIdempotentHandler() (*Handler, *memoryWebhookStore) {
+	store := &memoryWebhookStore{
+		endpoints: map[string]*models.WebhookEndpoint{
+			"/webhook/acme": {
+				ID:          "ep-1",
+				Platform:    "acme",
+				Idempotency: &models.IdempotencyConfig{Source: models.IdempotencySourceHeader, Header: "X-Event-Id"},
+			},
+		},
+		apiKeys:     map[string]*models.APIKey{"acme-key": {Key: "acme-key", Platform: "acme", IsActive: true}},
+		idempotency: make(map[string]models.IdempotencyRecord),
+	}
+	h := &Handler{
+		webhooks: store,
+		config:   &models.Config{IdempotencyTTL: 3600},
+		metrics:  &models.Metrics{},
+		prom:     metrics.NewServerMetrics(prometheus.NewRegistry()),
+	}
+	return h, store
+}
+
+// postWebhook delivers an acme event and decodes the response
+func postWebhook(t *testing.T, h *Handler, eventID string) (int, map[string]interface{}) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/webhook/acme", strings.NewReader(`{"event":"created"}`))
+	req.Header.Set("X-API-Key", "acme-key")
+	req.Header.Set("X-Event-Id", eventID)
+	rec := httptest.NewRecorder()
+	h.HandleWebhook(rec, req)
+
+	var response map[string]interface{}
+	if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	return rec.Code, response
+}
+
+func TestHandleWebhookReturnsOriginalForDuplicates(t *testing.T) {
+	h, store := newIdempotentHandler()
+
+	code, first := postWebhook(t, h, "evt-1")
+	if code != http.StatusAccepted {
+		t.Fatalf("Expected status 202, got %d: %v", code, first)
+	}
+
+	code, duplicate := postWebhook(t, h, "evt-1")
+	if code != http.StatusOK || duplicate["duplicate"] != true {
+		t.Fatalf("Expected a duplicate with status 200, got %d: %v", code, duplicate)
+	}
+	if duplicate["message_id"] != first["message_id"] || duplicate["webhook_id"] != first["webhook_id"] {
+		t.Errorf("Expected the original delivery %v, got %v", first, duplicate)
+	}
+	if len(store.webhooks) != 1 {
+		t.Errorf("Expected one webhook enqueued, got %d", len(store.webhooks))
+	}
+
+	if code, _ := postWebhook(t, h, "evt-2"); code != http.StatusAccepted {
+		t.Errorf("Expected a different event to be accepted, got %d", code)
+	}
+}
+
+func TestHandleWebhookReleasesKeyWhenEnqueueFails(t *testing.T) {
+	h, store := newIdempotentHandler()
+	store.addErr = models.NewRelayError(models.ErrCodeStreamWrite, "failed to add webhook to stream", errors.New("redis unavailable"))
+
+	if code, response := postWebhook(t, h, "evt-1"); code != http.StatusInternalServerError {
+		t.Fatalf("Expected status 500, got %d: %v", code, response)
+	}
+	if len(store.idempotency) != 0 {
+		t.Errorf("Expected the idempotency key to be released, got %v", store.idempotency)
+	}
+
+	// The platform's retry is accepted rather than dropped as a duplicate
+	store.addErr = nil
+	code, response := postWebhook(t, h, "evt-1")
+	if code != http.StatusAccepted || response["duplicate"] == true {
+		t.Errorf("Expected the retry to be accepted, got %d: %v", code, response)
+	}
+	if record := store.idempotency["ep-1:evt-1"]; record.MessageID != response["message_id"] {
+		t.Errorf("Expected the key to record message %v, got %+v", response["message_id"], record)
+	}
+}
+
+func TestHandleWebhookFailsClosedWhenEndpointLookupFails(t *testing.T) {
+	h, store := newIdempotentHandler()
+	store.lookupErr = models.NewRelayError(models.ErrCodeRedisConnection, "failed to lookup endpoint by path", errors.New("i/o timeout"))
+
+	// Without the endpoint its signature and idempotency checks cannot run
+	if code, response := postWebhook(t, h, "evt-1"); code != http.StatusServiceUnavailable {
+		t.Fatalf("Expected status 503, got %d: %v", code, response)
+	}
+	if len(store.webhooks) != 0 {
+		t.Errorf("Expected nothing enqueued, got %d webhooks", len(store.webhooks))
+	}
+
+	// A platform without an endpoint is still accepted with an API key
+	store.lookupErr = nil
+	delete(store.endpoints, "/webhook/acme")
+	if code, response := postWebhook(t, h, "evt-1"); code != http.StatusAccepted {
+		t.Errorf("Expected an unregistered platform to be accepted, got %d: %v", code, response)
+	}
+}
+
+func TestApplyEndpointUpdateSignature(t *testing.T) {
+	stored := func() *models.WebhookEndpoint {
+		return &models.WebhookEndpoint{
+			ID:          "ep-1",
+			Signature:   &models.SignatureConfig{Scheme: models.SignatureSchemeGitHub, Secret: "stored-secret"},
+			Idempotency: &models.IdempotencyConfig{Source: models.IdempotencySourceBodyHash},
+		}
+	}
+
+	decode := func(body string) endpointUpdate {
+		var req endpointUpdate
+		if err := json.Unmarshal([]byte(body), &req); err != nil {
+			t.Fatalf("Failed to decode %s: %v", body, err)
+		}
+		return req
+	}
+
+	// Absent and null both leave the settings unchanged
+	for _, body := range []string{`{}`, `{"signature":null,"idempotency":null}`} {
+		endpoint := stored()
+		if err := applyEndpointUpdate(endpoint, decode(body)); err != nil {
+			t.Fatalf("%s: unexpected error: %v", body, err)
+		}
+		if endpoint.Signature == nil || endpoint.Signature.Secret != "stored-secret" || endpoint.Idempotency == nil {
+			t.Errorf("%s: expected the settings to be kept, got %+v", body, endpoint)
+		}
+	}
+
+	// A new scheme without a secret keeps the stored secret
+	endpoint := stored()
+	if err := applyEndpointUpdate(endpoint, decode(`{"signature":{"scheme":"shopify"}}`)); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if endpoint.Signature.Scheme != models.SignatureSchemeShopify || endpoint.Signature.Secret != "stored-secret" {
+		t.Errorf("Expected shopify with the stored secret, got %+v", endpoint.Signature)
+	}
+
+	// An empty scheme or source clears the setting
+	endpoint = stored()
+	if err := applyEndpointUpdate(endpoint, decode(`{"signature":{"scheme":""},"idempotency":{"source":""}}`)); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if endpoint.Signature != nil || endpoint.Idempotency != nil {
+		t.Errorf("Expected signature and idempotency to be removed, got %+v, %+v", endpoint.Signature, endpoint.Idempotency)
+	}
+
+	endpoint = stored()
+	if err := applyEndpointUpdate(endpoint, decode(`{"signature":{"scheme":"unknown"}}`)); err == nil {
+		t.Error("Expected an unknown scheme to be rejected")
+	}
+}
@@ -0,0 +1,90 @@
+package relayserver
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/QuantumSolver/crm-relay/internal/models"
+)
+
+// validateIdempotencyConfig checks an endpoint deduplication configuration
+func validateIdempotencyConfig(cfg models.IdempotencyConfig) error {
+	switch cfg.Source {
+	case models.IdempotencySourceHeader:
+		if cfg.Header == "" {
+			return errors.New("header source requires a header")
+		}
+	case models.IdempotencySourceJSON:
+		if cfg.JSONPath == "" {
+			return errors.New("json source requires a json_path")
+		}
+	case models.IdempotencySourceBodyHash:
+	default:
+		return fmt.Errorf("unsupported idempotency source %q", cfg.Source)
+	}
+
+	if cfg.TTL < 0 {
+		return errors.New("ttl must be non-negative")
+	}
+
+	return nil
+}
+
+// deriveIdempotencyKey extracts the idempotency key of a request. An empty key
+// means the request carries none and is not deduplicated.
+func deriveIdempotencyKey(cfg models.IdempotencyConfig, header http.Header, body []byte) (string, error) {
+	switch cfg.Source {
+	case models.IdempotencySourceHeader:
+		return header.Get(cfg.Header), nil
+	case models.IdempotencySourceJSON:
+		return lookupJSONPath(body, cfg.JSONPath)
+	case models.IdempotencySourceBodyHash:
+		sum := sha256.Sum256(body)
+		return hex.EncodeToString(sum[:]), nil
+	default:
+		return "", fmt.Errorf("unsupported idempotency source %q", cfg.Source)
+	}
+}
+
+// lookupJSONPath resolves a dot-separated path such as "entry.0.id" in a JSON
+// document. Strings are returned as-is, other values in their JSON encoding.
+func lookupJSONPath(body []byte, path string) (string, error) {
+	var value interface{}
+	if err := json.Unmarshal(body, &value); err != nil {
+		return "", err
+	}
+
+	for _, segment := range strings.Split(path, ".") {
+		switch node := value.(type) {
+		case map[string]interface{}:
+			value = node[segment]
+		case []interface{}:
+			index, err := strconv.Atoi(segment)
+			if err != nil || index < 0 || index >= len(node) {
+				return "", nil
+			}
+			value = node[index]
+		default:
+			return "", nil
+		}
+	}
+
+	switch v := value.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	default:
+		encoded, err := json.Marshal(v)
+		if err != nil {
+			return "", err
+		}
+		return string(encoded), nil
+	}
+}
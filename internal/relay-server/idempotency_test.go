@@ -0,0 +1,66 @@
+package relayserver
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/QuantumSolver/crm-relay/internal/models"
+)
+
+func TestDeriveIdempotencyKey(t *testing.T) {
+	body := []byte(`{"entry":[{"id":"123","changes":[{"value":{"messages":[{"id":"wamid.A"}]}}]}],"count":7}`)
+	header := http.Header{}
+	header.Set("X-GitHub-Delivery", "delivery-1")
+
+	tests := []struct {
+		name string
+		cfg  models.IdempotencyConfig
+		want string
+	}{
+		{"header", models.IdempotencyConfig{Source: models.IdempotencySourceHeader, Header: "X-GitHub-Delivery"}, "delivery-1"},
+		{"missing header", models.IdempotencyConfig{Source: models.IdempotencySourceHeader, Header: "X-Missing"}, ""},
+		{"json string", models.IdempotencyConfig{Source: models.IdempotencySourceJSON, JSONPath: "entry.0.changes.0.value.messages.0.id"}, "wamid.A"},
+		{"json number", models.IdempotencyConfig{Source: models.IdempotencySourceJSON, JSONPath: "count"}, "7"},
+		{"json missing", models.IdempotencyConfig{Source: models.IdempotencySourceJSON, JSONPath: "entry.5.id"}, ""},
+		{"body hash", models.IdempotencyConfig{Source: models.IdempotencySourceBodyHash}, ""},
+	}
+
+	for _, tt := range tests {
+		got, err := deriveIdempotencyKey(tt.cfg, header, body)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.name, err)
+			continue
+		}
+		if tt.cfg.Source == models.IdempotencySourceBodyHash {
+			if len(got) != 64 {
+				t.Errorf("%s: expected a sha256 hex digest, got %q", tt.name, got)
+			}
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s: expected %q, got %q", tt.name, tt.want, got)
+		}
+	}
+
+	if _, err := deriveIdempotencyKey(models.IdempotencyConfig{Source: models.IdempotencySourceJSON, JSONPath: "id"}, header, []byte("not json")); err == nil {
+		t.Error("Expected error for non-JSON body")
+	}
+}
+
+func TestValidateIdempotencyConfig(t *testing.T) {
+	invalid := []models.IdempotencyConfig{
+		{Source: "uuid"},
+		{Source: models.IdempotencySourceHeader},
+		{Source: models.IdempotencySourceJSON},
+		{Source: models.IdempotencySourceBodyHash, TTL: -1},
+	}
+	for _, cfg := range invalid {
+		if err := validateIdempotencyConfig(cfg); err == nil {
+			t.Errorf("Expected error for config %+v", cfg)
+		}
+	}
+
+	if err := validateIdempotencyConfig(models.IdempotencyConfig{Source: models.IdempotencySourceBodyHash}); err != nil {
+		t.Errorf("Expected body_hash config to be valid, got %v", err)
+	}
+}
@@ -1,25 +1,42 @@
 package relayserver
 
 import (
-	"context"
 	"encoding/json"
-	"log"
+	"log/slog"
 	"net/http"
 	"time"
 
 	"github.com/QuantumSolver/crm-relay/internal/auth"
+	"github.com/QuantumSolver/crm-relay/internal/logging"
 	"github.com/QuantumSolver/crm-relay/internal/models"
 )
 
+// RequestIDMiddleware tags each request with an ID, reusing a well-formed X-Request-ID
+// sent by the caller, and echoes it on the response
+func RequestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := logging.NewRequestID(r.Header.Get(logging.RequestIDHeader))
+		w.Header().Set(logging.RequestIDHeader, id)
+		next.ServeHTTP(w, r.WithContext(logging.WithRequestID(r.Context(), id)))
+	})
+}
+
 // LoggingMiddleware logs HTTP requests
 func LoggingMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
-		log.Printf("Started %s %s", r.Method, r.URL.Path)
-
-		next.ServeHTTP(w, r)
-
-		log.Printf("Completed %s %s in %v", r.Method, r.URL.Path, time.Since(start))
+		recorder := &logging.StatusRecorder{ResponseWriter: w}
+
+		next.ServeHTTP(recorder, r)
+
+		slog.InfoContext(r.Context(), "HTTP request",
+			"method", r.Method,
+			"path", r.URL.Path,
+			"status", recorder.Status,
+			"bytes", recorder.Bytes,
+			"duration_ms", time.Since(start).Milliseconds(),
+			"remote_addr", r.RemoteAddr,
+		)
 	})
 }
 
@@ -28,7 +45,7 @@ func RecoveryMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		defer func() {
 			if err := recover(); err != nil {
-				log.Printf("Panic recovered: %v", err)
+				slog.ErrorContext(r.Context(), "Panic recovered", "panic", err, "path", r.URL.Path)
 				http.Error(w, "Internal Server Error", http.StatusInternalServerError)
 			}
 		}()
@@ -75,7 +92,8 @@ func CORSMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
 		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, X-API-Key, Authorization")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, X-API-Key, Authorization, X-Request-ID")
+		w.Header().Set("Access-Control-Expose-Headers", "X-Request-ID")
 
 		if r.Method == "OPTIONS" {
 			w.WriteHeader(http.StatusOK)
@@ -86,61 +104,33 @@ func CORSMiddleware(next http.Handler) http.Handler {
 	})
 }
 
-// JWTMiddleware validates JWT tokens
-func JWTMiddleware(jwtService *auth.JWTService) func(http.Handler) http.Handler {
-	return func(next http.Handler) http.Handler {
-		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			// Skip JWT for health check and login
-			if r.URL.Path == "/health" || r.URL.Path == "/api/auth/login" {
-				next.ServeHTTP(w, r)
-				return
-			}
-
-			// Skip JWT for webhook endpoints (they use API key auth)
-			if r.URL.Path == "/webhook" || (len(r.URL.Path) > 8 && r.URL.Path[:8] == "/webhook/") {
-				next.ServeHTTP(w, r)
-				return
-			}
-
-			// Get token from Authorization header
-			authHeader := r.Header.Get("Authorization")
-			if authHeader == "" {
-				sendErrorResponse(w, http.StatusUnauthorized, models.NewRelayError(
-					models.ErrCodeAuthentication,
-					"missing authorization header",
-					nil,
-				))
-				return
-			}
-
-			// Extract token from "Bearer <token>"
-			if len(authHeader) < 7 || authHeader[:7] != "Bearer " {
-				sendErrorResponse(w, http.StatusUnauthorized, models.NewRelayError(
-					models.ErrCodeAuthentication,
-					"invalid authorization header format",
-					nil,
-				))
-				return
-			}
-
-			tokenString := authHeader[7:]
+// RequirePermission rejects requests from users whose role does not grant permission.
+// It runs after JWTMiddleware, which puts the user's claims on the request context.
+func RequirePermission(permission auth.Permission, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		claims, ok := r.Context().Value("user").(*models.JWTClaims)
+		if !ok {
+			sendErrorResponse(w, http.StatusUnauthorized, models.NewRelayError(
+				models.ErrCodeAuthentication,
+				"user not authenticated",
+				nil,
+			))
+			return
+		}
 
-			// Validate token
-			claims, err := jwtService.ValidateToken(tokenString)
-			if err != nil {
-				sendErrorResponse(w, http.StatusUnauthorized, models.NewRelayError(
-					models.ErrCodeAuthentication,
-					"invalid token",
-					err,
-				))
-				return
-			}
+		if !auth.Allowed(claims.Role, permission) {
+			slog.WarnContext(r.Context(), "Permission denied",
+				"username", claims.Username, "role", claims.Role, "permission", permission)
+			sendErrorResponse(w, http.StatusForbidden, models.NewRelayError(
+				models.ErrCodeForbidden,
+				"insufficient permissions",
+				nil,
+			))
+			return
+		}
 
-			// Add user context to request
-			ctx := context.WithValue(r.Context(), "user", claims)
-			next.ServeHTTP(w, r.WithContext(ctx))
-		})
-	}
+		next.ServeHTTP(w, r)
+	})
 }
 
 // sendErrorResponse sends an error response as JSON
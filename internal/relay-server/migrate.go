@@ -0,0 +1,59 @@
+package relayserver
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/QuantumSolver/crm-relay/internal/models"
+)
+
+// copiedRetryConfigMigration names the migration that clears the retry configs
+// endpoints used to be created with
+const copiedRetryConfigMigration = "endpoint-retry-config"
+
+// MigrationStore is the storage the startup migrations use
+type MigrationStore interface {
+	ListEndpoints(ctx context.Context) ([]*models.WebhookEndpoint, error)
+	ResetEndpointRetryConfig(ctx context.Context, id string, expected models.RetryConfig) (bool, error)
+	IsMigrated(ctx context.Context, name string) (bool, error)
+	MarkMigrated(ctx context.Context, name string) error
+}
+
+// ClearCopiedRetryConfigs resets the retry config of endpoints that were created
+// without an override and so got a copy of the server's global policy. Left in
+// place, that copy overrides the client's own global policy. It runs once per Redis,
+// so overrides set later are kept even if they equal the global policy.
+func ClearCopiedRetryConfigs(ctx context.Context, store MigrationStore, config *models.Config) (int, error) {
+	done, err := store.IsMigrated(ctx, copiedRetryConfigMigration)
+	if err != nil || done {
+		return 0, err
+	}
+
+	copied := models.RetryConfig{
+		MaxRetries:      config.MaxRetries,
+		RetryDelay:      config.RetryDelay,
+		RetryMultiplier: config.RetryMultiplier,
+	}
+
+	endpoints, err := store.ListEndpoints(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	cleared := 0
+	for _, endpoint := range endpoints {
+		if endpoint.RetryConfig != copied {
+			continue
+		}
+		reset, err := store.ResetEndpointRetryConfig(ctx, endpoint.ID, copied)
+		if err != nil {
+			return cleared, err
+		}
+		if reset {
+			slog.InfoContext(ctx, "Cleared copied endpoint retry config", "endpoint_id", endpoint.ID)
+			cleared++
+		}
+	}
+
+	return cleared, store.MarkMigrated(ctx, copiedRetryConfigMigration)
+}
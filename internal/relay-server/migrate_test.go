@@ -0,0 +1,76 @@
+package relayserver
+
+import (
+	"context"
+	"testing"
+
+	"github.com/QuantumSolver/crm-relay/internal/models"
+)
+
+// memoryMigrationStore is a MigrationStore standing in for Redis
+type memoryMigrationStore struct {
+	endpoints map[string]*models.WebhookEndpoint
+	migrated  map[string]bool
+}
+
+func (s *memoryMigrationStore) ListEndpoints(ctx context.Context) ([]*models.WebhookEndpoint, error) {
+	endpoints := make([]*models.WebhookEndpoint, 0, len(s.endpoints))
+	for _, endpoint := range s.endpoints {
+		copied := *endpoint
+		endpoints = append(endpoints, &copied)
+	}
+	return endpoints, nil
+}
+
+func (s *memoryMigrationStore) ResetEndpointRetryConfig(ctx context.Context, id string, expected models.RetryConfig) (bool, error) {
+	endpoint, ok := s.endpoints[id]
+	if !ok || endpoint.RetryConfig != expected {
+		return false, nil
+	}
+	endpoint.RetryConfig = models.RetryConfig{}
+	return true, nil
+}
+
+func (s *memoryMigrationStore) IsMigrated(ctx context.Context, name string) (bool, error) {
+	return s.migrated[name], nil
+}
+
+func (s *memoryMigrationStore) MarkMigrated(ctx context.Context, name string) error {
+	s.migrated[name] = true
+	return nil
+}
+
+func TestClearCopiedRetryConfigs(t *testing.T) {
+	config := &models.Config{MaxRetries: 3, RetryDelay: 1000, RetryMultiplier: 2}
+	copied := models.RetryConfig{MaxRetries: 3, RetryDelay: 1000, RetryMultiplier: 2}
+	override := models.RetryConfig{MaxRetries: 10, RetryDelay: 500, RetryMultiplier: 3}
+
+	store := &memoryMigrationStore{
+		endpoints: map[string]*models.WebhookEndpoint{
+			"copied":   {ID: "copied", RetryConfig: copied},
+			"override": {ID: "override", RetryConfig: override},
+			"unset":    {ID: "unset"},
+		},
+		migrated: make(map[string]bool),
+	}
+
+	cleared, err := ClearCopiedRetryConfigs(context.Background(), store, config)
+	if err != nil {
+		t.Fatalf("Failed to clear retry configs: %v", err)
+	}
+	if cleared != 1 || !store.endpoints["copied"].RetryConfig.IsZero() {
+		t.Errorf("Expected the copied config to be cleared, got %d cleared: %+v", cleared, store.endpoints["copied"].RetryConfig)
+	}
+	if store.endpoints["override"].RetryConfig != override {
+		t.Errorf("Expected the override to be kept, got %+v", store.endpoints["override"].RetryConfig)
+	}
+
+	// An override set after the migration is kept even if it equals the global policy
+	store.endpoints["copied"].RetryConfig = copied
+	if cleared, err := ClearCopiedRetryConfigs(context.Background(), store, config); err != nil || cleared != 0 {
+		t.Errorf("Expected the migration to run once, got %d cleared (%v)", cleared, err)
+	}
+	if store.endpoints["copied"].RetryConfig != copied {
+		t.Errorf("Expected the later override to be kept, got %+v", store.endpoints["copied"].RetryConfig)
+	}
+}
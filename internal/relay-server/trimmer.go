@@ -0,0 +1,79 @@
+package relayserver
+
+import (
+	"context"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"github.com/QuantumSolver/crm-relay/internal/models"
+	"github.com/QuantumSolver/crm-relay/internal/storage"
+)
+
+// StreamTrimmer enforces retention on the main stream and the dead letter queue
+type StreamTrimmer struct {
+	redisClient *storage.RedisClient
+	config      *models.Config
+	running     atomic.Bool
+}
+
+// NewStreamTrimmer creates a new stream trimmer
+func NewStreamTrimmer(redisClient *storage.RedisClient, config *models.Config) *StreamTrimmer {
+	return &StreamTrimmer{
+		redisClient: redisClient,
+		config:      config,
+	}
+}
+
+// Start trims the streams periodically until the context is cancelled or Stop is called
+func (t *StreamTrimmer) Start(ctx context.Context) {
+	t.running.Store(true)
+	slog.Info("Stream trimmer started",
+		"stream", t.config.StreamName,
+		"dlq", t.config.DeadLetterQueue,
+		"interval_s", t.config.TrimInterval,
+	)
+
+	ticker := time.NewTicker(time.Duration(t.config.TrimInterval) * time.Second)
+	defer ticker.Stop()
+
+	for t.running.Load() {
+		select {
+		case <-ctx.Done():
+			slog.Info("Stream trimmer stopping due to context cancellation")
+			return
+		case <-ticker.C:
+			t.trim(ctx)
+		}
+	}
+}
+
+// Stop stops the stream trimmer
+func (t *StreamTrimmer) Stop() {
+	t.running.Store(false)
+	slog.Info("Stream trimmer stopped")
+}
+
+// trim runs one retention pass over both streams
+func (t *StreamTrimmer) trim(ctx context.Context) {
+	now := time.Now()
+
+	trimmed, err := t.redisClient.TrimStream(ctx, now)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to trim stream", "stream", t.config.StreamName, "error", err)
+	} else if trimmed > 0 {
+		slog.InfoContext(ctx, "Trimmed expired entries", "stream", t.config.StreamName, "count", trimmed)
+	}
+
+	expired, dropped, err := t.redisClient.TrimDeadLetterQueue(ctx, now)
+	if dropped > 0 {
+		// These webhooks were never delivered and are now gone
+		slog.WarnContext(ctx, "Dropped dead-lettered webhooks over DLQ_MAX_LEN",
+			"stream", t.config.DeadLetterQueue, "count", dropped, "max_len", t.config.DLQMaxLen)
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to trim dead letter queue", "stream", t.config.DeadLetterQueue, "error", err)
+	} else if expired > 0 {
+		slog.InfoContext(ctx, "Trimmed expired entries", "stream", t.config.DeadLetterQueue, "count", expired)
+	}
+}
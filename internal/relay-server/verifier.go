@@ -0,0 +1,270 @@
+package relayserver
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/QuantumSolver/crm-relay/internal/models"
+)
+
+// defaultSignatureTolerance is the accepted clock skew for timestamped signatures
+const defaultSignatureTolerance = 5 * time.Minute
+
+// Signature verification errors
+var (
+	errSignatureMissing  = errors.New("signature header missing")
+	errSignatureMismatch = errors.New("signature mismatch")
+	errTimestampInvalid  = errors.New("signature timestamp outside tolerance")
+)
+
+// SignatureVerifier verifies the signature a platform attached to a webhook request
+type SignatureVerifier interface {
+	Verify(header http.Header, body []byte, now time.Time) error
+}
+
+// NewSignatureVerifier builds the verifier for a signature configuration
+func NewSignatureVerifier(cfg models.SignatureConfig) (SignatureVerifier, error) {
+	if cfg.Secret == "" {
+		return nil, errors.New("signature secret is required")
+	}
+
+	tolerance := defaultSignatureTolerance
+	if cfg.Tolerance > 0 {
+		tolerance = time.Duration(cfg.Tolerance) * time.Second
+	}
+
+	switch cfg.Scheme {
+	case models.SignatureSchemeMeta, models.SignatureSchemeGitHub:
+		return &hmacVerifier{
+			secret:   []byte(cfg.Secret),
+			header:   "X-Hub-Signature-256",
+			hash:     sha256.New,
+			encoding: "hex",
+			prefix:   "sha256=",
+		}, nil
+	case models.SignatureSchemeShopify:
+		return &hmacVerifier{
+			secret:   []byte(cfg.Secret),
+			header:   "X-Shopify-Hmac-Sha256",
+			hash:     sha256.New,
+			encoding: "base64",
+		}, nil
+	case models.SignatureSchemeStripe:
+		return &stripeVerifier{secret: []byte(cfg.Secret), tolerance: tolerance}, nil
+	case models.SignatureSchemeSlack:
+		return &slackVerifier{secret: []byte(cfg.Secret), tolerance: tolerance}, nil
+	case models.SignatureSchemeHMAC:
+		return newGenericHMACVerifier(cfg)
+	default:
+		return nil, fmt.Errorf("unsupported signature scheme %q", cfg.Scheme)
+	}
+}
+
+// endpointVerifier returns the verifier configured on an endpoint, or nil if it has none.
+// A bare AppSecret is treated as a Meta signature configuration.
+func endpointVerifier(endpoint *models.WebhookEndpoint) (SignatureVerifier, error) {
+	if endpoint.Signature != nil {
+		return NewSignatureVerifier(*endpoint.Signature)
+	}
+
+	if endpoint.AppSecret != "" {
+		return NewSignatureVerifier(models.SignatureConfig{
+			Scheme: models.SignatureSchemeMeta,
+			Secret: endpoint.AppSecret,
+		})
+	}
+
+	return nil, nil
+}
+
+// hmacVerifier checks an HMAC of the raw body carried in a single header
+type hmacVerifier struct {
+	secret   []byte
+	header   string
+	hash     func() hash.Hash
+	encoding string
+	prefix   string
+}
+
+// newGenericHMACVerifier builds an hmacVerifier from a fully custom configuration
+func newGenericHMACVerifier(cfg models.SignatureConfig) (*hmacVerifier, error) {
+	if cfg.Header == "" {
+		return nil, errors.New("hmac signature requires a header")
+	}
+
+	v := &hmacVerifier{
+		secret:   []byte(cfg.Secret),
+		header:   cfg.Header,
+		encoding: cfg.Encoding,
+		prefix:   cfg.Prefix,
+	}
+
+	switch cfg.Algorithm {
+	case "sha1":
+		v.hash = sha1.New
+	case "", "sha256":
+		v.hash = sha256.New
+	case "sha512":
+		v.hash = sha512.New
+	default:
+		return nil, fmt.Errorf("unsupported hmac algorithm %q", cfg.Algorithm)
+	}
+
+	switch cfg.Encoding {
+	case "":
+		v.encoding = "hex"
+	case "hex", "base64":
+	default:
+		return nil, fmt.Errorf("unsupported hmac encoding %q", cfg.Encoding)
+	}
+
+	return v, nil
+}
+
+// Verify implements SignatureVerifier
+func (v *hmacVerifier) Verify(header http.Header, body []byte, now time.Time) error {
+	value := header.Get(v.header)
+	if value == "" {
+		return errSignatureMissing
+	}
+
+	digest, ok := strings.CutPrefix(value, v.prefix)
+	if !ok {
+		return errSignatureMismatch
+	}
+
+	expected, err := decodeSignature(digest, v.encoding)
+	if err != nil {
+		return errSignatureMismatch
+	}
+
+	if !hmac.Equal(computeHMAC(v.hash, v.secret, body), expected) {
+		return errSignatureMismatch
+	}
+
+	return nil
+}
+
+// stripeVerifier checks the Stripe-Signature header ("t=<ts>,v1=<hex>,...")
+type stripeVerifier struct {
+	secret    []byte
+	tolerance time.Duration
+}
+
+// Verify implements SignatureVerifier
+func (v *stripeVerifier) Verify(header http.Header, body []byte, now time.Time) error {
+	value := header.Get("Stripe-Signature")
+	if value == "" {
+		return errSignatureMissing
+	}
+
+	var timestamp string
+	var signatures []string
+	for _, part := range strings.Split(value, ",") {
+		key, val, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "t":
+			timestamp = val
+		case "v1":
+			signatures = append(signatures, val)
+		}
+	}
+
+	if timestamp == "" || len(signatures) == 0 {
+		return errSignatureMismatch
+	}
+
+	if err := checkTimestamp(timestamp, now, v.tolerance); err != nil {
+		return err
+	}
+
+	payload := append([]byte(timestamp+"."), body...)
+	expected := computeHMAC(sha256.New, v.secret, payload)
+	for _, signature := range signatures {
+		if decoded, err := hex.DecodeString(signature); err == nil && hmac.Equal(expected, decoded) {
+			return nil
+		}
+	}
+
+	return errSignatureMismatch
+}
+
+// slackVerifier checks X-Slack-Signature ("v0=<hex>") over "v0:<timestamp>:<body>"
+type slackVerifier struct {
+	secret    []byte
+	tolerance time.Duration
+}
+
+// Verify implements SignatureVerifier
+func (v *slackVerifier) Verify(header http.Header, body []byte, now time.Time) error {
+	value := header.Get("X-Slack-Signature")
+	timestamp := header.Get("X-Slack-Request-Timestamp")
+	if value == "" || timestamp == "" {
+		return errSignatureMissing
+	}
+
+	if err := checkTimestamp(timestamp, now, v.tolerance); err != nil {
+		return err
+	}
+
+	digest, ok := strings.CutPrefix(value, "v0=")
+	if !ok {
+		return errSignatureMismatch
+	}
+
+	expected, err := hex.DecodeString(digest)
+	if err != nil {
+		return errSignatureMismatch
+	}
+
+	payload := append([]byte("v0:"+timestamp+":"), body...)
+	if !hmac.Equal(computeHMAC(sha256.New, v.secret, payload), expected) {
+		return errSignatureMismatch
+	}
+
+	return nil
+}
+
+// computeHMAC returns the HMAC of data using the given hash
+func computeHMAC(h func() hash.Hash, secret, data []byte) []byte {
+	mac := hmac.New(h, secret)
+	mac.Write(data)
+	return mac.Sum(nil)
+}
+
+// decodeSignature decodes a hex or base64 signature
+func decodeSignature(signature, encoding string) ([]byte, error) {
+	if encoding == "base64" {
+		return base64.StdEncoding.DecodeString(signature)
+	}
+	return hex.DecodeString(signature)
+}
+
+// checkTimestamp rejects unix timestamps further than tolerance from now to prevent replays
+func checkTimestamp(timestamp string, now time.Time, tolerance time.Duration) error {
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return errTimestampInvalid
+	}
+
+	skew := now.Sub(time.Unix(seconds, 0))
+	if skew < -tolerance || skew > tolerance {
+		return errTimestampInvalid
+	}
+
+	return nil
+}
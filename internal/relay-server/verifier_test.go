@@ -0,0 +1,161 @@
+package relayserver
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/QuantumSolver/crm-relay/internal/models"
+)
+
+func hmacHex(secret string, data []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(data)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func mustVerifier(t *testing.T, cfg models.SignatureConfig) SignatureVerifier {
+	t.Helper()
+	v, err := NewSignatureVerifier(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create verifier: %v", err)
+	}
+	return v
+}
+
+func TestMetaVerifier(t *testing.T) {
+	body := []byte(`{"object":"whatsapp_business_account"}`)
+	v := mustVerifier(t, models.SignatureConfig{Scheme: models.SignatureSchemeMeta, Secret: "app-secret"})
+
+	header := http.Header{}
+	header.Set("X-Hub-Signature-256", "sha256="+hmacHex("app-secret", body))
+	if err := v.Verify(header, body, time.Now()); err != nil {
+		t.Errorf("Expected valid signature to verify, got %v", err)
+	}
+
+	if err := v.Verify(header, []byte(`{"object":"tampered"}`), time.Now()); err == nil {
+		t.Error("Expected signature over a different body to fail")
+	}
+
+	if err := v.Verify(http.Header{}, body, time.Now()); err != errSignatureMissing {
+		t.Errorf("Expected missing signature error, got %v", err)
+	}
+
+	for _, bad := range []string{"sha1=abcd", "sha256=not-hex", "sha256="} {
+		header.Set("X-Hub-Signature-256", bad)
+		if err := v.Verify(header, body, time.Now()); err == nil {
+			t.Errorf("Expected malformed signature %q to fail", bad)
+		}
+	}
+}
+
+func TestShopifyVerifier(t *testing.T) {
+	body := []byte(`{"id":1}`)
+	v := mustVerifier(t, models.SignatureConfig{Scheme: models.SignatureSchemeShopify, Secret: "shpss"})
+
+	mac := hmac.New(sha256.New, []byte("shpss"))
+	mac.Write(body)
+
+	header := http.Header{}
+	header.Set("X-Shopify-Hmac-Sha256", base64.StdEncoding.EncodeToString(mac.Sum(nil)))
+	if err := v.Verify(header, body, time.Now()); err != nil {
+		t.Errorf("Expected valid signature to verify, got %v", err)
+	}
+}
+
+func TestStripeVerifier(t *testing.T) {
+	body := []byte(`{"type":"payment_intent.succeeded"}`)
+	now := time.Unix(1700000000, 0)
+	ts := strconv.FormatInt(now.Unix(), 10)
+	v := mustVerifier(t, models.SignatureConfig{Scheme: models.SignatureSchemeStripe, Secret: "whsec_test"})
+
+	signature := hmacHex("whsec_test", []byte(ts+"."+string(body)))
+
+	header := http.Header{}
+	header.Set("Stripe-Signature", "t="+ts+",v1=deadbeef,v1="+signature)
+	if err := v.Verify(header, body, now); err != nil {
+		t.Errorf("Expected valid signature to verify, got %v", err)
+	}
+
+	if err := v.Verify(header, body, now.Add(10*time.Minute)); err != errTimestampInvalid {
+		t.Errorf("Expected replayed signature to be rejected, got %v", err)
+	}
+
+	header.Set("Stripe-Signature", "t="+ts+",v1=deadbeef")
+	if err := v.Verify(header, body, now); err != errSignatureMismatch {
+		t.Errorf("Expected signature mismatch, got %v", err)
+	}
+}
+
+func TestSlackVerifier(t *testing.T) {
+	body := []byte(`token=x&team_id=T1`)
+	now := time.Unix(1700000000, 0)
+	ts := strconv.FormatInt(now.Unix(), 10)
+	v := mustVerifier(t, models.SignatureConfig{Scheme: models.SignatureSchemeSlack, Secret: "slack-secret", Tolerance: 60})
+
+	header := http.Header{}
+	header.Set("X-Slack-Request-Timestamp", ts)
+	header.Set("X-Slack-Signature", "v0="+hmacHex("slack-secret", []byte("v0:"+ts+":"+string(body))))
+	if err := v.Verify(header, body, now); err != nil {
+		t.Errorf("Expected valid signature to verify, got %v", err)
+	}
+
+	if err := v.Verify(header, body, now.Add(2*time.Minute)); err != errTimestampInvalid {
+		t.Errorf("Expected timestamp outside tolerance to be rejected, got %v", err)
+	}
+}
+
+func TestGenericHMACVerifier(t *testing.T) {
+	body := []byte(`{"event":"lead.created"}`)
+	v := mustVerifier(t, models.SignatureConfig{
+		Scheme:    models.SignatureSchemeHMAC,
+		Secret:    "crm-secret",
+		Header:    "X-CRM-Signature",
+		Algorithm: "sha1",
+		Encoding:  "base64",
+		Prefix:    "sha1=",
+	})
+
+	mac := hmac.New(sha1.New, []byte("crm-secret"))
+	mac.Write(body)
+
+	header := http.Header{}
+	header.Set("X-CRM-Signature", "sha1="+base64.StdEncoding.EncodeToString(mac.Sum(nil)))
+	if err := v.Verify(header, body, time.Now()); err != nil {
+		t.Errorf("Expected valid signature to verify, got %v", err)
+	}
+}
+
+func TestNewSignatureVerifierInvalidConfig(t *testing.T) {
+	configs := []models.SignatureConfig{
+		{Scheme: models.SignatureSchemeMeta},
+		{Scheme: "unknown", Secret: "s"},
+		{Scheme: models.SignatureSchemeHMAC, Secret: "s"},
+		{Scheme: models.SignatureSchemeHMAC, Secret: "s", Header: "X-Sig", Algorithm: "md5"},
+		{Scheme: models.SignatureSchemeHMAC, Secret: "s", Header: "X-Sig", Encoding: "base32"},
+	}
+
+	for _, cfg := range configs {
+		if _, err := NewSignatureVerifier(cfg); err == nil {
+			t.Errorf("Expected error for config %+v", cfg)
+		}
+	}
+}
+
+func TestEndpointVerifierFallsBackToAppSecret(t *testing.T) {
+	v, err := endpointVerifier(&models.WebhookEndpoint{AppSecret: "app-secret"})
+	if err != nil || v == nil {
+		t.Fatalf("Expected Meta verifier from app secret, got %v, %v", v, err)
+	}
+
+	v, err = endpointVerifier(&models.WebhookEndpoint{})
+	if err != nil || v != nil {
+		t.Errorf("Expected no verifier for endpoint without secrets, got %v, %v", v, err)
+	}
+}
@@ -1,14 +1,23 @@
 package storage
 
 import (
+	"cmp"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/redis/go-redis/v9"
 	"github.com/QuantumSolver/crm-relay/internal/models"
+	"github.com/QuantumSolver/crm-relay/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // RedisClient wraps the Redis client with stream operations
@@ -54,6 +63,16 @@ func NewRedisClient(cfg *models.Config) (*RedisClient, error) {
 		return nil, fmt.Errorf("failed to initialize consumer group: %w", err)
 	}
 
+	// Earlier releases set a TTL on the whole stream key, which dropped the consumer
+	// group after idle periods. Streams are now trimmed by TrimStream instead.
+	if err := client.Persist(ctx, cfg.StreamName).Err(); err != nil {
+		return nil, models.NewRelayError(
+			models.ErrCodeRedisConnection,
+			"failed to clear stream expiry",
+			err,
+		)
+	}
+
 	return redisClient, nil
 }
 
@@ -85,6 +104,51 @@ func (r *RedisClient) AddWebhook(ctx context.Context, webhook *models.Webhook) (
 		CreatedAt:  time.Now(),
 	}
 
+	return r.AddRelayMessage(ctx, &message)
+}
+
+// AddReplay re-adds a message taken from the DLQ to the stream, marking it as a replay
+// of the message originally delivered under streamID. Replays of replays keep the
+// original IDs and increment the replay count.
+func (r *RedisClient) AddReplay(ctx context.Context, message *models.RelayMessage, streamID, actor string) (string, error) {
+	replay := &models.ReplayInfo{
+		OriginalWebhookID: message.Webhook.ID,
+		OriginalStreamID:  streamID,
+		Count:             1,
+		ReplayedBy:        actor,
+		ReplayedAt:        time.Now(),
+	}
+	if message.Replay != nil {
+		replay.OriginalWebhookID = message.Replay.OriginalWebhookID
+		replay.OriginalStreamID = message.Replay.OriginalStreamID
+		replay.Count = message.Replay.Count + 1
+	}
+
+	replayed := *message
+	replayed.Replay = replay
+	return r.AddRelayMessage(ctx, &replayed)
+}
+
+// AddRelayMessage adds an existing relay message to the Redis stream as-is,
+// keeping its retry count, attempt history and target endpoint
+func (r *RedisClient) AddRelayMessage(ctx context.Context, message *models.RelayMessage) (id string, err error) {
+	ctx, span := tracing.Start(ctx, "redis.xadd", trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(
+			attribute.String("messaging.system", "redis"),
+			attribute.String("messaging.destination.name", r.config.StreamName),
+			attribute.String("relay.webhook_id", message.Webhook.ID),
+		))
+	defer func() {
+		span.SetAttributes(attribute.String("messaging.message.id", id))
+		tracing.End(span, err)
+	}()
+
+	// New messages carry the trace of the request that enqueued them; retries and
+	// replays keep the trace they started in
+	if message.TraceContext == nil {
+		message.TraceContext = tracing.Inject(ctx)
+	}
+
 	messageJSON, err := json.Marshal(message)
 	if err != nil {
 		return "", models.NewRelayError(
@@ -95,7 +159,7 @@ func (r *RedisClient) AddWebhook(ctx context.Context, webhook *models.Webhook) (
 	}
 
 	// Add to stream
-	id, err := r.client.XAdd(ctx, &redis.XAddArgs{
+	id, err = r.client.XAdd(ctx, &redis.XAddArgs{
 		Stream: r.config.StreamName,
 		Values: map[string]interface{}{
 			"data": messageJSON,
@@ -110,9 +174,6 @@ func (r *RedisClient) AddWebhook(ctx context.Context, webhook *models.Webhook) (
 		)
 	}
 
-	// Set TTL on stream key
-	r.client.Expire(ctx, r.config.StreamName, time.Duration(r.config.MessageTTL)*time.Second)
-
 	return id, nil
 }
 
@@ -142,6 +203,83 @@ func (r *RedisClient) ReadMessages(ctx context.Context, count int64, block time.
 	return messages[0].Messages, nil
 }
 
+// ClaimedMessage is a pending stream entry reclaimed from an idle consumer
+type ClaimedMessage struct {
+	Message       redis.XMessage
+	DeliveryCount int64
+}
+
+// ClaimStaleMessages transfers pending entries idle for at least minIdle to this
+// consumer, scanning up to count entries from the given cursor. Entries for which
+// skip reports true are left untouched, so their delivery count and owner do not
+// change. It returns the claimed entries together with their delivery counts and the
+// cursor to resume from on the next call.
+func (r *RedisClient) ClaimStaleMessages(ctx context.Context, start string, minIdle time.Duration, count int64, skip func(id string) bool) ([]ClaimedMessage, string, error) {
+	pending, err := r.client.XPendingExt(ctx, &redis.XPendingExtArgs{
+		Stream: r.config.StreamName,
+		Group:  r.config.ConsumerGroup,
+		Idle:   minIdle,
+		Start:  start,
+		End:    "+",
+		Count:  count,
+	}).Result()
+	if err != nil && err != redis.Nil {
+		return nil, start, models.NewRelayError(
+			models.ErrCodeStreamRead,
+			"failed to read pending entries",
+			err,
+		)
+	}
+
+	// Wrap around once the end of the pending list is reached
+	next := "0-0"
+	if int64(len(pending)) == count {
+		next = nextStreamID(pending[len(pending)-1].ID)
+	}
+
+	ids := make([]string, 0, len(pending))
+	deliveries := make(map[string]int64, len(pending))
+	for _, entry := range pending {
+		if skip != nil && skip(entry.ID) {
+			continue
+		}
+		ids = append(ids, entry.ID)
+		// Claiming counts as one more delivery
+		deliveries[entry.ID] = entry.RetryCount + 1
+	}
+
+	if len(ids) == 0 {
+		return nil, next, nil
+	}
+
+	// XCLAIM re-checks the idle time, so an entry another consumer picked up in the
+	// meantime is not taken from it
+	messages, err := r.client.XClaim(ctx, &redis.XClaimArgs{
+		Stream:   r.config.StreamName,
+		Group:    r.config.ConsumerGroup,
+		Consumer: r.config.ConsumerName,
+		MinIdle:  minIdle,
+		Messages: ids,
+	}).Result()
+	if err != nil && err != redis.Nil {
+		return nil, start, models.NewRelayError(
+			models.ErrCodeStreamRead,
+			"failed to claim stale messages",
+			err,
+		)
+	}
+
+	claimed := make([]ClaimedMessage, 0, len(messages))
+	for _, message := range messages {
+		claimed = append(claimed, ClaimedMessage{
+			Message:       message,
+			DeliveryCount: deliveries[message.ID],
+		})
+	}
+
+	return claimed, next, nil
+}
+
 // AcknowledgeMessage acknowledges a message as processed
 func (r *RedisClient) AcknowledgeMessage(ctx context.Context, messageID string) error {
 	err := r.client.XAck(ctx, r.config.StreamName, r.config.ConsumerGroup, messageID).Err()
@@ -155,8 +293,15 @@ func (r *RedisClient) AcknowledgeMessage(ctx context.Context, messageID string)
 	return nil
 }
 
-// MoveToDeadLetterQueue moves a message to the dead letter queue
-func (r *RedisClient) MoveToDeadLetterQueue(ctx context.Context, messageID string, message *models.RelayMessage) error {
+// MoveToDeadLetterQueue moves a message to the dead letter queue, recording the error that caused it
+func (r *RedisClient) MoveToDeadLetterQueue(ctx context.Context, messageID string, message *models.RelayMessage, cause error) (err error) {
+	ctx, span := tracing.Start(ctx, "dlq.move", trace.WithAttributes(
+		attribute.String("messaging.message.id", messageID),
+		attribute.String("relay.webhook_id", message.Webhook.ID),
+		attribute.Int("relay.retry_count", message.RetryCount),
+	))
+	defer func() { tracing.End(span, err) }()
+
 	// Serialize message
 	messageJSON, err := json.Marshal(message)
 	if err != nil {
@@ -167,6 +312,16 @@ func (r *RedisClient) MoveToDeadLetterQueue(ctx context.Context, messageID strin
 		)
 	}
 
+	var errorCode, errorText string
+	if cause != nil {
+		errorText = cause.Error()
+		var relayErr *models.RelayError
+		if errors.As(cause, &relayErr) {
+			errorCode = relayErr.Code
+		}
+	}
+	span.SetAttributes(attribute.String("relay.error_code", errorCode))
+
 	// Add to dead letter queue
 	_, err = r.client.XAdd(ctx, &redis.XAddArgs{
 		Stream: r.config.DeadLetterQueue,
@@ -174,6 +329,8 @@ func (r *RedisClient) MoveToDeadLetterQueue(ctx context.Context, messageID strin
 			"original_id": messageID,
 			"data":        messageJSON,
 			"moved_at":    time.Now().Unix(),
+			"error_code":  errorCode,
+			"error":       errorText,
 		},
 	}).Result()
 
@@ -193,6 +350,207 @@ func (r *RedisClient) MoveToDeadLetterQueue(ctx context.Context, messageID strin
 	return nil
 }
 
+// Retention methods
+
+// TrimStream removes stream entries older than MessageTTL. Entries that any consumer
+// group has not yet delivered or acknowledged are always kept, so the trim point never
+// passes the oldest pending entry or a group's last delivered ID.
+func (r *RedisClient) TrimStream(ctx context.Context, now time.Time) (int64, error) {
+	cutoff := now.Add(-time.Duration(r.config.MessageTTL) * time.Second)
+
+	groups, err := r.client.XInfoGroups(ctx, r.config.StreamName).Result()
+	if err != nil {
+		if strings.Contains(err.Error(), "no such key") {
+			return 0, nil
+		}
+		return 0, models.NewRelayError(
+			models.ErrCodeStreamRead,
+			"failed to get consumer groups",
+			err,
+		)
+	}
+
+	var keep []string
+	for _, group := range groups {
+		keep = append(keep, group.LastDeliveredID)
+
+		if group.Pending == 0 {
+			continue
+		}
+
+		pending, err := r.client.XPending(ctx, r.config.StreamName, group.Name).Result()
+		if err != nil {
+			return 0, models.NewRelayError(
+				models.ErrCodeStreamRead,
+				"failed to get pending entries",
+				err,
+			)
+		}
+		if pending.Count > 0 {
+			keep = append(keep, pending.Lower)
+		}
+	}
+	minID := trimMinID(fmt.Sprintf("%d-0", cutoff.UnixMilli()), keep...)
+
+	trimmed, err := r.client.XTrimMinIDApprox(ctx, r.config.StreamName, minID, 0).Result()
+	if err != nil {
+		return 0, models.NewRelayError(
+			models.ErrCodeStreamWrite,
+			"failed to trim stream",
+			err,
+		)
+	}
+
+	return trimmed, nil
+}
+
+// TrimDeadLetterQueue removes DLQ entries older than DLQTTL and, when DLQMaxLen is
+// set, caps the DLQ at about that many entries. It returns the number of expired
+// entries and, separately, the number of entries dropped by the cap.
+func (r *RedisClient) TrimDeadLetterQueue(ctx context.Context, now time.Time) (expired, dropped int64, err error) {
+	cutoff := now.Add(-time.Duration(r.config.DLQTTL) * time.Second)
+
+	expired, err = r.client.XTrimMinIDApprox(ctx, r.config.DeadLetterQueue, fmt.Sprintf("%d-0", cutoff.UnixMilli()), 0).Result()
+	if err != nil {
+		return 0, 0, models.NewRelayError(
+			models.ErrCodeStreamWrite,
+			"failed to trim dead letter queue",
+			err,
+		)
+	}
+
+	if r.config.DLQMaxLen > 0 {
+		dropped, err = r.client.XTrimMaxLenApprox(ctx, r.config.DeadLetterQueue, int64(r.config.DLQMaxLen), 0).Result()
+		if err != nil {
+			return expired, 0, models.NewRelayError(
+				models.ErrCodeStreamWrite,
+				"failed to cap dead letter queue",
+				err,
+			)
+		}
+	}
+
+	// The replay audit log follows the DLQ retention
+	audited, err := r.client.XTrimMinIDApprox(ctx, r.replayAuditStream(), fmt.Sprintf("%d-0", cutoff.UnixMilli()), 0).Result()
+	if err != nil {
+		return expired, dropped, models.NewRelayError(
+			models.ErrCodeStreamWrite,
+			"failed to trim replay audit log",
+			err,
+		)
+	}
+
+	return expired + audited, dropped, nil
+}
+
+// trimMinID lowers a retention cutoff to the lowest of the IDs that must be kept,
+// such as a group's last delivered ID or its oldest pending entry
+func trimMinID(cutoff string, keep ...string) string {
+	minID := cutoff
+	for _, id := range keep {
+		if id != "" && compareStreamIDs(id, minID) < 0 {
+			minID = id
+		}
+	}
+	return minID
+}
+
+// compareStreamIDs orders two stream IDs of the form "<ms>-<seq>"
+func compareStreamIDs(a, b string) int {
+	aMs, aSeq := parseStreamID(a)
+	bMs, bSeq := parseStreamID(b)
+	if c := cmp.Compare(aMs, bMs); c != 0 {
+		return c
+	}
+	return cmp.Compare(aSeq, bSeq)
+}
+
+// nextStreamID returns the smallest stream ID after id
+func nextStreamID(id string) string {
+	ms, seq := parseStreamID(id)
+	return fmt.Sprintf("%d-%d", ms, seq+1)
+}
+
+// parseStreamID splits a stream ID into its millisecond and sequence parts
+func parseStreamID(id string) (uint64, uint64) {
+	msPart, seqPart, _ := strings.Cut(id, "-")
+	ms, _ := strconv.ParseUint(msPart, 10, 64)
+	seq, _ := strconv.ParseUint(seqPart, 10, 64)
+	return ms, seq
+}
+
+// promoteRetriesScript atomically moves due entries from the retry sorted set
+// back into the main stream so concurrent schedulers never double-deliver.
+var promoteRetriesScript = redis.NewScript(`
+local due = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', ARGV[1], 'LIMIT', 0, ARGV[2])
+for _, member in ipairs(due) do
+	if redis.call('ZREM', KEYS[1], member) == 1 then
+		redis.call('XADD', KEYS[2], '*', 'data', member)
+	end
+end
+return #due
+`)
+
+// ScheduleRetry stores a message in the retry queue until retryAt and acknowledges
+// the original stream entry so it no longer occupies the pending entries list
+func (r *RedisClient) ScheduleRetry(ctx context.Context, messageID string, message *models.RelayMessage, retryAt time.Time) error {
+	messageJSON, err := json.Marshal(message)
+	if err != nil {
+		return models.NewRelayError(
+			models.ErrCodeStreamWrite,
+			"failed to serialize message for retry",
+			err,
+		)
+	}
+
+	_, err = r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.ZAdd(ctx, r.config.RetryQueue, redis.Z{
+			Score:  float64(retryAt.UnixMilli()),
+			Member: messageJSON,
+		})
+		pipe.XAck(ctx, r.config.StreamName, r.config.ConsumerGroup, messageID)
+		return nil
+	})
+	if err != nil {
+		return models.NewRelayError(
+			models.ErrCodeStreamWrite,
+			"failed to schedule message retry",
+			err,
+		)
+	}
+
+	return nil
+}
+
+// PromoteDueRetries moves up to limit retries that are due at now back into the stream
+func (r *RedisClient) PromoteDueRetries(ctx context.Context, now time.Time, limit int64) (int64, error) {
+	promoted, err := promoteRetriesScript.Run(ctx, r.client,
+		[]string{r.config.RetryQueue, r.config.StreamName},
+		now.UnixMilli(), limit,
+	).Int64()
+	if err != nil {
+		return 0, models.NewRelayError(
+			models.ErrCodeStreamWrite,
+			"failed to promote due retries",
+			err,
+		)
+	}
+	return promoted, nil
+}
+
+// GetScheduledRetries returns the number of messages waiting in the retry queue
+func (r *RedisClient) GetScheduledRetries(ctx context.Context) (int64, error) {
+	count, err := r.client.ZCard(ctx, r.config.RetryQueue).Result()
+	if err != nil {
+		return 0, models.NewRelayError(
+			models.ErrCodeRedisConnection,
+			"failed to get scheduled retries",
+			err,
+		)
+	}
+	return count, nil
+}
+
 // GetQueueDepth returns the current queue depth
 func (r *RedisClient) GetQueueDepth(ctx context.Context) (int64, error) {
 	length, err := r.client.XLen(ctx, r.config.StreamName).Result()
@@ -206,6 +564,19 @@ func (r *RedisClient) GetQueueDepth(ctx context.Context) (int64, error) {
 	return length, nil
 }
 
+// GetDLQLength returns the number of entries in the dead letter queue
+func (r *RedisClient) GetDLQLength(ctx context.Context) (int64, error) {
+	length, err := r.client.XLen(ctx, r.config.DeadLetterQueue).Result()
+	if err != nil {
+		return 0, models.NewRelayError(
+			models.ErrCodeRedisConnection,
+			"failed to get DLQ length",
+			err,
+		)
+	}
+	return length, nil
+}
+
 // GetPendingMessages returns the number of pending messages for the consumer
 func (r *RedisClient) GetPendingMessages(ctx context.Context) (int64, error) {
 	pending, err := r.client.XPending(ctx, r.config.StreamName, r.config.ConsumerGroup).Result()
@@ -241,7 +612,10 @@ func ParseMessage(message redis.XMessage) (*models.RelayMessage, error) {
 
 // User management methods
 
-// StoreUser stores a user in Redis
+// usersIndexKey is a set of all usernames, so users can be listed without scanning
+const usersIndexKey = "users"
+
+// StoreUser stores a user in Redis and adds it to the user index
 func (r *RedisClient) StoreUser(ctx context.Context, user *models.User) error {
 	userJSON, err := json.Marshal(user)
 	if err != nil {
@@ -252,8 +626,10 @@ func (r *RedisClient) StoreUser(ctx context.Context, user *models.User) error {
 		)
 	}
 
-	key := fmt.Sprintf("user:%s", user.Username)
-	if err := r.client.Set(ctx, key, userJSON, 0).Err(); err != nil {
+	pipe := r.client.TxPipeline()
+	pipe.Set(ctx, fmt.Sprintf("user:%s", user.Username), userJSON, 0)
+	pipe.SAdd(ctx, usersIndexKey, user.Username)
+	if _, err := pipe.Exec(ctx); err != nil {
 		return models.NewRelayError(
 			models.ErrCodeRedisConnection,
 			"failed to store user",
@@ -264,258 +640,1612 @@ func (r *RedisClient) StoreUser(ctx context.Context, user *models.User) error {
 	return nil
 }
 
-// GetUser retrieves a user by username
-func (r *RedisClient) GetUser(ctx context.Context, username string) (*models.User, error) {
-	key := fmt.Sprintf("user:%s", username)
-	data, err := r.client.Get(ctx, key).Result()
+// CreateUser stores a new user, failing if the username is taken
+func (r *RedisClient) CreateUser(ctx context.Context, user *models.User) error {
+	userJSON, err := json.Marshal(user)
 	if err != nil {
-		if err == redis.Nil {
-			return nil, models.NewRelayError(
-				models.ErrCodeAuthentication,
-				"user not found",
-				nil,
-			)
-		}
-		return nil, models.NewRelayError(
-			models.ErrCodeRedisConnection,
-			"failed to retrieve user",
+		return models.NewRelayError(
+			models.ErrCodeStreamWrite,
+			"failed to serialize user",
 			err,
 		)
 	}
 
-	var user models.User
-	if err := json.Unmarshal([]byte(data), &user); err != nil {
-		return nil, models.NewRelayError(
-			models.ErrCodeStreamRead,
-			"failed to unmarshal user",
+	created, err := r.client.SetNX(ctx, fmt.Sprintf("user:%s", user.Username), userJSON, 0).Result()
+	if err != nil {
+		return models.NewRelayError(
+			models.ErrCodeRedisConnection,
+			"failed to store user",
 			err,
 		)
 	}
+	if !created {
+		return models.NewRelayError(
+			models.ErrCodeInvalidRequest,
+			"user already exists",
+			nil,
+		)
+	}
 
-	return &user, nil
+	return r.IndexUser(ctx, user.Username)
 }
 
-// ValidateUser validates a user's credentials
-func (r *RedisClient) ValidateUser(ctx context.Context, username, password string) (*models.User, error) {
-	user, err := r.GetUser(ctx, username)
-	if err != nil {
-		return nil, err
+// IndexUser adds a username to the user index. Users created before the index
+// existed are added this way on startup.
+func (r *RedisClient) IndexUser(ctx context.Context, username string) error {
+	if err := r.client.SAdd(ctx, usersIndexKey, username).Err(); err != nil {
+		return models.NewRelayError(
+			models.ErrCodeRedisConnection,
+			"failed to index user",
+			err,
+		)
 	}
-
-	// Password validation is done in the auth package using bcrypt
-	return user, nil
+	return nil
 }
 
-// API Key management methods
-
-// CreateAPIKey creates a new API key
-func (r *RedisClient) CreateAPIKey(ctx context.Context, apiKey *models.APIKey) error {
-	apiKeyJSON, err := json.Marshal(apiKey)
+// ListUsers returns all indexed users sorted by username
+func (r *RedisClient) ListUsers(ctx context.Context) ([]*models.User, error) {
+	usernames, err := r.client.SMembers(ctx, usersIndexKey).Result()
 	if err != nil {
-		return models.NewRelayError(
-			models.ErrCodeStreamWrite,
-			"failed to serialize API key",
+		return nil, models.NewRelayError(
+			models.ErrCodeRedisConnection,
+			"failed to read user index",
 			err,
 		)
 	}
 
-	// Store by ID
-	key := fmt.Sprintf("apikey:%s", apiKey.ID)
-	if err := r.client.Set(ctx, key, apiKeyJSON, 0).Err(); err != nil {
-		return models.NewRelayError(
+	users := make([]*models.User, 0, len(usernames))
+	if len(usernames) == 0 {
+		return users, nil
+	}
+
+	sort.Strings(usernames)
+	keys := make([]string, len(usernames))
+	for i, username := range usernames {
+		keys[i] = fmt.Sprintf("user:%s", username)
+	}
+
+	values, err := r.client.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, models.NewRelayError(
 			models.ErrCodeRedisConnection,
-			"failed to store API key",
+			"failed to retrieve users",
 			err,
 		)
 	}
 
-	// Store by key value for quick lookup
-	lookupKey := fmt.Sprintf("apikey:lookup:%s", apiKey.Key)
-	if err := r.client.Set(ctx, lookupKey, apiKey.ID, 0).Err(); err != nil {
+	for _, value := range values {
+		data, ok := value.(string)
+		if !ok {
+			// Deleted between reading the index and the users
+			continue
+		}
+
+		var user models.User
+		if err := json.Unmarshal([]byte(data), &user); err != nil {
+			continue
+		}
+		users = append(users, &user)
+	}
+
+	return users, nil
+}
+
+// DeleteUser removes a user and its index entry
+func (r *RedisClient) DeleteUser(ctx context.Context, username string) error {
+	pipe := r.client.TxPipeline()
+	deleted := pipe.Del(ctx, fmt.Sprintf("user:%s", username))
+	pipe.SRem(ctx, usersIndexKey, username)
+	if _, err := pipe.Exec(ctx); err != nil {
 		return models.NewRelayError(
 			models.ErrCodeRedisConnection,
-			"failed to store API key lookup",
+			"failed to delete user",
 			err,
 		)
 	}
 
-	// Add to platform index
-	platformKey := fmt.Sprintf("apikey:platform:%s", apiKey.Platform)
-	if err := r.client.SAdd(ctx, platformKey, apiKey.ID).Err(); err != nil {
+	if deleted.Val() == 0 {
 		return models.NewRelayError(
-			models.ErrCodeRedisConnection,
-			"failed to add to platform index",
-			err,
+			models.ErrCodeInvalidRequest,
+			"user not found",
+			nil,
 		)
 	}
 
 	return nil
 }
 
-// GetAPIKey retrieves an API key by ID
-func (r *RedisClient) GetAPIKey(ctx context.Context, id string) (*models.APIKey, error) {
-	key := fmt.Sprintf("apikey:%s", id)
-	data, err := r.client.Get(ctx, key).Result()
+// keepAdminScript replaces a user with ARGV[2], or deletes it when ARGV[2] is empty,
+// unless that would leave no enabled admin. The other users are read inside the
+// script so the check and the write are atomic; their keys are built from the index
+// with the ARGV[3] prefix, which standalone Redis allows.
+var keepAdminScript = redis.NewScript(`
+local function enabled_admin(data)
+	if not data then
+		return false
+	end
+	local user = cjson.decode(data)
+	return user.role == 'admin' and user.disabled ~= true
+end
+
+local current = redis.call('GET', KEYS[2])
+if not current then
+	return -1
+end
+
+local updated = ARGV[2]
+if updated == '' then
+	updated = false
+end
+
+if enabled_admin(current) and not enabled_admin(updated) then
+	local other_admin = false
+	for _, username in ipairs(redis.call('SMEMBERS', KEYS[1])) do
+		if username ~= ARGV[1] and enabled_admin(redis.call('GET', ARGV[3] .. username)) then
+			other_admin = true
+			break
+		end
+	end
+	if not other_admin then
+		return 0
+	end
+end
+
+if updated then
+	redis.call('SET', KEYS[2], updated)
+	redis.call('SADD', KEYS[1], ARGV[1])
+else
+	redis.call('DEL', KEYS[2])
+	redis.call('SREM', KEYS[1], ARGV[1])
+end
+return 1
+`)
+
+// UpdateUserKeepingAdmin stores changes to an existing user, refusing any that would
+// leave no enabled admin
+func (r *RedisClient) UpdateUserKeepingAdmin(ctx context.Context, user *models.User) error {
+	userJSON, err := json.Marshal(user)
 	if err != nil {
-		if err == redis.Nil {
-			return nil, models.NewRelayError(
-				models.ErrCodeInvalidRequest,
-				"API key not found",
-				nil,
-			)
-		}
-		return nil, models.NewRelayError(
+		return models.NewRelayError(
+			models.ErrCodeStreamWrite,
+			"failed to serialize user",
+			err,
+		)
+	}
+
+	return r.writeUserKeepingAdmin(ctx, user.Username, string(userJSON), "failed to update user")
+}
+
+// DeleteUserKeepingAdmin removes a user and its index entry, refusing to remove the
+// last enabled admin
+func (r *RedisClient) DeleteUserKeepingAdmin(ctx context.Context, username string) error {
+	return r.writeUserKeepingAdmin(ctx, username, "", "failed to delete user")
+}
+
+// writeUserKeepingAdmin runs keepAdminScript for a user
+func (r *RedisClient) writeUserKeepingAdmin(ctx context.Context, username, userJSON, failure string) error {
+	result, err := keepAdminScript.Run(ctx, r.client,
+		[]string{usersIndexKey, fmt.Sprintf("user:%s", username)},
+		username, userJSON, "user:",
+	).Int()
+	if err != nil {
+		return models.NewRelayError(
 			models.ErrCodeRedisConnection,
-			"failed to retrieve API key",
+			failure,
 			err,
 		)
 	}
 
-	var apiKey models.APIKey
-	if err := json.Unmarshal([]byte(data), &apiKey); err != nil {
-		return nil, models.NewRelayError(
-			models.ErrCodeStreamRead,
-			"failed to unmarshal API key",
+	switch result {
+	case -1:
+		return models.NewRelayError(
+			models.ErrCodeInvalidRequest,
+			"user not found",
+			nil,
+		)
+	case 0:
+		return models.NewRelayError(
+			models.ErrCodeLastAdmin,
+			"cannot remove the last enabled admin",
+			nil,
+		)
+	}
+
+	return nil
+}
+
+// ChangeUserPassword sets a new password hash on a user, but only while the stored
+// user still matches expected; it reports false when the user was removed or changed
+// since it was read, so a concurrent disable or delete is never undone
+func (r *RedisClient) ChangeUserPassword(ctx context.Context, expected *models.User, passwordHash string) (bool, error) {
+	key := fmt.Sprintf("user:%s", expected.Username)
+	changed := false
+
+	err := r.client.Watch(ctx, func(tx *redis.Tx) error {
+		data, err := tx.Get(ctx, key).Result()
+		if err != nil {
+			return err
+		}
+
+		var user models.User
+		if err := json.Unmarshal([]byte(data), &user); err != nil {
+			return err
+		}
+		if !sameUser(&user, expected) {
+			return nil
+		}
+
+		user.PasswordHash = passwordHash
+		user.UpdatedAt = time.Now()
+		userJSON, err := json.Marshal(&user)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Set(ctx, key, userJSON, 0)
+			return nil
+		})
+		changed = err == nil
+		return err
+	}, key)
+	if err == redis.Nil || err == redis.TxFailedErr {
+		return false, nil
+	}
+	if err != nil {
+		return false, models.NewRelayError(
+			models.ErrCodeRedisConnection,
+			"failed to change password",
 			err,
 		)
 	}
 
-	return &apiKey, nil
+	return changed, nil
 }
 
-// GetAPIKeyByValue retrieves an API key by its value
-func (r *RedisClient) GetAPIKeyByValue(ctx context.Context, key string) (*models.APIKey, error) {
-	lookupKey := fmt.Sprintf("apikey:lookup:%s", key)
-	id, err := r.client.Get(ctx, lookupKey).Result()
+// sameUser reports whether two reads of a user show the same account state
+func sameUser(a, b *models.User) bool {
+	return a.ID == b.ID &&
+		a.PasswordHash == b.PasswordHash &&
+		a.Role == b.Role &&
+		a.Disabled == b.Disabled &&
+		a.UpdatedAt.Equal(b.UpdatedAt)
+}
+
+// GetUser retrieves a user by username
+func (r *RedisClient) GetUser(ctx context.Context, username string) (*models.User, error) {
+	key := fmt.Sprintf("user:%s", username)
+	data, err := r.client.Get(ctx, key).Result()
 	if err != nil {
 		if err == redis.Nil {
 			return nil, models.NewRelayError(
 				models.ErrCodeAuthentication,
-				"API key not found",
+				"user not found",
 				nil,
 			)
 		}
 		return nil, models.NewRelayError(
 			models.ErrCodeRedisConnection,
-			"failed to lookup API key",
+			"failed to retrieve user",
+			err,
+		)
+	}
+
+	var user models.User
+	if err := json.Unmarshal([]byte(data), &user); err != nil {
+		return nil, models.NewRelayError(
+			models.ErrCodeStreamRead,
+			"failed to unmarshal user",
+			err,
+		)
+	}
+
+	return &user, nil
+}
+
+// ValidateUser validates a user's credentials
+func (r *RedisClient) ValidateUser(ctx context.Context, username, password string) (*models.User, error) {
+	user, err := r.GetUser(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+
+	// Password validation is done in the auth package using bcrypt
+	return user, nil
+}
+
+// Login attempt methods
+
+// authAuditKey is the stream of login audit events
+const authAuditKey = "auth:audit"
+
+// authAuditMaxLen caps the login audit log, which attackers can grow at will
+const authAuditMaxLen = 10000
+
+func loginAttemptsKey(subject string) string {
+	return fmt.Sprintf("login:attempts:%s", subject)
+}
+
+// recordLoginFailureScript counts a failed login and starts a lockout once the
+// failures reach the limit. Each lockout lasts twice as long as the one before, up
+// to the maximum, for as long as the subject keeps failing within the window.
+var recordLoginFailureScript = redis.NewScript(`
+local now = tonumber(ARGV[1])
+local locked_until = tonumber(redis.call('HGET', KEYS[1], 'locked_until') or '0')
+if locked_until > now then
+	return 0
+end
+
+local window = tonumber(ARGV[3])
+local ttl = window
+local failures = redis.call('HINCRBY', KEYS[1], 'failures', 1)
+if failures >= tonumber(ARGV[2]) then
+	local lockouts = redis.call('HINCRBY', KEYS[1], 'lockouts', 1)
+	local duration = math.min(tonumber(ARGV[4]) * 2 ^ (lockouts - 1), tonumber(ARGV[5]))
+	locked_until = now + math.floor(duration)
+	redis.call('HSET', KEYS[1], 'failures', 0, 'locked_until', locked_until)
+	ttl = locked_until - now + window
+	redis.call('PEXPIRE', KEYS[1], ttl)
+	return locked_until
+end
+
+redis.call('PEXPIRE', KEYS[1], ttl)
+return 0
+`)
+
+// RecordLoginFailure counts a failed login against subject, such as "user:alice" or
+// "ip:203.0.113.7", and returns when the lockout it started ends, or the zero time
+func (r *RedisClient) RecordLoginFailure(ctx context.Context, subject string, maxAttempts int, window, lockoutBase, lockoutMax time.Duration) (time.Time, error) {
+	lockedUntil, err := recordLoginFailureScript.Run(ctx, r.client,
+		[]string{loginAttemptsKey(subject)},
+		time.Now().UnixMilli(), maxAttempts, window.Milliseconds(), lockoutBase.Milliseconds(), lockoutMax.Milliseconds(),
+	).Int64()
+	if err != nil {
+		return time.Time{}, models.NewRelayError(
+			models.ErrCodeRedisConnection,
+			"failed to record login failure",
+			err,
+		)
+	}
+
+	if lockedUntil == 0 {
+		return time.Time{}, nil
+	}
+	return time.UnixMilli(lockedUntil), nil
+}
+
+// LoginLockedUntil returns when the latest lockout of any of the subjects ends. The
+// result is in the past when none of them is locked out.
+func (r *RedisClient) LoginLockedUntil(ctx context.Context, subjects ...string) (time.Time, error) {
+	pipe := r.client.Pipeline()
+	cmds := make([]*redis.StringCmd, len(subjects))
+	for i, subject := range subjects {
+		cmds[i] = pipe.HGet(ctx, loginAttemptsKey(subject), "locked_until")
+	}
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return time.Time{}, models.NewRelayError(
+			models.ErrCodeRedisConnection,
+			"failed to read login lockouts",
+			err,
+		)
+	}
+
+	var latest int64
+	for _, cmd := range cmds {
+		if lockedUntil, err := cmd.Int64(); err == nil && lockedUntil > latest {
+			latest = lockedUntil
+		}
+	}
+	return time.UnixMilli(latest), nil
+}
+
+// ClearLoginFailures forgets the failed logins and lockouts of subject
+func (r *RedisClient) ClearLoginFailures(ctx context.Context, subject string) error {
+	if err := r.client.Del(ctx, loginAttemptsKey(subject)).Err(); err != nil {
+		return models.NewRelayError(
+			models.ErrCodeRedisConnection,
+			"failed to clear login failures",
+			err,
+		)
+	}
+
+	return nil
+}
+
+// RecordAuthEvent appends an entry to the login audit log
+func (r *RedisClient) RecordAuthEvent(ctx context.Context, event *models.AuthEvent) error {
+	eventJSON, err := json.Marshal(event)
+	if err != nil {
+		return models.NewRelayError(
+			models.ErrCodeStreamWrite,
+			"failed to serialize auth event",
+			err,
+		)
+	}
+
+	err = r.client.XAdd(ctx, &redis.XAddArgs{
+		Stream: authAuditKey,
+		MaxLen: authAuditMaxLen,
+		Approx: true,
+		Values: map[string]interface{}{
+			"type": event.Type,
+			"data": eventJSON,
+		},
+	}).Err()
+	if err != nil {
+		return models.NewRelayError(
+			models.ErrCodeStreamWrite,
+			"failed to record auth event",
+			err,
+		)
+	}
+
+	return nil
+}
+
+// ListAuthEvents returns up to count login audit events, newest first
+func (r *RedisClient) ListAuthEvents(ctx context.Context, count int64) ([]*models.AuthEvent, error) {
+	messages, err := r.client.XRevRangeN(ctx, authAuditKey, "+", "-", count).Result()
+	if err != nil && err != redis.Nil {
+		return nil, models.NewRelayError(
+			models.ErrCodeStreamRead,
+			"failed to read auth audit log",
+			err,
+		)
+	}
+
+	events := make([]*models.AuthEvent, 0, len(messages))
+	for _, msg := range messages {
+		data, ok := msg.Values["data"].(string)
+		if !ok {
+			continue
+		}
+
+		var event models.AuthEvent
+		if err := json.Unmarshal([]byte(data), &event); err != nil {
+			continue
+		}
+
+		events = append(events, &event)
+	}
+
+	return events, nil
+}
+
+// Signing key methods
+
+// signingKeysKey is the hash of JWT signing keys shared by every replica, by key ID
+const signingKeysKey = "jwt:signing-keys"
+
+// createSigningKeysScript stores the first signing key unless another replica already
+// created the key set
+var createSigningKeysScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 1 then
+	return 0
+end
+redis.call('HSET', KEYS[1], ARGV[1], ARGV[2])
+return 1
+`)
+
+// GetSigningKeys returns every signing key, including retired ones, oldest first
+func (r *RedisClient) GetSigningKeys(ctx context.Context) ([]models.SigningKey, error) {
+	fields, err := r.client.HGetAll(ctx, signingKeysKey).Result()
+	if err != nil {
+		return nil, models.NewRelayError(
+			models.ErrCodeRedisConnection,
+			"failed to retrieve signing keys",
+			err,
+		)
+	}
+
+	keys := make([]models.SigningKey, 0, len(fields))
+	for id, data := range fields {
+		var key models.SigningKey
+		if err := json.Unmarshal([]byte(data), &key); err != nil {
+			return nil, models.NewRelayError(
+				models.ErrCodeStreamRead,
+				fmt.Sprintf("failed to deserialize signing key %s", id),
+				err,
+			)
+		}
+		keys = append(keys, key)
+	}
+
+	sort.Slice(keys, func(i, j int) bool { return keys[i].CreatedAt.Before(keys[j].CreatedAt) })
+	return keys, nil
+}
+
+// CreateSigningKeys starts the key set with key and reports false, leaving the set
+// unchanged, if it already exists
+func (r *RedisClient) CreateSigningKeys(ctx context.Context, key models.SigningKey) (bool, error) {
+	keyJSON, err := json.Marshal(key)
+	if err != nil {
+		return false, models.NewRelayError(
+			models.ErrCodeStreamWrite,
+			"failed to serialize signing key",
+			err,
+		)
+	}
+
+	created, err := createSigningKeysScript.Run(ctx, r.client, []string{signingKeysKey}, key.ID, keyJSON).Int()
+	if err != nil {
+		return false, models.NewRelayError(
+			models.ErrCodeRedisConnection,
+			"failed to store signing key",
+			err,
+		)
+	}
+
+	return created == 1, nil
+}
+
+// StoreSigningKey adds or updates a signing key
+func (r *RedisClient) StoreSigningKey(ctx context.Context, key models.SigningKey) error {
+	keyJSON, err := json.Marshal(key)
+	if err != nil {
+		return models.NewRelayError(
+			models.ErrCodeStreamWrite,
+			"failed to serialize signing key",
+			err,
+		)
+	}
+
+	if err := r.client.HSet(ctx, signingKeysKey, key.ID, keyJSON).Err(); err != nil {
+		return models.NewRelayError(
+			models.ErrCodeRedisConnection,
+			"failed to store signing key",
+			err,
+		)
+	}
+
+	return nil
+}
+
+// Session methods
+
+func sessionKey(id string) string {
+	return fmt.Sprintf("session:%s", id)
+}
+
+func userSessionsKey(username string) string {
+	return fmt.Sprintf("sessions:user:%s", username)
+}
+
+// rotateSessionScript swaps a session's refresh token hash only if the presented
+// token is the current one, so a refresh token can be used exactly once
+var rotateSessionScript = redis.NewScript(`
+local current = redis.call('HGET', KEYS[1], 'refresh_hash')
+if not current then
+	return -1
+end
+if current ~= ARGV[1] then
+	return 0
+end
+redis.call('HSET', KEYS[1], 'refresh_hash', ARGV[2], 'refreshed_at', ARGV[3], 'expires_at', ARGV[4])
+redis.call('PEXPIREAT', KEYS[1], ARGV[4])
+return 1
+`)
+
+// CreateSession stores a new session until its expiry and indexes it under its user
+func (r *RedisClient) CreateSession(ctx context.Context, session *models.Session) error {
+	// Drop sessions of this user that have expired since they were indexed
+	ids, err := r.client.SMembers(ctx, userSessionsKey(session.Username)).Result()
+	if err != nil {
+		return models.NewRelayError(
+			models.ErrCodeRedisConnection,
+			"failed to read user sessions",
+			err,
+		)
+	}
+
+	pipe := r.client.TxPipeline()
+	for _, id := range ids {
+		if n, err := r.client.Exists(ctx, sessionKey(id)).Result(); err == nil && n == 0 {
+			pipe.SRem(ctx, userSessionsKey(session.Username), id)
+		}
+	}
+	pipe.HSet(ctx, sessionKey(session.ID),
+		"username", session.Username,
+		"refresh_hash", session.RefreshHash,
+		"created_at", session.CreatedAt.UnixMilli(),
+		"refreshed_at", session.RefreshedAt.UnixMilli(),
+		"expires_at", session.ExpiresAt.UnixMilli(),
+	)
+	pipe.PExpireAt(ctx, sessionKey(session.ID), session.ExpiresAt)
+	pipe.SAdd(ctx, userSessionsKey(session.Username), session.ID)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return models.NewRelayError(
+			models.ErrCodeRedisConnection,
+			"failed to store session",
+			err,
+		)
+	}
+
+	return nil
+}
+
+// GetSession retrieves a session by ID
+func (r *RedisClient) GetSession(ctx context.Context, id string) (*models.Session, error) {
+	fields, err := r.client.HGetAll(ctx, sessionKey(id)).Result()
+	if err != nil {
+		return nil, models.NewRelayError(
+			models.ErrCodeRedisConnection,
+			"failed to retrieve session",
+			err,
+		)
+	}
+	if len(fields) == 0 {
+		return nil, models.NewRelayError(
+			models.ErrCodeAuthentication,
+			"session not found",
+			nil,
+		)
+	}
+
+	millis := func(name string) time.Time {
+		ms, _ := strconv.ParseInt(fields[name], 10, 64)
+		return time.UnixMilli(ms)
+	}
+
+	return &models.Session{
+		ID:          id,
+		Username:    fields["username"],
+		RefreshHash: fields["refresh_hash"],
+		CreatedAt:   millis("created_at"),
+		RefreshedAt: millis("refreshed_at"),
+		ExpiresAt:   millis("expires_at"),
+	}, nil
+}
+
+// RotateSession replaces the session's refresh token hash and extends it to expiresAt.
+// It reports false when oldHash is not the current hash, i.e. a refresh token was reused.
+func (r *RedisClient) RotateSession(ctx context.Context, id, oldHash, newHash string, expiresAt time.Time) (bool, error) {
+	result, err := rotateSessionScript.Run(ctx, r.client,
+		[]string{sessionKey(id)},
+		oldHash, newHash, time.Now().UnixMilli(), expiresAt.UnixMilli(),
+	).Int()
+	if err != nil {
+		return false, models.NewRelayError(
+			models.ErrCodeRedisConnection,
+			"failed to rotate session",
+			err,
+		)
+	}
+
+	if result < 0 {
+		return false, models.NewRelayError(
+			models.ErrCodeAuthentication,
+			"session not found",
+			nil,
+		)
+	}
+
+	return result == 1, nil
+}
+
+// DeleteSession ends a session; access tokens issued for it stop working
+func (r *RedisClient) DeleteSession(ctx context.Context, session *models.Session) error {
+	pipe := r.client.TxPipeline()
+	pipe.Del(ctx, sessionKey(session.ID))
+	pipe.SRem(ctx, userSessionsKey(session.Username), session.ID)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return models.NewRelayError(
+			models.ErrCodeRedisConnection,
+			"failed to delete session",
 			err,
 		)
 	}
 
-	return r.GetAPIKey(ctx, id)
+	return nil
+}
+
+// DeleteUserSessions ends every session of a user except keep, which may be empty,
+// and returns how many were ended
+func (r *RedisClient) DeleteUserSessions(ctx context.Context, username, keep string) (int64, error) {
+	ids, err := r.client.SMembers(ctx, userSessionsKey(username)).Result()
+	if err != nil {
+		return 0, models.NewRelayError(
+			models.ErrCodeRedisConnection,
+			"failed to read user sessions",
+			err,
+		)
+	}
+
+	var keys, members []string
+	for _, id := range ids {
+		if id != keep {
+			keys = append(keys, sessionKey(id))
+			members = append(members, id)
+		}
+	}
+	if len(keys) == 0 {
+		return 0, nil
+	}
+
+	pipe := r.client.TxPipeline()
+	deleted := pipe.Del(ctx, keys...)
+	pipe.SRem(ctx, userSessionsKey(username), members)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, models.NewRelayError(
+			models.ErrCodeRedisConnection,
+			"failed to delete user sessions",
+			err,
+		)
+	}
+
+	return deleted.Val(), nil
+}
+
+// RevokeToken adds an access token ID to the revocation list until the token expires
+func (r *RedisClient) RevokeToken(ctx context.Context, tokenID string, expiresAt time.Time) error {
+	ttl := time.Until(expiresAt)
+	if ttl <= 0 {
+		return nil
+	}
+
+	if err := r.client.Set(ctx, fmt.Sprintf("revoked:token:%s", tokenID), 1, ttl).Err(); err != nil {
+		return models.NewRelayError(
+			models.ErrCodeRedisConnection,
+			"failed to revoke token",
+			err,
+		)
+	}
+
+	return nil
+}
+
+// IsTokenRevoked reports whether an access token was revoked or its session has ended
+func (r *RedisClient) IsTokenRevoked(ctx context.Context, claims *models.JWTClaims) (bool, error) {
+	pipe := r.client.Pipeline()
+	revoked := pipe.Exists(ctx, fmt.Sprintf("revoked:token:%s", claims.TokenID))
+	session := pipe.Exists(ctx, sessionKey(claims.SessionID))
+	if _, err := pipe.Exec(ctx); err != nil {
+		return false, models.NewRelayError(
+			models.ErrCodeRedisConnection,
+			"failed to check token revocation",
+			err,
+		)
+	}
+
+	return revoked.Val() > 0 || session.Val() == 0, nil
+}
+
+// API Key management methods
+
+// CreateAPIKey creates a new API key
+func (r *RedisClient) CreateAPIKey(ctx context.Context, apiKey *models.APIKey) error {
+	apiKeyJSON, err := json.Marshal(apiKey)
+	if err != nil {
+		return models.NewRelayError(
+			models.ErrCodeStreamWrite,
+			"failed to serialize API key",
+			err,
+		)
+	}
+
+	// Store by ID
+	key := fmt.Sprintf("apikey:%s", apiKey.ID)
+	if err := r.client.Set(ctx, key, apiKeyJSON, 0).Err(); err != nil {
+		return models.NewRelayError(
+			models.ErrCodeRedisConnection,
+			"failed to store API key",
+			err,
+		)
+	}
+
+	// Store by key value for quick lookup
+	lookupKey := fmt.Sprintf("apikey:lookup:%s", apiKey.Key)
+	if err := r.client.Set(ctx, lookupKey, apiKey.ID, 0).Err(); err != nil {
+		return models.NewRelayError(
+			models.ErrCodeRedisConnection,
+			"failed to store API key lookup",
+			err,
+		)
+	}
+
+	// Add to platform index
+	platformKey := fmt.Sprintf("apikey:platform:%s", apiKey.Platform)
+	if err := r.client.SAdd(ctx, platformKey, apiKey.ID).Err(); err != nil {
+		return models.NewRelayError(
+			models.ErrCodeRedisConnection,
+			"failed to add to platform index",
+			err,
+		)
+	}
+
+	return nil
+}
+
+// GetAPIKey retrieves an API key by ID
+func (r *RedisClient) GetAPIKey(ctx context.Context, id string) (*models.APIKey, error) {
+	key := fmt.Sprintf("apikey:%s", id)
+	data, err := r.client.Get(ctx, key).Result()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, models.NewRelayError(
+				models.ErrCodeInvalidRequest,
+				"API key not found",
+				nil,
+			)
+		}
+		return nil, models.NewRelayError(
+			models.ErrCodeRedisConnection,
+			"failed to retrieve API key",
+			err,
+		)
+	}
+
+	var apiKey models.APIKey
+	if err := json.Unmarshal([]byte(data), &apiKey); err != nil {
+		return nil, models.NewRelayError(
+			models.ErrCodeStreamRead,
+			"failed to unmarshal API key",
+			err,
+		)
+	}
+
+	return &apiKey, nil
+}
+
+// GetAPIKeyByValue retrieves an API key by its value
+func (r *RedisClient) GetAPIKeyByValue(ctx context.Context, key string) (*models.APIKey, error) {
+	lookupKey := fmt.Sprintf("apikey:lookup:%s", key)
+	id, err := r.client.Get(ctx, lookupKey).Result()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, models.NewRelayError(
+				models.ErrCodeAuthentication,
+				"API key not found",
+				nil,
+			)
+		}
+		return nil, models.NewRelayError(
+			models.ErrCodeRedisConnection,
+			"failed to lookup API key",
+			err,
+		)
+	}
+
+	return r.GetAPIKey(ctx, id)
+}
+
+// ListAPIKeys lists all API keys
+func (r *RedisClient) ListAPIKeys(ctx context.Context) ([]*models.APIKey, error) {
+	iter := r.client.Scan(ctx, 0, "apikey:*", 0).Iterator()
+	var apiKeys []*models.APIKey
+
+	for iter.Next(ctx) {
+		key := iter.Val()
+		if strings.Contains(key, ":lookup:") || strings.Contains(key, ":platform:") {
+			continue
+		}
+
+		data, err := r.client.Get(ctx, key).Result()
+		if err != nil {
+			continue
+		}
+
+		var apiKey models.APIKey
+		if err := json.Unmarshal([]byte(data), &apiKey); err != nil {
+			continue
+		}
+
+		apiKeys = append(apiKeys, &apiKey)
+	}
+
+	if err := iter.Err(); err != nil {
+		return nil, models.NewRelayError(
+			models.ErrCodeRedisConnection,
+			"failed to scan API keys",
+			err,
+		)
+	}
+
+	return apiKeys, nil
+}
+
+// UpdateAPIKey updates an existing API key
+func (r *RedisClient) UpdateAPIKey(ctx context.Context, apiKey *models.APIKey) error {
+	apiKey.UpdatedAt = time.Now()
+	return r.CreateAPIKey(ctx, apiKey)
+}
+
+// DeleteAPIKey deletes an API key
+func (r *RedisClient) DeleteAPIKey(ctx context.Context, id string) error {
+	// Get the API key first to get its value and platform
+	apiKey, err := r.GetAPIKey(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	// Delete by ID
+	key := fmt.Sprintf("apikey:%s", id)
+	if err := r.client.Del(ctx, key).Err(); err != nil {
+		return models.NewRelayError(
+			models.ErrCodeRedisConnection,
+			"failed to delete API key",
+			err,
+		)
+	}
+
+	// Delete lookup
+	lookupKey := fmt.Sprintf("apikey:lookup:%s", apiKey.Key)
+	if err := r.client.Del(ctx, lookupKey).Err(); err != nil {
+		return models.NewRelayError(
+			models.ErrCodeRedisConnection,
+			"failed to delete API key lookup",
+			err,
+		)
+	}
+
+	// Remove from platform index
+	platformKey := fmt.Sprintf("apikey:platform:%s", apiKey.Platform)
+	if err := r.client.SRem(ctx, platformKey, id).Err(); err != nil {
+		return models.NewRelayError(
+			models.ErrCodeRedisConnection,
+			"failed to remove from platform index",
+			err,
+		)
+	}
+
+	return nil
+}
+
+// Webhook Endpoint management methods
+
+// CreateEndpoint creates a new webhook endpoint
+func (r *RedisClient) CreateEndpoint(ctx context.Context, endpoint *models.WebhookEndpoint) error {
+	endpointJSON, err := json.Marshal(endpoint)
+	if err != nil {
+		return models.NewRelayError(
+			models.ErrCodeStreamWrite,
+			"failed to serialize endpoint",
+			err,
+		)
+	}
+
+	// Store by ID
+	key := fmt.Sprintf("endpoint:%s", endpoint.ID)
+	if err := r.client.Set(ctx, key, endpointJSON, 0).Err(); err != nil {
+		return models.NewRelayError(
+			models.ErrCodeRedisConnection,
+			"failed to store endpoint",
+			err,
+		)
+	}
+
+	// Store by path for routing lookup
+	pathKey := fmt.Sprintf("endpoint:path:%s", endpoint.Path)
+	if err := r.client.Set(ctx, pathKey, endpoint.ID, 0).Err(); err != nil {
+		return models.NewRelayError(
+			models.ErrCodeRedisConnection,
+			"failed to store endpoint path lookup",
+			err,
+		)
+	}
+
+	return nil
+}
+
+// GetEndpoint retrieves an endpoint by ID
+func (r *RedisClient) GetEndpoint(ctx context.Context, id string) (*models.WebhookEndpoint, error) {
+	key := fmt.Sprintf("endpoint:%s", id)
+	data, err := r.client.Get(ctx, key).Result()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, models.NewRelayError(
+				models.ErrCodeInvalidRequest,
+				"endpoint not found",
+				nil,
+			)
+		}
+		return nil, models.NewRelayError(
+			models.ErrCodeRedisConnection,
+			"failed to retrieve endpoint",
+			err,
+		)
+	}
+
+	var endpoint models.WebhookEndpoint
+	if err := json.Unmarshal([]byte(data), &endpoint); err != nil {
+		return nil, models.NewRelayError(
+			models.ErrCodeStreamRead,
+			"failed to unmarshal endpoint",
+			err,
+		)
+	}
+
+	return &endpoint, nil
+}
+
+// GetEndpointByPath retrieves an endpoint by its path
+func (r *RedisClient) GetEndpointByPath(ctx context.Context, path string) (*models.WebhookEndpoint, error) {
+	pathKey := fmt.Sprintf("endpoint:path:%s", path)
+	id, err := r.client.Get(ctx, pathKey).Result()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, models.NewRelayError(
+				models.ErrCodeInvalidRequest,
+				"endpoint not found for path",
+				nil,
+			)
+		}
+		return nil, models.NewRelayError(
+			models.ErrCodeRedisConnection,
+			"failed to lookup endpoint by path",
+			err,
+		)
+	}
+
+	return r.GetEndpoint(ctx, id)
+}
+
+// ListEndpoints lists all endpoints
+func (r *RedisClient) ListEndpoints(ctx context.Context) ([]*models.WebhookEndpoint, error) {
+	iter := r.client.Scan(ctx, 0, "endpoint:*", 0).Iterator()
+	var endpoints []*models.WebhookEndpoint
+
+	for iter.Next(ctx) {
+		key := iter.Val()
+		if strings.Contains(key, ":path:") {
+			continue
+		}
+
+		data, err := r.client.Get(ctx, key).Result()
+		if err != nil {
+			continue
+		}
+
+		var endpoint models.WebhookEndpoint
+		if err := json.Unmarshal([]byte(data), &endpoint); err != nil {
+			continue
+		}
+
+		endpoints = append(endpoints, &endpoint)
+	}
+
+	if err := iter.Err(); err != nil {
+		return nil, models.NewRelayError(
+			models.ErrCodeRedisConnection,
+			"failed to scan endpoints",
+			err,
+		)
+	}
+
+	return endpoints, nil
+}
+
+// UpdateEndpoint updates an existing endpoint
+func (r *RedisClient) UpdateEndpoint(ctx context.Context, endpoint *models.WebhookEndpoint) error {
+	endpoint.UpdatedAt = time.Now()
+	return r.CreateEndpoint(ctx, endpoint)
+}
+
+// DeleteEndpoint deletes an endpoint
+func (r *RedisClient) DeleteEndpoint(ctx context.Context, id string) error {
+	// Get the endpoint first to get its path
+	endpoint, err := r.GetEndpoint(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	// Delete by ID
+	key := fmt.Sprintf("endpoint:%s", id)
+	if err := r.client.Del(ctx, key).Err(); err != nil {
+		return models.NewRelayError(
+			models.ErrCodeRedisConnection,
+			"failed to delete endpoint",
+			err,
+		)
+	}
+
+	// Delete path lookup
+	pathKey := fmt.Sprintf("endpoint:path:%s", endpoint.Path)
+	if err := r.client.Del(ctx, pathKey).Err(); err != nil {
+		return models.NewRelayError(
+			models.ErrCodeRedisConnection,
+			"failed to delete endpoint path lookup",
+			err,
+		)
+	}
+
+	return nil
+}
+
+// ResetEndpointRetryConfig clears an endpoint's retry config if it still equals
+// expected. It reports whether the endpoint was changed; a concurrent update or
+// delete leaves it untouched.
+func (r *RedisClient) ResetEndpointRetryConfig(ctx context.Context, id string, expected models.RetryConfig) (bool, error) {
+	key := fmt.Sprintf("endpoint:%s", id)
+	reset := false
+
+	err := r.client.Watch(ctx, func(tx *redis.Tx) error {
+		data, err := tx.Get(ctx, key).Result()
+		if err != nil {
+			return err
+		}
+
+		var endpoint models.WebhookEndpoint
+		if err := json.Unmarshal([]byte(data), &endpoint); err != nil {
+			return err
+		}
+		if endpoint.RetryConfig != expected {
+			return nil
+		}
+
+		endpoint.RetryConfig = models.RetryConfig{}
+		endpointJSON, err := json.Marshal(&endpoint)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Set(ctx, key, endpointJSON, 0)
+			return nil
+		})
+		reset = err == nil
+		return err
+	}, key)
+	if err == redis.Nil || err == redis.TxFailedErr {
+		return false, nil
+	}
+	if err != nil {
+		return false, models.NewRelayError(
+			models.ErrCodeRedisConnection,
+			"failed to reset endpoint retry config",
+			err,
+		)
+	}
+
+	return reset, nil
+}
+
+// Migration methods
+
+// migrationKey returns the key marking a one-time data migration as done
+func migrationKey(name string) string {
+	return fmt.Sprintf("migration:%s", name)
+}
+
+// IsMigrated reports whether the named migration has already run against this Redis
+func (r *RedisClient) IsMigrated(ctx context.Context, name string) (bool, error) {
+	exists, err := r.client.Exists(ctx, migrationKey(name)).Result()
+	if err != nil {
+		return false, models.NewRelayError(
+			models.ErrCodeRedisConnection,
+			"failed to check migration",
+			err,
+		)
+	}
+	return exists > 0, nil
+}
+
+// MarkMigrated records that the named migration has run
+func (r *RedisClient) MarkMigrated(ctx context.Context, name string) error {
+	if err := r.client.Set(ctx, migrationKey(name), time.Now().Format(time.RFC3339), 0).Err(); err != nil {
+		return models.NewRelayError(
+			models.ErrCodeRedisConnection,
+			"failed to record migration",
+			err,
+		)
+	}
+	return nil
+}
+
+// Route management methods
+
+// CreateRoute creates a new client-side route
+func (r *RedisClient) CreateRoute(ctx context.Context, route *models.Route) error {
+	routeJSON, err := json.Marshal(route)
+	if err != nil {
+		return models.NewRelayError(
+			models.ErrCodeStreamWrite,
+			"failed to serialize route",
+			err,
+		)
+	}
+
+	key := fmt.Sprintf("route:%s", route.ID)
+	if err := r.client.Set(ctx, key, routeJSON, 0).Err(); err != nil {
+		return models.NewRelayError(
+			models.ErrCodeRedisConnection,
+			"failed to store route",
+			err,
+		)
+	}
+
+	return nil
+}
+
+// GetRoute retrieves a route by ID
+func (r *RedisClient) GetRoute(ctx context.Context, id string) (*models.Route, error) {
+	key := fmt.Sprintf("route:%s", id)
+	data, err := r.client.Get(ctx, key).Result()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, models.NewRelayError(
+				models.ErrCodeInvalidRequest,
+				"route not found",
+				nil,
+			)
+		}
+		return nil, models.NewRelayError(
+			models.ErrCodeRedisConnection,
+			"failed to retrieve route",
+			err,
+		)
+	}
+
+	var route models.Route
+	if err := json.Unmarshal([]byte(data), &route); err != nil {
+		return nil, models.NewRelayError(
+			models.ErrCodeStreamRead,
+			"failed to unmarshal route",
+			err,
+		)
+	}
+
+	return &route, nil
+}
+
+// ListRoutes lists all routes
+func (r *RedisClient) ListRoutes(ctx context.Context) ([]*models.Route, error) {
+	iter := r.client.Scan(ctx, 0, "route:*", 0).Iterator()
+	var routes []*models.Route
+
+	for iter.Next(ctx) {
+		data, err := r.client.Get(ctx, iter.Val()).Result()
+		if err != nil {
+			continue
+		}
+
+		var route models.Route
+		if err := json.Unmarshal([]byte(data), &route); err != nil {
+			continue
+		}
+
+		routes = append(routes, &route)
+	}
+
+	if err := iter.Err(); err != nil {
+		return nil, models.NewRelayError(
+			models.ErrCodeRedisConnection,
+			"failed to scan routes",
+			err,
+		)
+	}
+
+	return routes, nil
+}
+
+// UpdateRoute updates an existing route
+func (r *RedisClient) UpdateRoute(ctx context.Context, route *models.Route) error {
+	route.UpdatedAt = time.Now()
+	return r.CreateRoute(ctx, route)
+}
+
+// DeleteRoute deletes a route
+func (r *RedisClient) DeleteRoute(ctx context.Context, id string) error {
+	key := fmt.Sprintf("route:%s", id)
+	deleted, err := r.client.Del(ctx, key).Result()
+	if err != nil {
+		return models.NewRelayError(
+			models.ErrCodeRedisConnection,
+			"failed to delete route",
+			err,
+		)
+	}
+
+	if deleted == 0 {
+		return models.NewRelayError(
+			models.ErrCodeInvalidRequest,
+			"route not found",
+			nil,
+		)
+	}
+
+	return nil
+}
+
+// Idempotency methods
+
+// idempotencyKey returns the Redis key for an idempotency key within a scope.
+// Keys are hashed so arbitrary header or body values stay bounded.
+func idempotencyKey(scope, key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return fmt.Sprintf("idempotency:%s:%s", scope, hex.EncodeToString(sum[:]))
+}
+
+// ReserveIdempotencyKey claims an idempotency key for a webhook. If the key was already
+// claimed, the stored record of the original delivery is returned instead.
+func (r *RedisClient) ReserveIdempotencyKey(ctx context.Context, scope, key, webhookID string, ttl time.Duration) (*models.IdempotencyRecord, error) {
+	recordJSON, err := json.Marshal(models.IdempotencyRecord{WebhookID: webhookID})
+	if err != nil {
+		return nil, models.NewRelayError(
+			models.ErrCodeStreamWrite,
+			"failed to serialize idempotency record",
+			err,
+		)
+	}
+
+	redisKey := idempotencyKey(scope, key)
+
+	// Retry once in case the existing key expires between SET NX and GET
+	for attempt := 0; attempt < 2; attempt++ {
+		reserved, err := r.client.SetNX(ctx, redisKey, recordJSON, ttl).Result()
+		if err != nil {
+			return nil, models.NewRelayError(
+				models.ErrCodeRedisConnection,
+				"failed to reserve idempotency key",
+				err,
+			)
+		}
+		if reserved {
+			return nil, nil
+		}
+
+		data, err := r.client.Get(ctx, redisKey).Result()
+		if err == redis.Nil {
+			continue
+		}
+		if err != nil {
+			return nil, models.NewRelayError(
+				models.ErrCodeRedisConnection,
+				"failed to get idempotency record",
+				err,
+			)
+		}
+
+		var record models.IdempotencyRecord
+		if err := json.Unmarshal([]byte(data), &record); err != nil {
+			return nil, models.NewRelayError(
+				models.ErrCodeStreamRead,
+				"failed to deserialize idempotency record",
+				err,
+			)
+		}
+		return &record, nil
+	}
+
+	return nil, models.NewRelayError(
+		models.ErrCodeRedisConnection,
+		"failed to reserve idempotency key",
+		nil,
+	)
+}
+
+// CompleteIdempotencyKey records the stream message created for a reserved key, keeping its TTL
+func (r *RedisClient) CompleteIdempotencyKey(ctx context.Context, scope, key string, record *models.IdempotencyRecord) error {
+	recordJSON, err := json.Marshal(record)
+	if err != nil {
+		return models.NewRelayError(
+			models.ErrCodeStreamWrite,
+			"failed to serialize idempotency record",
+			err,
+		)
+	}
+
+	err = r.client.SetArgs(ctx, idempotencyKey(scope, key), recordJSON, redis.SetArgs{
+		Mode:    "XX",
+		KeepTTL: true,
+	}).Err()
+	if err != nil && err != redis.Nil {
+		return models.NewRelayError(
+			models.ErrCodeRedisConnection,
+			"failed to update idempotency record",
+			err,
+		)
+	}
+
+	return nil
+}
+
+// ReleaseIdempotencyKey removes a reservation so that a failed delivery can be retried
+func (r *RedisClient) ReleaseIdempotencyKey(ctx context.Context, scope, key string) error {
+	if err := r.client.Del(ctx, idempotencyKey(scope, key)).Err(); err != nil {
+		return models.NewRelayError(
+			models.ErrCodeRedisConnection,
+			"failed to release idempotency key",
+			err,
+		)
+	}
+	return nil
+}
+
+// InitializeDefaultUser creates the default admin user and reports false if the
+// username is already taken
+func (r *RedisClient) InitializeDefaultUser(ctx context.Context, username, passwordHash string) (bool, error) {
+	// Create default admin user
+	user := &models.User{
+		ID:           "admin",
+		Username:     username,
+		PasswordHash: passwordHash,
+		Role:         models.RoleAdmin,
+		CreatedAt:    time.Now(),
+		UpdatedAt:    time.Now(),
+	}
+
+	// Replicas starting together race to create the admin; only one succeeds
+	if err := r.CreateUser(ctx, user); err != nil {
+		if err.(*models.RelayError).Code == models.ErrCodeInvalidRequest {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return true, nil
+}
+
+// Dead Letter Queue methods
+
+// dlqScanBatch is how many DLQ entries are fetched per XREVRANGE call while filtering
+const dlqScanBatch = 200
+
+// dlqScanLimit bounds how many DLQ entries a single page may examine, so that selective
+// filters over a large DLQ return a cursor instead of scanning the whole stream
+const dlqScanLimit = 5000
+
+// ListDLQ returns up to limit DLQ entries matching the filter, newest first, starting
+// after the cursor (a DLQ stream ID). The returned cursor is empty when no entries remain.
+func (r *RedisClient) ListDLQ(ctx context.Context, filter models.DLQFilter, cursor string, limit int) ([]*models.DLQEntry, string, error) {
+	return r.scanDLQ(ctx, filter, cursor, limit, dlqScanLimit, true)
 }
 
-// ListAPIKeys lists all API keys
-func (r *RedisClient) ListAPIKeys(ctx context.Context) ([]*models.APIKey, error) {
-	iter := r.client.Scan(ctx, 0, "apikey:*", 0).Iterator()
-	var apiKeys []*models.APIKey
+// ScanDLQ is like ListDLQ but walks the DLQ oldest first, which keeps bulk replays in
+// their original order
+func (r *RedisClient) ScanDLQ(ctx context.Context, filter models.DLQFilter, cursor string, limit int) ([]*models.DLQEntry, string, error) {
+	return r.scanDLQ(ctx, filter, cursor, limit, dlqScanLimit, false)
+}
 
-	for iter.Next(ctx) {
-		key := iter.Val()
-		if strings.Contains(key, ":lookup:") || strings.Contains(key, ":platform:") {
-			continue
+// CountDLQ counts the DLQ entries matching the filter
+func (r *RedisClient) CountDLQ(ctx context.Context, filter models.DLQFilter) (int64, error) {
+	var count int64
+	cursor := ""
+	for {
+		entries, next, err := r.scanDLQ(ctx, filter, cursor, dlqScanLimit, dlqScanLimit, false)
+		if err != nil {
+			return 0, err
+		}
+		count += int64(len(entries))
+		if next == "" {
+			return count, nil
 		}
+		cursor = next
+	}
+}
 
-		data, err := r.client.Get(ctx, key).Result()
-		if err != nil {
-			continue
+// scanDLQ walks the DLQ from the cursor in either direction, returning up to limit
+// matching entries and examining at most scanLimit entries
+func (r *RedisClient) scanDLQ(ctx context.Context, filter models.DLQFilter, cursor string, limit, scanLimit int, reverse bool) ([]*models.DLQEntry, string, error) {
+	if cursor != "" && !isStreamID(cursor) {
+		return nil, "", models.NewRelayError(
+			models.ErrCodeInvalidRequest,
+			"invalid cursor",
+			nil,
+		)
+	}
+
+	// Stream IDs start with the insertion time, so the time range bounds the scan
+	lower := "-"
+	if !filter.Since.IsZero() {
+		lower = strconv.FormatInt(filter.Since.UnixMilli(), 10)
+	}
+	upper := "+"
+	if !filter.Until.IsZero() {
+		upper = strconv.FormatInt(filter.Until.UnixMilli(), 10)
+	}
+	if cursor != "" {
+		if reverse {
+			upper = "(" + cursor
+		} else {
+			lower = "(" + cursor
 		}
+	}
 
-		var apiKey models.APIKey
-		if err := json.Unmarshal([]byte(data), &apiKey); err != nil {
-			continue
+	entries := make([]*models.DLQEntry, 0, limit)
+	scanned := 0
+	last := ""
+	for scanned < scanLimit {
+		var messages []redis.XMessage
+		var err error
+		if reverse {
+			messages, err = r.client.XRevRangeN(ctx, r.config.DeadLetterQueue, upper, lower, dlqScanBatch).Result()
+		} else {
+			messages, err = r.client.XRangeN(ctx, r.config.DeadLetterQueue, lower, upper, dlqScanBatch).Result()
+		}
+		if err != nil && err != redis.Nil {
+			return nil, "", models.NewRelayError(
+				models.ErrCodeStreamRead,
+				"failed to read DLQ messages",
+				err,
+			)
 		}
 
-		apiKeys = append(apiKeys, &apiKey)
-	}
+		for _, msg := range messages {
+			scanned++
+			last = msg.ID
+			if reverse {
+				upper = "(" + msg.ID
+			} else {
+				lower = "(" + msg.ID
+			}
 
-	if err := iter.Err(); err != nil {
-		return nil, models.NewRelayError(
-			models.ErrCodeRedisConnection,
-			"failed to scan API keys",
-			err,
-		)
+			entry, err := parseDLQEntry(msg)
+			if err != nil || !filter.Matches(entry) {
+				continue
+			}
+
+			entries = append(entries, entry)
+			if len(entries) == limit {
+				return entries, msg.ID, nil
+			}
+		}
+
+		if len(messages) < dlqScanBatch {
+			return entries, "", nil
+		}
 	}
 
-	return apiKeys, nil
+	// Scan budget exhausted; let the caller continue from where we stopped
+	return entries, last, nil
 }
 
-// UpdateAPIKey updates an existing API key
-func (r *RedisClient) UpdateAPIKey(ctx context.Context, apiKey *models.APIKey) error {
-	apiKey.UpdatedAt = time.Now()
-	return r.CreateAPIKey(ctx, apiKey)
-}
+// GetDLQMessage retrieves a specific message from the DLQ by its DLQ stream ID
+func (r *RedisClient) GetDLQMessage(ctx context.Context, messageID string) (*models.DLQEntry, error) {
+	notFound := models.NewRelayError(
+		models.ErrCodeInvalidRequest,
+		"message not found in DLQ",
+		nil,
+	)
 
-// DeleteAPIKey deletes an API key
-func (r *RedisClient) DeleteAPIKey(ctx context.Context, id string) error {
-	// Get the API key first to get its value and platform
-	apiKey, err := r.GetAPIKey(ctx, id)
-	if err != nil {
-		return err
+	if !isStreamID(messageID) {
+		return nil, notFound
 	}
 
-	// Delete by ID
-	key := fmt.Sprintf("apikey:%s", id)
-	if err := r.client.Del(ctx, key).Err(); err != nil {
-		return models.NewRelayError(
-			models.ErrCodeRedisConnection,
-			"failed to delete API key",
+	messages, err := r.client.XRange(ctx, r.config.DeadLetterQueue, messageID, messageID).Result()
+	if err != nil {
+		return nil, models.NewRelayError(
+			models.ErrCodeStreamRead,
+			"failed to read DLQ",
 			err,
 		)
 	}
 
-	// Delete lookup
-	lookupKey := fmt.Sprintf("apikey:lookup:%s", apiKey.Key)
-	if err := r.client.Del(ctx, lookupKey).Err(); err != nil {
-		return models.NewRelayError(
-			models.ErrCodeRedisConnection,
-			"failed to delete API key lookup",
-			err,
-		)
+	if len(messages) == 0 {
+		return nil, notFound
 	}
 
-	// Remove from platform index
-	platformKey := fmt.Sprintf("apikey:platform:%s", apiKey.Platform)
-	if err := r.client.SRem(ctx, platformKey, id).Err(); err != nil {
-		return models.NewRelayError(
-			models.ErrCodeRedisConnection,
-			"failed to remove from platform index",
+	entry, err := parseDLQEntry(messages[0])
+	if err != nil {
+		return nil, models.NewRelayError(
+			models.ErrCodeStreamRead,
+			"failed to parse DLQ message",
 			err,
 		)
 	}
 
-	return nil
+	return entry, nil
 }
 
-// Webhook Endpoint management methods
+// dlqJobTTL is how long finished and running job records are kept
+const dlqJobTTL = 7 * 24 * time.Hour
 
-// CreateEndpoint creates a new webhook endpoint
-func (r *RedisClient) CreateEndpoint(ctx context.Context, endpoint *models.WebhookEndpoint) error {
-	endpointJSON, err := json.Marshal(endpoint)
+// SaveDLQJob creates or updates a bulk DLQ job record
+func (r *RedisClient) SaveDLQJob(ctx context.Context, job *models.DLQJob) error {
+	jobJSON, err := json.Marshal(job)
 	if err != nil {
 		return models.NewRelayError(
 			models.ErrCodeStreamWrite,
-			"failed to serialize endpoint",
+			"failed to serialize DLQ job",
 			err,
 		)
 	}
 
-	// Store by ID
-	key := fmt.Sprintf("endpoint:%s", endpoint.ID)
-	if err := r.client.Set(ctx, key, endpointJSON, 0).Err(); err != nil {
-		return models.NewRelayError(
-			models.ErrCodeRedisConnection,
-			"failed to store endpoint",
-			err,
-		)
-	}
-
-	// Store by path for routing lookup
-	pathKey := fmt.Sprintf("endpoint:path:%s", endpoint.Path)
-	if err := r.client.Set(ctx, pathKey, endpoint.ID, 0).Err(); err != nil {
+	key := fmt.Sprintf("dlqjob:%s", job.ID)
+	if err := r.client.Set(ctx, key, jobJSON, dlqJobTTL).Err(); err != nil {
 		return models.NewRelayError(
 			models.ErrCodeRedisConnection,
-			"failed to store endpoint path lookup",
+			"failed to store DLQ job",
 			err,
 		)
 	}
@@ -523,124 +2253,120 @@ func (r *RedisClient) CreateEndpoint(ctx context.Context, endpoint *models.Webho
 	return nil
 }
 
-// GetEndpoint retrieves an endpoint by ID
-func (r *RedisClient) GetEndpoint(ctx context.Context, id string) (*models.WebhookEndpoint, error) {
-	key := fmt.Sprintf("endpoint:%s", id)
+// GetDLQJob retrieves a bulk DLQ job by ID
+func (r *RedisClient) GetDLQJob(ctx context.Context, id string) (*models.DLQJob, error) {
+	key := fmt.Sprintf("dlqjob:%s", id)
 	data, err := r.client.Get(ctx, key).Result()
 	if err != nil {
 		if err == redis.Nil {
 			return nil, models.NewRelayError(
 				models.ErrCodeInvalidRequest,
-				"endpoint not found",
+				"DLQ job not found",
 				nil,
 			)
 		}
 		return nil, models.NewRelayError(
 			models.ErrCodeRedisConnection,
-			"failed to retrieve endpoint",
+			"failed to get DLQ job",
 			err,
 		)
 	}
 
-	var endpoint models.WebhookEndpoint
-	if err := json.Unmarshal([]byte(data), &endpoint); err != nil {
+	var job models.DLQJob
+	if err := json.Unmarshal([]byte(data), &job); err != nil {
 		return nil, models.NewRelayError(
 			models.ErrCodeStreamRead,
-			"failed to unmarshal endpoint",
-			err,
-		)
-	}
-
-	return &endpoint, nil
-}
-
-// GetEndpointByPath retrieves an endpoint by its path
-func (r *RedisClient) GetEndpointByPath(ctx context.Context, path string) (*models.WebhookEndpoint, error) {
-	pathKey := fmt.Sprintf("endpoint:path:%s", path)
-	id, err := r.client.Get(ctx, pathKey).Result()
-	if err != nil {
-		if err == redis.Nil {
-			return nil, models.NewRelayError(
-				models.ErrCodeInvalidRequest,
-				"endpoint not found for path",
-				nil,
-			)
-		}
-		return nil, models.NewRelayError(
-			models.ErrCodeRedisConnection,
-			"failed to lookup endpoint by path",
+			"failed to deserialize DLQ job",
 			err,
 		)
 	}
 
-	return r.GetEndpoint(ctx, id)
+	return &job, nil
 }
 
-// ListEndpoints lists all endpoints
-func (r *RedisClient) ListEndpoints(ctx context.Context) ([]*models.WebhookEndpoint, error) {
-	iter := r.client.Scan(ctx, 0, "endpoint:*", 0).Iterator()
-	var endpoints []*models.WebhookEndpoint
+// ListDLQJobs lists all bulk DLQ jobs that have not expired
+func (r *RedisClient) ListDLQJobs(ctx context.Context) ([]*models.DLQJob, error) {
+	iter := r.client.Scan(ctx, 0, "dlqjob:*", 0).Iterator()
+	var jobs []*models.DLQJob
 
 	for iter.Next(ctx) {
 		key := iter.Val()
-		if strings.Contains(key, ":path:") {
+		if strings.HasSuffix(key, ":cancel") {
 			continue
 		}
 
-		data, err := r.client.Get(ctx, key).Result()
+		job, err := r.GetDLQJob(ctx, strings.TrimPrefix(key, "dlqjob:"))
 		if err != nil {
 			continue
 		}
-
-		var endpoint models.WebhookEndpoint
-		if err := json.Unmarshal([]byte(data), &endpoint); err != nil {
-			continue
-		}
-
-		endpoints = append(endpoints, &endpoint)
+		jobs = append(jobs, job)
 	}
 
 	if err := iter.Err(); err != nil {
 		return nil, models.NewRelayError(
 			models.ErrCodeRedisConnection,
-			"failed to scan endpoints",
+			"failed to list DLQ jobs",
 			err,
 		)
 	}
 
-	return endpoints, nil
+	return jobs, nil
 }
 
-// UpdateEndpoint updates an existing endpoint
-func (r *RedisClient) UpdateEndpoint(ctx context.Context, endpoint *models.WebhookEndpoint) error {
-	endpoint.UpdatedAt = time.Now()
-	return r.CreateEndpoint(ctx, endpoint)
+// CancelDLQJob flags a job for cancellation; the runner checks the flag while it works through entries
+func (r *RedisClient) CancelDLQJob(ctx context.Context, id string) error {
+	key := fmt.Sprintf("dlqjob:%s:cancel", id)
+	if err := r.client.Set(ctx, key, 1, dlqJobTTL).Err(); err != nil {
+		return models.NewRelayError(
+			models.ErrCodeRedisConnection,
+			"failed to cancel DLQ job",
+			err,
+		)
+	}
+	return nil
 }
 
-// DeleteEndpoint deletes an endpoint
-func (r *RedisClient) DeleteEndpoint(ctx context.Context, id string) error {
-	// Get the endpoint first to get its path
-	endpoint, err := r.GetEndpoint(ctx, id)
+// IsDLQJobCancelled reports whether cancellation was requested for a job
+func (r *RedisClient) IsDLQJobCancelled(ctx context.Context, id string) (bool, error) {
+	key := fmt.Sprintf("dlqjob:%s:cancel", id)
+	exists, err := r.client.Exists(ctx, key).Result()
 	if err != nil {
-		return err
+		return false, models.NewRelayError(
+			models.ErrCodeRedisConnection,
+			"failed to check DLQ job cancellation",
+			err,
+		)
 	}
+	return exists > 0, nil
+}
 
-	// Delete by ID
-	key := fmt.Sprintf("endpoint:%s", id)
-	if err := r.client.Del(ctx, key).Err(); err != nil {
+// replayAuditStream is the stream linking replayed DLQ entries to their new stream IDs
+func (r *RedisClient) replayAuditStream() string {
+	return r.config.DeadLetterQueue + ":audit"
+}
+
+// RecordReplay appends an entry to the replay audit log
+func (r *RedisClient) RecordReplay(ctx context.Context, audit *models.ReplayAudit) error {
+	auditJSON, err := json.Marshal(audit)
+	if err != nil {
 		return models.NewRelayError(
-			models.ErrCodeRedisConnection,
-			"failed to delete endpoint",
+			models.ErrCodeStreamWrite,
+			"failed to serialize replay audit",
 			err,
 		)
 	}
 
-	// Delete path lookup
-	pathKey := fmt.Sprintf("endpoint:path:%s", endpoint.Path)
-	if err := r.client.Del(ctx, pathKey).Err(); err != nil {
+	err = r.client.XAdd(ctx, &redis.XAddArgs{
+		Stream: r.replayAuditStream(),
+		Values: map[string]interface{}{
+			"dlq_id": audit.DLQID,
+			"data":   auditJSON,
+		},
+	}).Err()
+	if err != nil {
 		return models.NewRelayError(
-			models.ErrCodeRedisConnection,
-			"failed to delete endpoint path lookup",
+			models.ErrCodeStreamWrite,
+			"failed to record replay audit",
 			err,
 		)
 	}
@@ -648,96 +2374,97 @@ func (r *RedisClient) DeleteEndpoint(ctx context.Context, id string) error {
 	return nil
 }
 
-// InitializeDefaultUser creates a default admin user if none exists
-func (r *RedisClient) InitializeDefaultUser(ctx context.Context, username, passwordHash string) error {
-	// Check if user already exists
-	_, err := r.GetUser(ctx, username)
-	if err == nil {
-		// User already exists
-		return nil
-	}
+// replayScanLimit bounds how many audit entries a lookup by DLQ ID examines
+const replayScanLimit = 5000
 
-	// Create default admin user
-	user := &models.User{
-		ID:           "admin",
-		Username:     username,
-		PasswordHash: passwordHash,
-		Role:         "admin",
-		CreatedAt:    time.Now(),
-		UpdatedAt:    time.Now(),
+// ListReplays returns up to count replay audit entries, newest first. When dlqID is
+// set only replays of that DLQ entry are returned; the log is then paged newest
+// first and the lookup stops after replayScanLimit entries.
+func (r *RedisClient) ListReplays(ctx context.Context, dlqID string, count int64) ([]*models.ReplayAudit, error) {
+	batch := count
+	if dlqID != "" {
+		batch = dlqScanBatch
 	}
 
-	return r.StoreUser(ctx, user)
-}
+	audits := make([]*models.ReplayAudit, 0)
+	upper := "+"
+	scanned := 0
+	for scanned < replayScanLimit {
+		messages, err := r.client.XRevRangeN(ctx, r.replayAuditStream(), upper, "-", batch).Result()
+		if err != nil && err != redis.Nil {
+			return nil, models.NewRelayError(
+				models.ErrCodeStreamRead,
+				"failed to read replay audit log",
+				err,
+			)
+		}
 
-// Dead Letter Queue methods
+		for _, msg := range messages {
+			scanned++
+			upper = "(" + msg.ID
+			if dlqID != "" && msg.Values["dlq_id"] != dlqID {
+				continue
+			}
 
-// ReadDLQMessages reads messages from the dead letter queue
-func (r *RedisClient) ReadDLQMessages(ctx context.Context, count int64) ([]*models.RelayMessage, error) {
-	messages, err := r.client.XRevRange(ctx, r.config.DeadLetterQueue, "+", "-").Result()
-	if err != nil && err != redis.Nil {
-		return nil, models.NewRelayError(
-			models.ErrCodeStreamRead,
-			"failed to read DLQ messages",
-			err,
-		)
-	}
+			data, ok := msg.Values["data"].(string)
+			if !ok {
+				continue
+			}
 
-	var relayMessages []*models.RelayMessage
-	for i, msg := range messages {
-		if int64(i) >= count {
-			break
-		}
+			var audit models.ReplayAudit
+			if err := json.Unmarshal([]byte(data), &audit); err != nil {
+				continue
+			}
 
-		data, ok := msg.Values["data"].(string)
-		if !ok {
-			continue
+			audits = append(audits, &audit)
+			if int64(len(audits)) >= count {
+				return audits, nil
+			}
 		}
 
-		var relayMessage models.RelayMessage
-		if err := json.Unmarshal([]byte(data), &relayMessage); err != nil {
-			continue
+		// Without a DLQ ID the first page is the answer
+		if dlqID == "" || int64(len(messages)) < batch {
+			break
 		}
-
-		relayMessages = append(relayMessages, &relayMessage)
 	}
 
-	return relayMessages, nil
+	return audits, nil
 }
 
-// GetDLQMessage retrieves a specific message from the DLQ
-func (r *RedisClient) GetDLQMessage(ctx context.Context, messageID string) (*models.RelayMessage, error) {
-	// Scan DLQ for the message
-	messages, err := r.client.XRange(ctx, r.config.DeadLetterQueue, "-", "+").Result()
+// parseDLQEntry converts a DLQ stream entry into a DLQEntry
+func parseDLQEntry(message redis.XMessage) (*models.DLQEntry, error) {
+	relayMessage, err := ParseMessage(message)
 	if err != nil {
-		return nil, models.NewRelayError(
-			models.ErrCodeStreamRead,
-			"failed to read DLQ",
-			err,
-		)
+		return nil, err
 	}
 
-	for _, msg := range messages {
-		if msg.ID == messageID {
-			data, ok := msg.Values["data"].(string)
-			if !ok {
-				continue
-			}
-
-			var relayMessage models.RelayMessage
-			if err := json.Unmarshal([]byte(data), &relayMessage); err != nil {
-				continue
-			}
-
-			return &relayMessage, nil
+	entry := &models.DLQEntry{
+		ID:           message.ID,
+		RelayMessage: *relayMessage,
+	}
+	entry.OriginalID, _ = message.Values["original_id"].(string)
+	entry.ErrorCode, _ = message.Values["error_code"].(string)
+	entry.Error, _ = message.Values["error"].(string)
+	if movedAt, ok := message.Values["moved_at"].(string); ok {
+		if seconds, err := strconv.ParseInt(movedAt, 10, 64); err == nil {
+			entry.MovedAt = time.Unix(seconds, 0)
 		}
 	}
 
-	return nil, models.NewRelayError(
-		models.ErrCodeInvalidRequest,
-		"message not found in DLQ",
-		nil,
-	)
+	return entry, nil
+}
+
+// isStreamID reports whether id is a complete stream ID ("<ms>-<seq>")
+func isStreamID(id string) bool {
+	msPart, seqPart, ok := strings.Cut(id, "-")
+	if !ok {
+		return false
+	}
+	if _, err := strconv.ParseUint(msPart, 10, 64); err != nil {
+		return false
+	}
+	_, err := strconv.ParseUint(seqPart, 10, 64)
+	return err == nil
 }
 
 // DeleteDLQMessage deletes a message from the DLQ
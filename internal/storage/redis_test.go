@@ -0,0 +1,347 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/QuantumSolver/crm-relay/internal/models"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestCompareStreamIDs(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1700000000000-0", "1700000000000-0", 0},
+		{"1700000000000-1", "1700000000000-0", 1},
+		{"1699999999999-9", "1700000000000-0", -1},
+		{"0-0", "1700000000000-0", -1},
+		{"1700000000000-10", "1700000000000-9", 1},
+	}
+
+	for _, tt := range tests {
+		if got := compareStreamIDs(tt.a, tt.b); got != tt.want {
+			t.Errorf("compareStreamIDs(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestTrimMinID(t *testing.T) {
+	cutoff := "1700000000000-0"
+
+	tests := []struct {
+		name string
+		keep []string
+		want string
+	}{
+		{"nothing to keep", nil, cutoff},
+		{"everything delivered and acknowledged", []string{"1700000009000-0", "1700000009000-0"}, cutoff},
+		{"last delivered ID before the cutoff", []string{"1699999990000-3"}, "1699999990000-3"},
+		{"oldest pending entry before the cutoff", []string{"1700000009000-0", "1699999995000-0"}, "1699999995000-0"},
+		{"lowest of several groups", []string{"1700000009000-0", "1699999980000-0", "1699999990000-0", "1699999985000-1"}, "1699999980000-0"},
+		{"group that has not read anything", []string{"0-0"}, "0-0"},
+		{"empty IDs are ignored", []string{""}, cutoff},
+	}
+
+	for _, tt := range tests {
+		if got := trimMinID(cutoff, tt.keep...); got != tt.want {
+			t.Errorf("%s: expected %s, got %s", tt.name, tt.want, got)
+		}
+	}
+}
+
+func TestIsStreamID(t *testing.T) {
+	for _, id := range []string{"1700000000000-0", "0-1"} {
+		if !isStreamID(id) {
+			t.Errorf("Expected %q to be a stream ID", id)
+		}
+	}
+	for _, id := range []string{"", "1700000000000", "abc-1", "1-x", "+", "-"} {
+		if isStreamID(id) {
+			t.Errorf("Expected %q not to be a stream ID", id)
+		}
+	}
+}
+
+// recordingHook captures the commands a client sends instead of sending them
+type recordingHook struct {
+	commands [][]interface{}
+	result   interface{}       // value returned to script calls
+	respond  func(redis.Cmder) // sets the reply of other commands
+}
+
+func (h *recordingHook) DialHook(next redis.DialHook) redis.DialHook {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return nil, errors.New("no Redis in tests")
+	}
+}
+
+func (h *recordingHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		h.commands = append(h.commands, cmd.Args())
+		if c, ok := cmd.(*redis.Cmd); ok {
+			c.SetVal(h.result)
+		} else if h.respond != nil {
+			h.respond(cmd)
+		}
+		return nil
+	}
+}
+
+func (h *recordingHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		for _, cmd := range cmds {
+			h.commands = append(h.commands, cmd.Args())
+		}
+		return nil
+	}
+}
+
+// newRecordingClient returns a client whose commands are recorded by the hook
+func newRecordingClient(config *models.Config) (*RedisClient, *recordingHook) {
+	hook := &recordingHook{}
+	client := redis.NewClient(&redis.Options{Addr: "127.0.0.1:0"})
+	client.AddHook(hook)
+	return &RedisClient{client: client, config: config}, hook
+}
+
+func TestScheduleRetryAcknowledgesAfterQueueing(t *testing.T) {
+	config := &models.Config{StreamName: "webhooks", ConsumerGroup: "relay", RetryQueue: "webhooks:retry"}
+	r, hook := newRecordingClient(config)
+
+	retryAt := time.UnixMilli(1700000005000)
+	message := &models.RelayMessage{Webhook: models.Webhook{ID: "wh-1"}, RetryCount: 1}
+	if err := r.ScheduleRetry(context.Background(), "1700000000000-0", message, retryAt); err != nil {
+		t.Fatalf("Failed to schedule retry: %v", err)
+	}
+
+	var names []string
+	for _, args := range hook.commands {
+		names = append(names, strings.ToLower(fmt.Sprint(args[0])))
+	}
+	// The entry must only leave the pending list in the same transaction that
+	// queues it, so a failure never loses the message
+	if want := []string{"multi", "zadd", "xack", "exec"}; !slices.Equal(names, want) {
+		t.Fatalf("Expected commands %v, got %v", want, names)
+	}
+
+	zadd := hook.commands[1]
+	if zadd[1] != config.RetryQueue || zadd[2] != float64(retryAt.UnixMilli()) {
+		t.Errorf("Expected the retry scored at %d in %s, got %v", retryAt.UnixMilli(), config.RetryQueue, zadd)
+	}
+	var queued models.RelayMessage
+	if err := json.Unmarshal(zadd[3].([]byte), &queued); err != nil || queued.Webhook.ID != "wh-1" || queued.RetryCount != 1 {
+		t.Errorf("Expected the message to be queued, got %s (%v)", zadd[3], err)
+	}
+
+	xack := hook.commands[2]
+	if want := []interface{}{"xack", config.StreamName, config.ConsumerGroup, "1700000000000-0"}; !slices.Equal(xack, want) {
+		t.Errorf("Expected %v, got %v", want, xack)
+	}
+}
+
+func TestPromoteDueRetriesUsesNowAsCutoff(t *testing.T) {
+	config := &models.Config{StreamName: "webhooks", RetryQueue: "webhooks:retry"}
+	r, hook := newRecordingClient(config)
+	hook.result = int64(2)
+
+	now := time.UnixMilli(1700000005000)
+	promoted, err := r.PromoteDueRetries(context.Background(), now, 100)
+	if err != nil {
+		t.Fatalf("Failed to promote retries: %v", err)
+	}
+	if promoted != 2 {
+		t.Errorf("Expected 2 promoted retries, got %d", promoted)
+	}
+
+	// Only entries scored at or before now are due: the script gets the retry
+	// queue and stream as keys and now in milliseconds as the cutoff
+	args := fmt.Sprint(hook.commands[0])
+	want := fmt.Sprint([]interface{}{"evalsha", promoteRetriesScript.Hash(), 2, config.RetryQueue, config.StreamName, now.UnixMilli(), 100})
+	if args != want {
+		t.Errorf("Expected %s, got %s", want, args)
+	}
+}
+
+func TestTrimStreamKeepsUndeliveredAndPendingEntries(t *testing.T) {
+	config := &models.Config{StreamName: "webhooks", MessageTTL: 3600}
+	r, hook := newRecordingClient(config)
+	hook.respond = func(cmd redis.Cmder) {
+		switch c := cmd.(type) {
+		case *redis.XInfoGroupsCmd:
+			c.SetVal([]redis.XInfoGroup{
+				{Name: "caught-up", LastDeliveredID: "1700000009000-0"},
+				{Name: "pending", LastDeliveredID: "1700000008000-0", Pending: 2},
+				{Name: "behind", LastDeliveredID: "1699999998000-0"},
+			})
+		case *redis.XPendingCmd:
+			c.SetVal(&redis.XPending{Count: 2, Lower: "1699999997000-5", Higher: "1700000008000-0"})
+		}
+	}
+
+	// The TTL cutoff is 1700000000000-0; the pending group's oldest entry is older
+	now := time.UnixMilli(1700000000000).Add(time.Hour)
+	if _, err := r.TrimStream(context.Background(), now); err != nil {
+		t.Fatalf("Failed to trim stream: %v", err)
+	}
+
+	xtrim := fmt.Sprint(hook.commands[len(hook.commands)-1])
+	if want := fmt.Sprint([]interface{}{"xtrim", "webhooks", "minid", "~", "1699999997000-5"}); xtrim != want {
+		t.Errorf("Expected %s, got %s", want, xtrim)
+	}
+}
+
+func TestTrimDeadLetterQueueUsesTTLCutoff(t *testing.T) {
+	config := &models.Config{StreamName: "webhooks", DeadLetterQueue: "webhooks:dlq", DLQTTL: 86400, DLQMaxLen: 1000}
+	r, hook := newRecordingClient(config)
+
+	now := time.UnixMilli(1700000000000).Add(24 * time.Hour)
+	if _, _, err := r.TrimDeadLetterQueue(context.Background(), now); err != nil {
+		t.Fatalf("Failed to trim dead letter queue: %v", err)
+	}
+
+	// The DLQ has no consumer groups, so only its TTL and length bound the trim
+	want := []string{
+		fmt.Sprint([]interface{}{"xtrim", "webhooks:dlq", "minid", "~", "1700000000000-0"}),
+		fmt.Sprint([]interface{}{"xtrim", "webhooks:dlq", "maxlen", "~", 1000}),
+	}
+	for i, w := range want {
+		if got := fmt.Sprint(hook.commands[i]); got != w {
+			t.Errorf("Expected %s, got %s", w, got)
+		}
+	}
+}
+
+func TestClaimStaleMessagesSkipsEntriesInFlight(t *testing.T) {
+	config := &models.Config{StreamName: "webhooks", ConsumerGroup: "relay", ConsumerName: "client-1"}
+	r, hook := newRecordingClient(config)
+	hook.respond = func(cmd redis.Cmder) {
+		switch c := cmd.(type) {
+		case *redis.XPendingExtCmd:
+			c.SetVal([]redis.XPendingExt{
+				{ID: "1-0", Consumer: "client-2", RetryCount: 1},
+				{ID: "2-0", Consumer: "client-1", RetryCount: 1},
+				{ID: "3-0", Consumer: "client-2", RetryCount: 4},
+			})
+		case *redis.XMessageSliceCmd:
+			c.SetVal([]redis.XMessage{{ID: "1-0"}, {ID: "3-0"}})
+		}
+	}
+
+	inFlight := func(id string) bool { return id == "2-0" }
+	claimed, next, err := r.ClaimStaleMessages(context.Background(), "0-0", time.Minute, 3, inFlight)
+	if err != nil {
+		t.Fatalf("Failed to claim stale messages: %v", err)
+	}
+
+	// Only the stale entries are claimed; the one in flight keeps its owner and count
+	xpending := fmt.Sprint(hook.commands[0])
+	if want := fmt.Sprint([]interface{}{"xpending", "webhooks", "relay", "idle", int64(60000), "0-0", "+", 3}); xpending != want {
+		t.Errorf("Expected %s, got %s", want, xpending)
+	}
+	xclaim := fmt.Sprint(hook.commands[1])
+	if want := fmt.Sprint([]interface{}{"xclaim", "webhooks", "relay", "client-1", int64(60000), "1-0", "3-0"}); xclaim != want {
+		t.Errorf("Expected %s, got %s", want, xclaim)
+	}
+
+	if len(claimed) != 2 || claimed[0].DeliveryCount != 2 || claimed[1].DeliveryCount != 5 {
+		t.Errorf("Expected 1-0 and 3-0 with their claim counted, got %+v", claimed)
+	}
+	if next != "3-1" {
+		t.Errorf("Expected the cursor to continue after 3-0, got %s", next)
+	}
+}
+
+func TestTrimDeadLetterQueueCapIsOptIn(t *testing.T) {
+	config := &models.Config{StreamName: "webhooks", DeadLetterQueue: "webhooks:dlq", DLQTTL: 86400}
+	r, hook := newRecordingClient(config)
+
+	if _, dropped, err := r.TrimDeadLetterQueue(context.Background(), time.Now()); err != nil || dropped != 0 {
+		t.Fatalf("Expected nothing dropped, got %d (%v)", dropped, err)
+	}
+	for _, args := range hook.commands {
+		if fmt.Sprint(args[2]) == "maxlen" {
+			t.Errorf("Expected no length cap without DLQ_MAX_LEN, got %v", args)
+		}
+	}
+
+	// With a cap, the entries it drops are reported apart from expired ones
+	config.DLQMaxLen = 1000
+	hook.commands = nil
+	hook.respond = func(cmd redis.Cmder) {
+		if c, ok := cmd.(*redis.IntCmd); ok && fmt.Sprint(cmd.Args()[2]) == "maxlen" {
+			c.SetVal(25)
+		}
+	}
+	if _, dropped, err := r.TrimDeadLetterQueue(context.Background(), time.Now()); err != nil || dropped != 25 {
+		t.Errorf("Expected 25 entries dropped by the cap, got %d (%v)", dropped, err)
+	}
+}
+
+func TestListReplaysPagesLookupsByDLQID(t *testing.T) {
+	config := &models.Config{DeadLetterQueue: "webhooks:dlq"}
+	r, hook := newRecordingClient(config)
+
+	// An audit log of 10000 entries where every 1000th replays dlq-a
+	const size = 10000
+	hook.respond = func(cmd redis.Cmder) {
+		c, ok := cmd.(*redis.XMessageSliceCmd)
+		if !ok {
+			return
+		}
+		args := cmd.Args()
+		upper := size + 1
+		if start := fmt.Sprint(args[2]); start != "+" {
+			upper, _ = strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(start, "("), "-0"))
+		}
+		count, _ := strconv.Atoi(fmt.Sprint(args[5]))
+
+		var messages []redis.XMessage
+		for i := upper - 1; i > 0 && len(messages) < count; i-- {
+			dlqID := "dlq-b"
+			if i%1000 == 0 {
+				dlqID = "dlq-a"
+			}
+			messages = append(messages, redis.XMessage{
+				ID:     fmt.Sprintf("%d-0", i),
+				Values: map[string]interface{}{"dlq_id": dlqID, "data": fmt.Sprintf(`{"dlq_id":%q,"replay_id":"%d-0"}`, dlqID, i)},
+			})
+		}
+		c.SetVal(messages)
+	}
+
+	// Without a DLQ ID a single page is read
+	replays, err := r.ListReplays(context.Background(), "", 50)
+	if err != nil || len(replays) != 50 || len(hook.commands) != 1 {
+		t.Fatalf("Expected 50 replays from one query, got %d from %d queries (%v)", len(replays), len(hook.commands), err)
+	}
+
+	// A lookup stops as soon as it has count matches
+	hook.commands = nil
+	replays, err = r.ListReplays(context.Background(), "dlq-a", 2)
+	if err != nil || len(replays) != 2 || replays[0].ReplayID != "10000-0" || replays[1].ReplayID != "9000-0" {
+		t.Fatalf("Expected the two newest replays of dlq-a, got %v (%v)", replays, err)
+	}
+	if len(hook.commands) != 6 {
+		t.Errorf("Expected 6 pages of %d to reach the second match, got %d", dlqScanBatch, len(hook.commands))
+	}
+
+	// and otherwise after replayScanLimit entries
+	hook.commands = nil
+	replays, err = r.ListReplays(context.Background(), "dlq-a", 100)
+	if err != nil || len(replays) != 5 {
+		t.Fatalf("Expected the 5 replays within the scan limit, got %d (%v)", len(replays), err)
+	}
+	if len(hook.commands) != replayScanLimit/dlqScanBatch {
+		t.Errorf("Expected %d pages, got %d", replayScanLimit/dlqScanBatch, len(hook.commands))
+	}
+}
@@ -0,0 +1,101 @@
+// Package tracing sets up OpenTelemetry tracing and carries W3C trace context
+// through relay messages stored in Redis
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/QuantumSolver/crm-relay/internal/models"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/QuantumSolver/crm-relay"
+
+// propagator is used for traceparent headers and the trace context stored on messages
+var propagator = propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{})
+
+// Setup installs the global tracer provider for the configured exporter and returns
+// a function that flushes and stops it. With the "none" exporter spans are not recorded.
+func Setup(ctx context.Context, config *models.Config, serviceName string) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagator)
+
+	var exporter sdktrace.SpanExporter
+	var err error
+	switch config.TracingExporter {
+	case models.TracingExporterStdout:
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+	case models.TracingExporterOTLP:
+		// Endpoint, headers and TLS come from the standard OTEL_EXPORTER_OTLP_* variables
+		exporter, err = otlptracehttp.New(ctx)
+	default:
+		return func(context.Context) error { return nil }, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s trace exporter: %w", config.TracingExporter, err)
+	}
+
+	// OTEL_SERVICE_NAME and OTEL_RESOURCE_ATTRIBUTES override the defaults
+	res, err := resource.New(ctx,
+		resource.WithSchemaURL(semconv.SchemaURL),
+		resource.WithAttributes(semconv.ServiceName(serviceName)),
+		resource.WithTelemetrySDK(),
+		resource.WithFromEnv(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create trace resource: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(config.TracingSampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+
+	return provider.Shutdown, nil
+}
+
+// Start starts a span with the relay tracer
+func Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	return otel.Tracer(tracerName).Start(ctx, name, opts...)
+}
+
+// End records err on the span, if any, and ends it
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// Inject returns the trace context of ctx for storing on a relay message, or nil
+// when ctx carries no span
+func Inject(ctx context.Context) map[string]string {
+	carrier := propagation.MapCarrier{}
+	propagator.Inject(ctx, carrier)
+	if len(carrier) == 0 {
+		return nil
+	}
+	return carrier
+}
+
+// Extract returns ctx with the trace context stored on a relay message
+func Extract(ctx context.Context, traceContext map[string]string) context.Context {
+	return propagator.Extract(ctx, propagation.MapCarrier(traceContext))
+}
+
+// InjectHeaders writes the traceparent of ctx into outgoing request headers
+func InjectHeaders(ctx context.Context, header propagation.HeaderCarrier) {
+	propagator.Inject(ctx, header)
+}
@@ -0,0 +1,34 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestInjectExtract(t *testing.T) {
+	if carrier := Inject(context.Background()); carrier != nil {
+		t.Errorf("Expected no trace context without a span, got %v", carrier)
+	}
+
+	provider := sdktrace.NewTracerProvider()
+	defer provider.Shutdown(context.Background())
+
+	ctx, span := provider.Tracer("test").Start(context.Background(), "ingest")
+	defer span.End()
+
+	carrier := Inject(ctx)
+	if carrier["traceparent"] == "" {
+		t.Fatalf("Expected traceparent in %v", carrier)
+	}
+
+	extracted := trace.SpanContextFromContext(Extract(context.Background(), carrier))
+	if extracted.TraceID() != span.SpanContext().TraceID() || extracted.SpanID() != span.SpanContext().SpanID() {
+		t.Errorf("Expected extracted span context %v, got %v", span.SpanContext(), extracted)
+	}
+	if !extracted.IsRemote() {
+		t.Error("Expected extracted span context to be remote")
+	}
+}
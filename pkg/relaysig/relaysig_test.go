@@ -0,0 +1,68 @@
+package relaysig
+
+import (
+	"encoding/base64"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestSignAndVerify(t *testing.T) {
+	secret := []byte("relay-secret")
+	body := []byte(`{"lead":42}`)
+	now := time.Unix(1700000000, 0)
+
+	header := http.Header{}
+	SetHeaders(header, secret, "msg_1", now, body)
+
+	if err := Verify(secret, header, body, now, 0); err != nil {
+		t.Errorf("Expected signature to verify, got %v", err)
+	}
+
+	if err := Verify([]byte("other-secret"), header, body, now, 0); err != ErrSignatureMismatch {
+		t.Errorf("Expected mismatch for wrong secret, got %v", err)
+	}
+
+	if err := Verify(secret, header, []byte(`{"lead":43}`), now, 0); err != ErrSignatureMismatch {
+		t.Errorf("Expected mismatch for modified body, got %v", err)
+	}
+
+	if err := Verify(secret, header, body, now.Add(10*time.Minute), 0); err != ErrInvalidTimestamp {
+		t.Errorf("Expected stale timestamp to be rejected, got %v", err)
+	}
+
+	if err := Verify(secret, http.Header{}, body, now, 0); err != ErrMissingHeaders {
+		t.Errorf("Expected missing headers error, got %v", err)
+	}
+}
+
+func TestVerifyMultipleSignatures(t *testing.T) {
+	secret := []byte("new-secret")
+	body := []byte("payload")
+	now := time.Unix(1700000000, 0)
+
+	header := http.Header{}
+	SetHeaders(header, secret, "msg_2", now, body)
+	header.Set(HeaderSignature, Sign([]byte("old-secret"), "msg_2", now, body)+" "+header.Get(HeaderSignature))
+
+	if err := Verify(secret, header, body, now, 0); err != nil {
+		t.Errorf("Expected one of several signatures to verify, got %v", err)
+	}
+}
+
+func TestDecodeSecret(t *testing.T) {
+	raw := []byte{0x01, 0x02, 0x03}
+	decoded, err := DecodeSecret("whsec_" + base64.StdEncoding.EncodeToString(raw))
+	if err != nil || string(decoded) != string(raw) {
+		t.Errorf("Expected prefixed secret to be base64-decoded, got %v, %v", decoded, err)
+	}
+
+	decoded, err = DecodeSecret("plain")
+	if err != nil || string(decoded) != "plain" {
+		t.Errorf("Expected plain secret to be used as-is, got %v, %v", decoded, err)
+	}
+
+	if _, err := DecodeSecret("whsec_!!!"); err == nil {
+		t.Error("Expected error for invalid base64 secret")
+	}
+}
@@ -1,12 +1,17 @@
-import axios, { AxiosInstance, AxiosError } from 'axios';
+import axios, { AxiosInstance, AxiosError, InternalAxiosRequestConfig } from 'axios';
 
 // API base URL - use relative path to work with any domain
 const API_BASE_URL = import.meta.env.VITE_API_BASE_URL || '';
 
 // Types
 export interface User {
+  id: string;
   username: string;
+  role: 'admin' | 'operator' | 'viewer';
+  disabled: boolean;
   created_at: string;
+  updated_at: string;
+  permissions?: string[];
 }
 
 export interface LoginRequest {
@@ -17,6 +22,26 @@ export interface LoginRequest {
 export interface LoginResponse {
   token: string;
   user: User;
+  expires_at: number;
+  refresh_token: string;
+  refresh_expires_at: number;
+}
+
+export interface WindowSummary {
+  count: number;
+  errors: number;
+  rate_per_sec: number;
+  error_rate: number;
+  mean_ms: number;
+  p50_ms: number;
+  p95_ms: number;
+  p99_ms: number;
+}
+
+export interface LatencySummary {
+  total: number;
+  last_observed?: string;
+  windows: Record<'1m' | '5m' | '1h', WindowSummary>;
 }
 
 export interface Metrics {
@@ -25,7 +50,8 @@ export interface Metrics {
   webhooks_failed: number;
   webhooks_retried: number;
   average_latency_ms: number;
-  last_webhook_time: string;
+  last_webhook_time: string | null;
+  forward?: LatencySummary;
   // Additional fields may be present
   queue_depth?: number;
   pending_messages?: number;
@@ -74,17 +100,60 @@ apiClient.interceptors.request.use((config) => {
   return config;
 });
 
+// Called with the new tokens after a refresh so the auth store can keep them
+let onTokensRefreshed: ((response: LoginResponse) => void) | null = null;
+
+export const setTokensRefreshedHandler = (handler: (response: LoginResponse) => void) => {
+  onTokensRefreshed = handler;
+};
+
+// Exchange the stored refresh token for new tokens; concurrent 401s share one refresh
+let refreshing: Promise<string | null> | null = null;
+
+const refreshAccessToken = (): Promise<string | null> => {
+  if (!refreshing) {
+    refreshing = (async () => {
+      try {
+        const parsed = JSON.parse(localStorage.getItem('auth-storage') || '{}');
+        const refreshToken = parsed?.state?.refreshToken;
+        if (!refreshToken) {
+          return null;
+        }
+        const response = await axios.post<LoginResponse>(`${API_BASE_URL}/api/auth/refresh`, {
+          refresh_token: refreshToken,
+        });
+        onTokensRefreshed?.(response.data);
+        return response.data.token;
+      } catch (e) {
+        return null;
+      } finally {
+        refreshing = null;
+      }
+    })();
+  }
+  return refreshing;
+};
+
 // Handle 401 errors
 apiClient.interceptors.response.use(
   (response) => response,
-  (error: AxiosError) => {
+  async (error: AxiosError) => {
     if (error.response?.status === 401) {
-      // Don't intercept 401 from login endpoint — let the auth store handle it
+      // Don't intercept 401 from login, refresh or logout — let the auth store handle it
       const requestUrl = error.config?.url || '';
-      if (requestUrl.includes('/api/auth/login')) {
+      if (/\/api\/auth\/(login|refresh|logout)/.test(requestUrl)) {
         return Promise.reject(error);
       }
-      // Clear auth storage for other 401s (expired token, etc.)
+      // The access token expired or was revoked; retry once with a refreshed one
+      const config = error.config as (InternalAxiosRequestConfig & { _retried?: boolean }) | undefined;
+      if (config && !config._retried) {
+        const token = await refreshAccessToken();
+        if (token) {
+          config._retried = true;
+          return apiClient(config);
+        }
+      }
+      // Clear auth storage when the session has ended
       localStorage.removeItem('auth-storage');
       window.location.href = '/login';
     }
@@ -103,6 +172,14 @@ export const authApi = {
     const response = await apiClient.get<User>('/api/auth/me');
     return response.data;
   },
+
+  logout: async (): Promise<void> => {
+    await apiClient.post('/api/auth/logout');
+  },
+
+  logoutAll: async (): Promise<void> => {
+    await apiClient.post('/api/auth/logout-all');
+  },
 };
 
 // Config API
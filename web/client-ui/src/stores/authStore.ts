@@ -1,10 +1,11 @@
 import { create } from 'zustand';
 import { persist } from 'zustand/middleware';
-import { authApi, User, LoginRequest } from '../lib/api';
+import { authApi, setTokensRefreshedHandler, User, LoginRequest } from '../lib/api';
 
 interface AuthState {
   user: User | null;
   token: string | null;
+  refreshToken: string | null;
   isAuthenticated: boolean;
   isLoading: boolean;
   error: string | null;
@@ -19,6 +20,7 @@ export const useAuthStore = create<AuthState>()(
     (set) => ({
       user: null,
       token: null,
+      refreshToken: null,
       isAuthenticated: false,
       isLoading: false,
       error: null,
@@ -30,6 +32,7 @@ export const useAuthStore = create<AuthState>()(
           set({
             user: response.user,
             token: response.token,
+            refreshToken: response.refresh_token,
             isAuthenticated: true,
             isLoading: false,
           });
@@ -43,9 +46,14 @@ export const useAuthStore = create<AuthState>()(
       },
 
       logout: () => {
+        // End the session on the server too; the local state is cleared either way
+        if (useAuthStore.getState().token) {
+          authApi.logout().catch(() => {});
+        }
         set({
           user: null,
           token: null,
+          refreshToken: null,
           isAuthenticated: false,
         });
       },
@@ -70,6 +78,7 @@ export const useAuthStore = create<AuthState>()(
           set({
             user: null,
             token: null,
+            refreshToken: null,
             isAuthenticated: false,
             isLoading: false,
           });
@@ -83,8 +92,18 @@ export const useAuthStore = create<AuthState>()(
       partialize: (state) => ({
         user: state.user,
         token: state.token,
+        refreshToken: state.refreshToken,
         isAuthenticated: state.isAuthenticated,
       }),
     }
   )
 );
+
+// Keep the tokens issued when the API client refreshes an expired access token
+setTokensRefreshedHandler((response) => {
+  useAuthStore.setState({
+    user: response.user,
+    token: response.token,
+    refreshToken: response.refresh_token,
+  });
+});
@@ -28,17 +28,59 @@ api.interceptors.request.use((config) => {
   return config;
 });
 
+// Called with the new tokens after a refresh so the auth store can keep them
+let onTokensRefreshed: ((response: LoginResponse) => void) | null = null;
+
+export const setTokensRefreshedHandler = (handler: (response: LoginResponse) => void) => {
+  onTokensRefreshed = handler;
+};
+
+// Exchange the stored refresh token for new tokens; concurrent 401s share one refresh
+let refreshing: Promise<string | null> | null = null;
+
+const refreshAccessToken = (): Promise<string | null> => {
+  if (!refreshing) {
+    refreshing = (async () => {
+      try {
+        const parsed = JSON.parse(localStorage.getItem('auth-storage') || '{}');
+        const refreshToken = parsed?.state?.refreshToken;
+        if (!refreshToken) {
+          return null;
+        }
+        const response = await axios.post<LoginResponse>(`${API_BASE_URL}/api/auth/refresh`, {
+          refresh_token: refreshToken,
+        });
+        onTokensRefreshed?.(response.data);
+        return response.data.token;
+      } catch (e) {
+        return null;
+      } finally {
+        refreshing = null;
+      }
+    })();
+  }
+  return refreshing;
+};
+
 // Handle auth errors
 api.interceptors.response.use(
   (response) => response,
-  (error) => {
+  async (error) => {
     if (error.response?.status === 401) {
-      // Don't intercept 401 from login endpoint — let the auth store handle it
+      // Don't intercept 401 from login, refresh or logout — let the auth store handle it
       const requestUrl = error.config?.url || '';
-      if (requestUrl.includes('/api/auth/login')) {
+      if (/\/api\/auth\/(login|refresh|logout)/.test(requestUrl)) {
         return Promise.reject(error);
       }
-      // Clear auth storage for other 401s (expired token, etc.)
+      // The access token expired or was revoked; retry once with a refreshed one
+      if (error.config && !error.config._retried) {
+        const token = await refreshAccessToken();
+        if (token) {
+          error.config._retried = true;
+          return api(error.config);
+        }
+      }
+      // Clear auth storage when the session has ended
       localStorage.removeItem('auth-storage');
       window.location.href = '/login';
     }
@@ -59,6 +101,8 @@ export interface LoginResponse {
     role: string;
   };
   expires_at: number;
+  refresh_token: string;
+  refresh_expires_at: number;
 }
 
 export interface APIKey {
@@ -82,10 +126,38 @@ export interface WebhookEndpoint {
     retry_delay: number;
     retry_multiplier: number;
   };
+  has_app_secret: boolean;
+  has_verify_token: boolean;
+  signature?: {
+    scheme: string;
+    secret_set: boolean;
+    header?: string;
+    algorithm?: string;
+    encoding?: string;
+    prefix?: string;
+    tolerance?: number;
+  };
   created_at: string;
   updated_at: string;
 }
 
+export interface WindowSummary {
+  count: number;
+  errors: number;
+  rate_per_sec: number;
+  error_rate: number;
+  mean_ms: number;
+  p50_ms: number;
+  p95_ms: number;
+  p99_ms: number;
+}
+
+export interface LatencySummary {
+  total: number;
+  last_observed?: string;
+  windows: Record<'1m' | '5m' | '1h', WindowSummary>;
+}
+
 export interface Metrics {
   webhooks_received: number;
   webhooks_processed: number;
@@ -94,7 +166,8 @@ export interface Metrics {
   queue_depth: number;
   pending_messages: number;
   average_latency_ms: number;
-  last_webhook_time: string;
+  last_webhook_time: string | null;
+  ingest?: LatencySummary;
 }
 
 // Auth API
@@ -108,6 +181,14 @@ export const authApi = {
     const response = await api.get('/api/auth/me');
     return response.data;
   },
+
+  logout: async (): Promise<void> => {
+    await api.post('/api/auth/logout');
+  },
+
+  logoutAll: async (): Promise<void> => {
+    await api.post('/api/auth/logout-all');
+  },
 };
 
 // API Keys API
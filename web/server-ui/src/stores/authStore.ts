@@ -1,6 +1,6 @@
 import { create } from 'zustand';
 import { persist } from 'zustand/middleware';
-import { authApi } from '../lib/api';
+import { authApi, setTokensRefreshedHandler } from '../lib/api';
 import type { LoginRequest, LoginResponse } from '../lib/api';
 
 interface User {
@@ -12,6 +12,7 @@ interface User {
 interface AuthState {
   user: User | null;
   token: string | null;
+  refreshToken: string | null;
   isAuthenticated: boolean;
   isLoading: boolean;
   error: string | null;
@@ -26,6 +27,7 @@ export const useAuthStore = create<AuthState>()(
     (set) => ({
       user: null,
       token: null,
+      refreshToken: null,
       isAuthenticated: false,
       isLoading: false,
       error: null,
@@ -37,6 +39,7 @@ export const useAuthStore = create<AuthState>()(
           set({
             user: response.user,
             token: response.token,
+            refreshToken: response.refresh_token,
             isAuthenticated: true,
             isLoading: false,
           });
@@ -50,9 +53,14 @@ export const useAuthStore = create<AuthState>()(
       },
 
       logout: () => {
+        // End the session on the server too; the local state is cleared either way
+        if (useAuthStore.getState().token) {
+          authApi.logout().catch(() => {});
+        }
         set({
           user: null,
           token: null,
+          refreshToken: null,
           isAuthenticated: false,
         });
       },
@@ -77,6 +85,7 @@ export const useAuthStore = create<AuthState>()(
           set({
             user: null,
             token: null,
+            refreshToken: null,
             isAuthenticated: false,
             isLoading: false,
           });
@@ -90,8 +99,18 @@ export const useAuthStore = create<AuthState>()(
       partialize: (state) => ({
         user: state.user,
         token: state.token,
+        refreshToken: state.refreshToken,
         isAuthenticated: state.isAuthenticated,
       }),
     }
   )
 );
+
+// Keep the tokens issued when the API client refreshes an expired access token
+setTokensRefreshedHandler((response) => {
+  useAuthStore.setState({
+    user: response.user,
+    token: response.token,
+    refreshToken: response.refresh_token,
+  });
+});